@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LoadAllowedColors reads a brand allow-list of hex colors. Colors may be separated
+// by newlines, commas, or spaces, and may carry a leading '#' (e.g., #1F4E79).
+// Anything after a '#' that does not start a hex color is a comment, as are blank lines.
+// Colors are returned uppercased.
+func LoadAllowedColors(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowed colors: %w", err)
+	}
+	defer file.Close()
+
+	allowed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		for _, field := range fields {
+			color := strings.TrimPrefix(field, "#")
+			if !isValidHexColor(color) {
+				if strings.HasPrefix(field, "#") {
+					break // Comment runs to the end of the line
+				}
+				return nil, fmt.Errorf("%s:%d: invalid hex color '%s'", path, lineNum, field)
+			}
+			allowed[strings.ToUpper(color)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read allowed colors: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no colors found in %s", path)
+	}
+
+	return allowed, nil
+}
+
+// ValidateAllowedColors checks that every hex target in a color mapping is on the
+// allow-list. Scheme color targets are always allowed, since they defer to the theme.
+// listName identifies the allow-list in the error message.
+func ValidateAllowedColors(colorMapping map[string]string, allowed map[string]bool, listName string) error {
+	sources := make([]string, 0, len(colorMapping))
+	for source := range colorMapping {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		target := colorMapping[source]
+		if isValidHexColor(target) && !allowed[strings.ToUpper(target)] {
+			return fmt.Errorf("target color '%s' in mapping '%s:%s' is not in the allowed colors list (%s)",
+				target, source, target, listName)
+		}
+	}
+
+	return nil
+}
+
+// ParseProtectedColors parses the colors given to --protect: scheme colors (any
+// casing) and hex values. A protected scheme color also covers the color map alias
+// that refers to it under the default color map, and vice versa (e.g., dk1 and tx1).
+// Returns each covered color, hex uppercased, keyed to the protected color that
+// covers it.
+func ParseProtectedColors(colors []string) (map[string]string, error) {
+	protected := make(map[string]string)
+	for _, color := range colors {
+		color = strings.TrimPrefix(strings.TrimSpace(color), "#")
+		if canonical, ok := canonicalSchemeColor(color); ok {
+			color = canonical
+		} else if isValidHexColor(color) {
+			color = strings.ToUpper(color)
+		} else {
+			return nil, &ErrInvalidColor{Color: color, Side: "protected"}
+		}
+		protected[color] = color
+	}
+
+	for alias, slot := range defaultColorMap {
+		if given, ok := protected[slot]; ok {
+			if _, ok := protected[alias]; !ok {
+				protected[alias] = given
+			}
+		} else if given, ok := protected[alias]; ok {
+			protected[slot] = given
+		}
+	}
+
+	return protected, nil
+}
+
+// ValidateProtectedColors checks that no mapping in a color mapping has a protected
+// color (see ParseProtectedColors) as its source or target
+func ValidateProtectedColors(colorMapping map[string]string, protected map[string]string) error {
+	sources := make([]string, 0, len(colorMapping))
+	for source := range colorMapping {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		target := colorMapping[source]
+		for _, color := range []string{source, target} {
+			if given, ok := protected[protectedKey(color)]; ok {
+				return &ErrProtectedColor{Color: given, Source: source, Target: target}
+			}
+		}
+	}
+
+	return nil
+}
+
+// protectedKey returns the spelling of a mapping color used in the protected set
+func protectedKey(color string) string {
+	if isValidHexColor(color) {
+		return strings.ToUpper(color)
+	}
+	return color
+}
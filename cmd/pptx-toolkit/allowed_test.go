@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAllowedColors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "one per line with comments",
+			content: "# Brand palette\n1f4e79\n#C00000  # brand red\n\n",
+			want:    []string{"1F4E79", "C00000"},
+		},
+		{
+			name:    "comma separated",
+			content: "1F4E79, C00000,FFFFFF",
+			want:    []string{"1F4E79", "C00000", "FFFFFF"},
+		},
+		{
+			name:    "invalid color",
+			content: "1F4E79\nnavy\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty list",
+			content: "# nothing here\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "brand.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			allowed, err := LoadAllowedColors(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadAllowedColors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(allowed) != len(tt.want) {
+				t.Errorf("got %d colors, want %d: %v", len(allowed), len(tt.want), allowed)
+			}
+			for _, color := range tt.want {
+				if !allowed[color] {
+					t.Errorf("expected %s to be allowed", color)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAllowedColors(t *testing.T) {
+	allowed := map[string]bool{"1F4E79": true, "C00000": true}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		wantErr string
+	}{
+		{name: "allowed hex target", mapping: map[string]string{"accent1": "1f4e79"}},
+		{name: "scheme target always allowed", mapping: map[string]string{"AABBCC": "accent2", "accent1": "accent3"}},
+		{name: "hex to hex allowed", mapping: map[string]string{"FF0000": "C00000"}},
+		{name: "scheme to disallowed hex", mapping: map[string]string{"accent1": "FF0000"}, wantErr: "'FF0000' in mapping 'accent1:FF0000'"},
+		{name: "hex to disallowed hex", mapping: map[string]string{"C00000": "00FF00"}, wantErr: "'00FF00'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAllowedColors(tt.mapping, allowed, "brand.txt")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "brand.txt") {
+				t.Errorf("expected error mentioning %q and the list, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateProtectedColors(t *testing.T) {
+	protected, err := ParseProtectedColors([]string{"DK1", "#c00000"})
+	if err != nil {
+		t.Fatalf("ParseProtectedColors() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		wantErr string
+	}{
+		{name: "unprotected colors", mapping: map[string]string{"accent1": "1F4E79", "lt1": "dk2"}},
+		{name: "protected source", mapping: map[string]string{"dk1": "accent1"}, wantErr: "mapping 'dk1:accent1' touches protected color 'dk1'"},
+		{name: "protected hex target", mapping: map[string]string{"accent2": "c00000"}, wantErr: "mapping 'accent2:c00000' touches protected color 'C00000'"},
+		{name: "alias of protected slot", mapping: map[string]string{"tx1": "accent2"}, wantErr: "mapping 'tx1:accent2' touches protected color 'dk1'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProtectedColors(tt.mapping, protected)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateProtectedColors() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := ParseProtectedColors([]string{"navy"}); err == nil {
+		t.Errorf("ParseProtectedColors() accepted an invalid color")
+	}
+}
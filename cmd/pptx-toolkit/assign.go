@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isRoleAssignment reports whether a mapping string uses role assignment
+// syntax ("hlink=accent1") rather than swap syntax ("accent1:FF0000")
+func isRoleAssignment(mappingStr string) bool {
+	return strings.Contains(mappingStr, "=")
+}
+
+// ParseRoleAssignment parses a role assignment string into a validated map of
+// scheme slot → color source.
+//
+// Each pair is "slot=source": the slot is a scheme color name and the source is a
+// scheme color name (resolved against the current theme) or a 6-digit hex color.
+//
+// Examples:
+//   - "hlink=accent1" -> make the hyperlink color match accent1
+//   - "hlink=accent1,folHlink=accent2"
+//   - "accent6=1F4E79" -> set accent6 to a fixed color
+func ParseRoleAssignment(assignmentStr string) (map[string]string, error) {
+	assignmentStr = strings.TrimSpace(assignmentStr)
+	if assignmentStr == "" {
+		return nil, fmt.Errorf("assignment string cannot be empty")
+	}
+
+	if strings.Contains(assignmentStr, ":") {
+		return nil, fmt.Errorf("cannot mix role assignments (slot=source) with swaps (source:target)")
+	}
+
+	assignments := make(map[string]string)
+	for _, pair := range strings.Split(assignmentStr, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid assignment format: '%s'. Expected 'slot=source'", pair)
+		}
+
+		slot := strings.TrimSpace(parts[0])
+		source := strings.TrimSpace(parts[1])
+
+		if !ValidSchemeColors[slot] {
+			return nil, fmt.Errorf("invalid slot: '%s'. Must be a scheme color (%s)", slot, getValidColorsString())
+		}
+		if !isValidColor(source) {
+			return nil, fmt.Errorf("invalid source color: '%s'. Must be a valid scheme color (%s) or 6-digit hex color (e.g., AABBCC)",
+				source, getValidColorsString())
+		}
+
+		if existing, exists := assignments[slot]; exists && existing != source {
+			return nil, fmt.Errorf("conflicting assignments for '%s':\n  - %s=%s\n  - %s=%s",
+				slot, slot, existing, slot, source)
+		}
+		assignments[slot] = source
+	}
+
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("no valid assignments found")
+	}
+
+	return assignments, nil
+}
+
+// resolveRoleAssignment turns slot → source assignments into slot → hex changes
+// using a theme's current colors. All sources are resolved before any slot changes,
+// so "hlink=accent1,accent1=accent2" gives hlink the original accent1.
+func resolveRoleAssignment(colors ColorScheme, assignments map[string]string) map[string]string {
+	changes := make(map[string]string, len(assignments))
+	for slot, source := range assignments {
+		if isValidHexColor(source) {
+			changes[slot] = strings.ToUpper(source)
+		} else {
+			changes[slot] = colors.Get(source)
+		}
+	}
+	return changes
+}
+
+// AssignThemeRoles rewrites theme color scheme slots from other slots or fixed
+// colors (see ParseRoleAssignment). Only theme definitions change; references in
+// slides are untouched, but follow the new slot colors. Returns the number of
+// themes processed.
+func AssignThemeRoles(inputPath, outputPath string, assignments map[string]string, themeFilter []string) (int, error) {
+	return rewriteThemeColors(inputPath, outputPath, themeFilter, func(colors ColorScheme) map[string]string {
+		return resolveRoleAssignment(colors, assignments)
+	})
+}
+
+// rewriteThemeColors sets the color scheme slots of every theme (or those in
+// themeFilter) to the slot → hex changes computed from each theme's current colors.
+// Only theme definitions change. Returns the number of themes processed.
+func rewriteThemeColors(inputPath, outputPath string, themeFilter []string, changesFor func(colors ColorScheme) map[string]string) (int, error) {
+	if err := checkThemeFilter(inputPath, themeFilter); err != nil {
+		return 0, err
+	}
+
+	themesProcessed := 0
+	err := editPackage(inputPath, outputPath, func(tempDir string) (map[string]bool, error) {
+		changedFiles, err := forEachTheme(tempDir, themeFilter, nil, func(themeFile string, content []byte) ([]byte, error) {
+			theme, err := parseThemeXML(content, themeFile)
+			if err != nil {
+				// Not a theme with a color scheme; nothing to change
+				return content, nil
+			}
+
+			modified, err := SetSchemeColors(content, changesFor(theme.Colors))
+			if err != nil {
+				return nil, fmt.Errorf("failed to update %s: %w", themeFile, err)
+			}
+			themesProcessed++
+			return modified, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if themesProcessed == 0 {
+			return nil, fmt.Errorf("no themes with a color scheme found")
+		}
+		return changedFiles, nil
+	})
+	return themesProcessed, err
+}
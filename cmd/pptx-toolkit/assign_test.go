@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRoleAssignment(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "slot from slot", input: "hlink=accent1", want: map[string]string{"hlink": "accent1"}},
+		{name: "multiple", input: "hlink=accent1, folHlink=accent2", want: map[string]string{"hlink": "accent1", "folHlink": "accent2"}},
+		{name: "slot from hex", input: "accent6=1f4e79", want: map[string]string{"accent6": "1f4e79"}},
+		{name: "hex slot", input: "AABBCC=accent1", wantErr: true},
+		{name: "invalid source", input: "hlink=blue", wantErr: true},
+		{name: "mixed with swap", input: "hlink=accent1,accent2:accent3", wantErr: true},
+		{name: "conflict", input: "hlink=accent1,hlink=accent2", wantErr: true},
+		{name: "empty", input: " ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoleAssignment(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRoleAssignment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseRoleAssignment() = %v, want %v", got, tt.want)
+			}
+			for slot, source := range tt.want {
+				if got[slot] != source {
+					t.Errorf("%s = %s, want %s", slot, got[slot], source)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignThemeRoles(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 4})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	// accent1 is reassigned too, but hlink must get accent1's original color
+	assignments := map[string]string{"hlink": "accent1", "accent1": "accent2", "folHlink": "abcdef"}
+	count, err := AssignThemeRoles(inputPath, outputPath, assignments, nil)
+	if err != nil {
+		t.Fatalf("AssignThemeRoles() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 theme processed, got %d", count)
+	}
+
+	colors, err := GetColorScheme(outputPath, "theme1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colors.Hlink != "4F81BD" {
+		t.Errorf("hlink = %s, want original accent1 4F81BD", colors.Hlink)
+	}
+	if colors.Accent1 != "C0504D" {
+		t.Errorf("accent1 = %s, want accent2 C0504D", colors.Accent1)
+	}
+	if colors.FolHlink != "ABCDEF" {
+		t.Errorf("folHlink = %s, want ABCDEF", colors.FolHlink)
+	}
+
+	// References are untouched
+	input := readZipEntry(t, inputPath, "ppt/slides/slide1.xml")
+	output := readZipEntry(t, outputPath, "ppt/slides/slide1.xml")
+	if string(input) != string(output) {
+		t.Error("expected slide references to be untouched")
+	}
+
+	if _, err := AssignThemeRoles(inputPath, outputPath, assignments, []string{"theme7"}); err == nil {
+		t.Error("expected error for unknown theme filter")
+	}
+}
+
+func TestSwapRoleAssignmentRejectsMappingChecks(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/theme/theme1.xml": minimalThemeXML()},
+	})
+	allowed := filepath.Join(t.TempDir(), "allowed.txt")
+	if err := os.WriteFile(allowed, []byte("FF0000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		strictMapping = false
+		protectColors = nil
+		allowedColorsFile = ""
+		swapOutputDir = ""
+	})
+
+	tests := []struct {
+		name string
+		flag []string
+	}{
+		{"strict", []string{"--strict"}},
+		{"protect", []string{"--protect", "dk1"}},
+		{"allowed colors", []string{"--allowed-colors", allowed}},
+	}
+	for _, tt := range tests {
+		for _, batch := range []bool{false, true} {
+			outputDir := t.TempDir()
+			args := []string{"color", "swap", "hlink=accent1", inputPath}
+			if batch {
+				args = append(args, "--output-dir", outputDir)
+			} else {
+				args = append(args, filepath.Join(outputDir, "output.pptx"))
+			}
+
+			var stderr bytes.Buffer
+			rootCmd.SetOut(&bytes.Buffer{})
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(append(args, tt.flag...))
+			err := rootCmd.Execute()
+			strictMapping, protectColors, allowedColorsFile, swapOutputDir = false, nil, "", ""
+
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != ExitUsage {
+				t.Errorf("%s (batch %v): Execute() error = %v, want exit status %d", tt.name, batch, err, ExitUsage)
+			}
+			if !strings.Contains(stderr.String(), "cannot be used with role assignments") {
+				t.Errorf("%s (batch %v): stderr = %q, want the flag rejected", tt.name, batch, stderr.String())
+			}
+			if entries, _ := os.ReadDir(outputDir); len(entries) > 0 {
+				t.Errorf("%s (batch %v): wrote %d file(s), want none", tt.name, batch, len(entries))
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// batchResult is the outcome of one input of a batch swap
+type batchResult struct {
+	out     bytes.Buffer // Output buffered while running concurrently
+	errOut  bytes.Buffer // Error output buffered while running concurrently
+	err     error        // Non-nil if the input failed
+	stats   swapStats    // What the swap did, for --summary-only
+	skipped bool         // Output existed and the user declined to overwrite
+	ran     bool         // The swap was attempted
+	done    chan struct{}
+}
+
+// runColorSwapBatch applies one swap to several inputs, writing each to
+// --output-dir under its own base name. Up to --jobs inputs are processed at
+// once; their output is shown in input order. Without --keep-going no new input
+// is started once one fails.
+func runColorSwapBatch(cmd *cobra.Command, mappingStr string, inputFiles []string) error {
+	if listSlides || recordFile != "" || reportFile != "" {
+		cmd.PrintErrln("Error: --list-slides, --record, and --report cannot be used with --output-dir")
+		return errSilent
+	}
+	if swapJobs < 1 {
+		cmd.PrintErrln("Error: --jobs must be at least 1")
+		return errSilent
+	}
+
+	// Work out every output up front so collisions fail before anything is written
+	outputFiles := make([]string, len(inputFiles))
+	outputFor := make(map[string]string)
+	for i, inputFile := range inputFiles {
+		outputFile := filepath.Join(swapOutputDir, filepath.Base(inputFile))
+		if sameFile(inputFile, outputFile) {
+			cmd.PrintErrf("Error: %s would be overwritten; --output-dir must not be the input's directory\n", inputFile)
+			return errSilent
+		}
+		if other, exists := outputFor[outputFile]; exists {
+			cmd.PrintErrf("Error: %s and %s would both be written to %s\n", other, inputFile, outputFile)
+			return errSilent
+		}
+		outputFor[outputFile] = inputFile
+		outputFiles[i] = outputFile
+	}
+
+	// Parse once; the same swap applies to every input
+	var swap *swapRequest
+	if isRoleAssignment(mappingStr) {
+		if err := checkRoleAssignmentFlags(cmd); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if swap, err = parseSwapRequest(cmd, mappingStr); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(swapOutputDir, 0755); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	// Check inputs and ask about overwrites before starting, since prompts
+	// can't be interleaved with concurrent work. Without --keep-going a failed
+	// check ends the batch there, as a failed swap would.
+	results := make([]*batchResult, len(inputFiles))
+	for i := range results {
+		results[i] = &batchResult{done: make(chan struct{})}
+	}
+	var pending []int
+	for i, inputFile := range inputFiles {
+		result := results[i]
+		if err := ValidateInputFile(inputFile); err != nil {
+			cmd.PrintErrln("Error:", err)
+			result.err = exitWith(exitCodeFor(err))
+		} else if shouldContinue, err := PromptOverwrite(cmd, outputFiles[i]); err != nil {
+			cmd.PrintErrf("Error: %s not swapped\n", inputFile)
+			result.err = err
+		} else if !shouldContinue {
+			result.skipped = true
+		} else {
+			pending = append(pending, i)
+			continue
+		}
+		close(result.done)
+		if result.err != nil && !keepGoing {
+			for _, rest := range results[i+1:] {
+				close(rest.done)
+			}
+			break
+		}
+	}
+
+	// Start inputs in order, at most --jobs at a time
+	start := time.Now()
+	var mu sync.Mutex
+	stopped := false
+	sem := make(chan struct{}, swapJobs)
+	go func() {
+		for n, i := range pending {
+			sem <- struct{}{}
+			mu.Lock()
+			stop := stopped
+			mu.Unlock()
+			if stop {
+				<-sem
+				close(results[i].done)
+				continue
+			}
+
+			go func(n, i int) {
+				result := results[i]
+				defer close(result.done)
+				defer func() { <-sem }()
+
+				// A single job streams to the terminal; concurrent jobs buffer
+				// their output so it can be shown in input order
+				out := cmd
+				if swapJobs > 1 {
+					out = &cobra.Command{}
+					out.SetOut(&result.out)
+					out.SetErr(&result.errOut)
+				} else if summaryOnly {
+					out = &cobra.Command{}
+					out.SetErr(cmd.ErrOrStderr())
+				}
+				// With --summary-only only the batch total is shown
+				if summaryOnly {
+					out.SetOut(io.Discard)
+				}
+				if n > 0 && !summaryOnly {
+					out.Println()
+				}
+
+				result.ran = true
+				if swap == nil {
+					result.err = runRoleAssignment(out, mappingStr, inputFiles[i], outputFiles[i])
+				} else {
+					result.err = swap.run(out, inputFiles[i], outputFiles[i], &result.stats)
+				}
+				if result.err != nil && !keepGoing {
+					mu.Lock()
+					stopped = true
+					mu.Unlock()
+				}
+			}(n, i)
+		}
+	}()
+
+	var failed, skipped, notRun []string
+	var total swapStats
+	var firstErr error
+	for i, inputFile := range inputFiles {
+		result := results[i]
+		<-result.done
+		cmd.Print(result.out.String())
+		cmd.PrintErr(result.errOut.String())
+		total.files += result.stats.files
+		total.replacements += result.stats.replacements
+
+		switch {
+		case result.skipped:
+			skipped = append(skipped, inputFile)
+		case result.err != nil:
+			failed = append(failed, inputFile)
+			if firstErr == nil {
+				firstErr = result.err
+			}
+		case !result.ran:
+			notRun = append(notRun, inputFile)
+		}
+	}
+
+	succeeded := len(inputFiles) - len(failed) - len(skipped) - len(notRun)
+	if summaryOnly {
+		printSwapSummary(cmd, total, time.Since(start), fmt.Sprintf("(%d of %d input(s) swapped into %s)", succeeded, len(inputFiles), swapOutputDir))
+	} else {
+		cmd.Printf("\n✓ %d of %d file(s) swapped into %s\n", succeeded, len(inputFiles), swapOutputDir)
+	}
+	if len(skipped) > 0 {
+		cmd.Printf("  %d skipped: %s\n", len(skipped), strings.Join(skipped, ", "))
+	}
+	if len(failed) > 0 {
+		cmd.PrintErrf("Error: %d of %d file(s) failed: %s\n", len(failed), len(inputFiles), strings.Join(failed, ", "))
+		if len(notRun) > 0 {
+			cmd.PrintErrf("Stopped after the first failure; %d input(s) not processed (use --keep-going to continue past failures): %s\n",
+				len(notRun), strings.Join(notRun, ", "))
+		}
+		return exitWith(batchExitCode(firstErr))
+	}
+	return nil
+}
+
+// batchExitCode returns the exit code for the first input of a batch that failed,
+// so a batch ends the way a single swap of that input would have
+func batchExitCode(err error) int {
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	return exitCodeFor(err)
+}
+
+// sameFile reports whether two paths name the same existing file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
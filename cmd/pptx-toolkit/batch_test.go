@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// writeBatchInputs writes a deck under each of names into one directory and
+// returns their paths. A name ending in ".txt.pptx" gets a file that isn't a
+// zip archive; one starting with "slip" gets a deck that fails to extract.
+func writeBatchInputs(t *testing.T, names ...string) []string {
+	t.Helper()
+	deck, err := os.ReadFile(writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slip, err := os.ReadFile(writeSyntheticPPTX(t, syntheticDeck{Slides: 1, Parts: map[string]string{"../outside.xml": "<x/>"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		content := deck
+		switch {
+		case strings.HasSuffix(name, ".txt.pptx"):
+			content = []byte("not a zip")
+		case strings.HasPrefix(name, "slip"):
+			content = slip
+		}
+		paths[i] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[i], content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// runSwapBatch runs "color swap accent1:FF0000 <inputs> --output-dir outputDir"
+// with any extra flags and returns what it printed
+func runSwapBatch(t *testing.T, outputDir string, inputs []string, flags ...string) (string, string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		swapOutputDir = ""
+		keepGoing = false
+		swapJobs = 1
+		summaryOnly = false
+		noPrompt = false
+	})
+
+	args := append([]string{"color", "swap", "accent1:FF0000"}, inputs...)
+	args = append(args, "--output-dir", outputDir)
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs(append(args, flags...))
+	err := rootCmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestSwapBatch_OutputNames(t *testing.T) {
+	inputs := writeBatchInputs(t, "a.pptx", "b.potx")
+	outputDir := filepath.Join(t.TempDir(), "out")
+
+	stdout, stderr, err := runSwapBatch(t, outputDir, inputs)
+	if err != nil {
+		t.Fatalf("Execute() error = %v (stderr: %s)", err, stderr)
+	}
+	for _, name := range []string{"a.pptx", "b.potx"} {
+		if err := ValidateInputFile(filepath.Join(outputDir, name)); err != nil {
+			t.Errorf("output %s: %v", name, err)
+		}
+	}
+	if want := fmt.Sprintf("✓ 2 of 2 file(s) swapped into %s\n", outputDir); !strings.HasSuffix(stdout, want) {
+		t.Errorf("stdout = %q, want it to end with %q", stdout, want)
+	}
+}
+
+func TestSwapBatch_Collisions(t *testing.T) {
+	first := writeBatchInputs(t, "deck.pptx")
+	second := writeBatchInputs(t, "deck.pptx")
+
+	tests := []struct {
+		name      string
+		inputs    []string
+		outputDir string
+		wantErr   string
+	}{
+		{"same base name", []string{first[0], second[0]}, filepath.Join(t.TempDir(), "out"), "would both be written to"},
+		{"input's own directory", first, filepath.Dir(first[0]), "would be overwritten"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := os.ReadFile(first[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, stderr, err := runSwapBatch(t, tt.outputDir, tt.inputs)
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != ExitUsage {
+				t.Fatalf("Execute() error = %v, want exit status %d", err, ExitUsage)
+			}
+			if !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("stderr = %q, want it to mention %q", stderr, tt.wantErr)
+			}
+
+			// Nothing is written, not even the first input
+			after, err := os.ReadFile(first[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(before, after) {
+				t.Error("input was overwritten")
+			}
+			if tt.outputDir != filepath.Dir(first[0]) {
+				if _, err := os.Stat(tt.outputDir); !os.IsNotExist(err) {
+					t.Errorf("output directory was created (stat error %v)", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSwapBatch_StopOnFirstError(t *testing.T) {
+	tests := []struct {
+		name      string
+		failing   string // The middle input
+		existing  bool   // The middle input's output already exists
+		flags     []string
+		keepGoing bool
+		wantCode  int
+		wantErr   string
+	}{
+		{"not a package", "b.txt.pptx", false, nil, false, ExitNotPPTX, "not a PowerPoint"},
+		{"not a package, keep going", "b.txt.pptx", false, nil, true, ExitNotPPTX, "not a PowerPoint"},
+		{"existing output with --no-prompt", "b.pptx", true, []string{"--no-prompt"}, false, ExitAborted, "b.pptx not swapped"},
+		{"existing output with --no-prompt, keep going", "b.pptx", true, []string{"--no-prompt"}, true, ExitAborted, "b.pptx not swapped"},
+		{"swap fails", "slip.pptx", false, nil, false, ExitUsage, "1 of 3 file(s) failed"},
+		{"swap fails, keep going", "slip.pptx", false, nil, true, ExitUsage, "1 of 3 file(s) failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := writeBatchInputs(t, "a.pptx", tt.failing, "c.pptx")
+			outputDir := t.TempDir()
+			if tt.existing {
+				if err := os.WriteFile(filepath.Join(outputDir, tt.failing), []byte("keep"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			flags := tt.flags
+			if tt.keepGoing {
+				flags = append(flags, "--keep-going")
+			}
+			_, stderr, err := runSwapBatch(t, outputDir, inputs, flags...)
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != tt.wantCode {
+				t.Fatalf("Execute() error = %v, want exit status %d (stderr: %s)", err, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("stderr = %q, want it to mention %q", stderr, tt.wantErr)
+			}
+
+			// Inputs before the failure are always swapped; those after it only with --keep-going
+			if err := ValidateInputFile(filepath.Join(outputDir, "a.pptx")); err != nil {
+				t.Errorf("first input not swapped: %v", err)
+			}
+			_, err = os.Stat(filepath.Join(outputDir, "c.pptx"))
+			if tt.keepGoing && err != nil {
+				t.Errorf("last input not swapped with --keep-going: %v", err)
+			}
+			if !tt.keepGoing {
+				if err == nil {
+					t.Error("last input swapped after a failure without --keep-going")
+				}
+				if !strings.Contains(stderr, "1 input(s) not processed") {
+					t.Errorf("stderr = %q, want it to list the input not processed", stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestSwapBatch_JobsKeepInputOrder(t *testing.T) {
+	names := []string{"a.pptx", "b.pptx", "c.pptx", "d.pptx", "e.pptx", "f.pptx"}
+	inputs := writeBatchInputs(t, names...)
+
+	stdout, stderr, err := runSwapBatch(t, t.TempDir(), inputs, "--jobs", "3")
+	if err != nil {
+		t.Fatalf("Execute() error = %v (stderr: %s)", err, stderr)
+	}
+
+	// Each input's output is shown whole, in input order
+	last := -1
+	for _, input := range inputs {
+		header := fmt.Sprintf("Processing %s...", input)
+		if strings.Count(stdout, header) != 1 {
+			t.Fatalf("stdout has %d %q headers, want 1:\n%s", strings.Count(stdout, header), header, stdout)
+		}
+		at := strings.Index(stdout, header)
+		if at < last {
+			t.Errorf("output for %s shown out of order:\n%s", input, stdout)
+		}
+		last = at
+	}
+	if !strings.Contains(stdout, fmt.Sprintf("✓ %d of %d file(s) swapped", len(inputs), len(inputs))) {
+		t.Errorf("stdout = %q, want every input swapped", stdout)
+	}
+}
+
+func TestSwapBatch_SummaryOnly(t *testing.T) {
+	inputs := writeBatchInputs(t, "a.pptx", "b.pptx", "c.pptx")
+
+	// What one input contributes
+	var stats swapStats
+	swap := &swapRequest{colorMapping: map[string]string{"accent1": "FF0000"}}
+	quiet := &bytes.Buffer{}
+	rootCmd.SetOut(quiet)
+	rootCmd.SetErr(quiet)
+	if err := swap.run(rootCmd, inputs[0], filepath.Join(t.TempDir(), "single.pptx"), &stats); err != nil {
+		t.Fatalf("run() error = %v (output: %s)", err, quiet)
+	}
+	if stats.files == 0 || stats.replacements == 0 {
+		t.Fatalf("single swap stats = %+v, want files and replacements", stats)
+	}
+
+	for _, jobs := range []string{"1", "3"} {
+		t.Run("jobs "+jobs, func(t *testing.T) {
+			outputDir := t.TempDir()
+			stdout, stderr, err := runSwapBatch(t, outputDir, inputs, "--summary-only", "--jobs", jobs)
+			if err != nil {
+				t.Fatalf("Execute() error = %v (stderr: %s)", err, stderr)
+			}
+
+			want := regexp.MustCompile(fmt.Sprintf(`^✓ %d files processed, %d color reference\(s\) replaced in \S+ `, 3*stats.files, 3*stats.replacements) +
+				regexp.QuoteMeta(fmt.Sprintf("(3 of 3 input(s) swapped into %s)", outputDir)) + "\n$")
+			if !want.MatchString(stdout) {
+				t.Errorf("stdout = %q, want a single summary line totalling every input", stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// NormalizeHexCase uppercases every srgbClr val and sysClr lastClr hex value in
+// PowerPoint XML content without changing the color. Values inside comments, CDATA,
+// and processing instructions are left alone.
+//
+// Returns the modified XML bytes, or the original if every value is already uppercase.
+func NormalizeHexCase(xmlContent []byte) []byte {
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	addEdit := func(start, end int) {
+		value := xmlContent[start:end]
+		if upper := bytes.ToUpper(value); !bytes.Equal(upper, value) {
+			edits = append(edits, byteEdit{start, end, upper})
+		}
+	}
+
+	for _, match := range srgbClrStartTag.FindAllSubmatchIndex(xmlContent, -1) {
+		if !inNonMarkup(match[0], regions) {
+			addEdit(match[8], match[9])
+		}
+	}
+
+	for _, loc := range sysClrStartTag.FindAllIndex(xmlContent, -1) {
+		if inNonMarkup(loc[0], regions) {
+			continue
+		}
+		if attr := lastClrAttr.FindSubmatchIndex(xmlContent[loc[0]:loc[1]]); attr != nil {
+			addEdit(loc[0]+attr[2], loc[0]+attr[3])
+		}
+	}
+
+	return applyEdits(xmlContent, edits)
+}
+
+// identityModifierPattern matches a color modifier child whose value leaves the
+// color unchanged: a 100% multiplier or level, or a 0 offset. Values may be written
+// in thousandths of a percent ("100000") or, as in Strict OOXML, with "%".
+var identityModifierPattern = regexp.MustCompile(
+	`<(?:[A-Za-z_][\w.\-]*:)?(?:` +
+		`(?:lumMod|satMod|hueMod|alphaMod|redMod|greenMod|blueMod|tint|shade|alpha)\s+val\s*=\s*"(?:100000|100%)"` +
+		`|(?:lumOff|satOff|hueOff|alphaOff|redOff|greenOff|blueOff)\s+val\s*=\s*"(?:0|0%)"` +
+		`)\s*/>`)
+
+// RemoveIdentityModifiers removes modifier children of schemeClr and srgbClr elements
+// that do not change the color (e.g. <a:lumMod val="100000"/>). A container whose
+// children are all removed becomes a self-closing element. Unbalanced elements and
+// content inside comments, CDATA, and processing instructions are left alone.
+//
+// Returns the modified XML bytes, or the original if nothing needs removing.
+func RemoveIdentityModifiers(xmlContent []byte) []byte {
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	clean := func(startTag *regexp.Regexp, tagPattern *regexp.Regexp) {
+		for _, match := range startTag.FindAllSubmatchIndex(xmlContent, -1) {
+			isSelfClosing := string(xmlContent[match[12]:match[13]]) == "/>"
+			if isSelfClosing || inNonMarkup(match[0], regions) {
+				continue
+			}
+
+			closeStart, closeEnd := findClosingTag(xmlContent, match[1], tagPattern, regions)
+			if closeStart == -1 {
+				continue
+			}
+
+			children := identityModifierPattern.FindAllIndex(xmlContent[match[1]:closeStart], -1)
+			if len(children) == 0 {
+				continue
+			}
+
+			// If only identity modifiers (and whitespace) remain, collapse to self-closing
+			remaining := identityModifierPattern.ReplaceAll(xmlContent[match[1]:closeStart], nil)
+			if len(bytes.TrimSpace(remaining)) == 0 {
+				edits = append(edits, byteEdit{match[12], closeEnd, []byte("/>")})
+				continue
+			}
+
+			for _, child := range children {
+				if !inNonMarkup(match[1]+child[0], regions) {
+					edits = append(edits, byteEdit{match[1] + child[0], match[1] + child[1], nil})
+				}
+			}
+		}
+	}
+
+	clean(schemeClrStartTag, schemeClrTag)
+	clean(srgbClrStartTag, srgbClrTag)
+
+	return applyEdits(xmlContent, edits)
+}
+
+// emptyColorEdits returns the edits turning each schemeClr and srgbClr container
+// with no children (e.g. <a:schemeClr val="accent1"></a:schemeClr>, whitespace
+// allowed) into the self-closing form. Unbalanced elements and content inside
+// comments, CDATA, and processing instructions are left alone.
+func emptyColorEdits(xmlContent []byte) []byteEdit {
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	collapse := func(startTag *regexp.Regexp, tagPattern *regexp.Regexp) {
+		for _, match := range startTag.FindAllSubmatchIndex(xmlContent, -1) {
+			isSelfClosing := string(xmlContent[match[12]:match[13]]) == "/>"
+			if isSelfClosing || inNonMarkup(match[0], regions) {
+				continue
+			}
+
+			closeStart, closeEnd := findClosingTag(xmlContent, match[1], tagPattern, regions)
+			if closeStart == -1 {
+				continue
+			}
+
+			if len(bytes.TrimSpace(xmlContent[match[1]:closeStart])) == 0 {
+				edits = append(edits, byteEdit{match[12], closeEnd, []byte("/>")})
+			}
+		}
+	}
+
+	collapse(schemeClrStartTag, schemeClrTag)
+	collapse(srgbClrStartTag, srgbClrTag)
+
+	return edits
+}
+
+// SelfCloseEmptyColors rewrites empty schemeClr and srgbClr containers in the
+// self-closing form, which some linters require. The color is unchanged.
+//
+// Returns the modified XML bytes, or the original if there are no empty containers.
+func SelfCloseEmptyColors(xmlContent []byte) []byte {
+	return applyEdits(xmlContent, emptyColorEdits(xmlContent))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHexCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "lowercase srgbClr",
+			input: `<a:srgbClr val="aabbcc"/>`,
+			want:  `<a:srgbClr val="AABBCC"/>`,
+		},
+		{
+			name:  "mixed case container keeps children",
+			input: `<a:srgbClr val="aAbBcC"><a:alpha val="50000"/></a:srgbClr>`,
+			want:  `<a:srgbClr val="AABBCC"><a:alpha val="50000"/></a:srgbClr>`,
+		},
+		{
+			name:  "sysClr lastClr",
+			input: `<a:sysClr val="windowText" lastClr="ffffff"/>`,
+			want:  `<a:sysClr val="windowText" lastClr="FFFFFF"/>`,
+		},
+		{
+			name:  "already uppercase",
+			input: `<a:srgbClr val="AABBCC"/><a:sysClr val="window" lastClr="FFFFFF"/>`,
+			want:  `<a:srgbClr val="AABBCC"/><a:sysClr val="window" lastClr="FFFFFF"/>`,
+		},
+		{
+			name:  "comments untouched",
+			input: `<!-- <a:srgbClr val="aabbcc"/> --><a:srgbClr val="ddeeff"/>`,
+			want:  `<!-- <a:srgbClr val="aabbcc"/> --><a:srgbClr val="DDEEFF"/>`,
+		},
+		{
+			name:  "other attributes untouched",
+			input: `<a:schemeClr val="accent1"/><a:prstClr val="black"/>`,
+			want:  `<a:schemeClr val="accent1"/><a:prstClr val="black"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NormalizeHexCase([]byte(tt.input))); got != tt.want {
+				t.Errorf("NormalizeHexCase() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteParts_Scope(t *testing.T) {
+	masterXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><p:sldMaster xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:bg><p:bgPr><a:solidFill><a:srgbClr val="abcdef"/></a:solidFill></p:bgPr></p:bg></p:cSld></p:sldMaster>`
+	slideXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree><p:sp><p:spPr><a:solidFill><a:srgbClr val="ff00aa"/></a:solidFill></p:spPr></p:sp></p:spTree></p:cSld></p:sld>`
+
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts: map[string]string{
+			"ppt/slideMasters/slideMaster1.xml": masterXML,
+			"ppt/slides/slide1.xml":             slideXML,
+		},
+	})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	changed, err := rewriteParts(inputPath, outputPath, ScopeContent, NormalizeHexCase)
+	if err != nil {
+		t.Fatalf("rewriteParts() error = %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("expected 1 changed part, got %d", changed)
+	}
+
+	if slide := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml")); !strings.Contains(slide, `val="FF00AA"`) {
+		t.Error("expected slide hex value to be uppercased")
+	}
+	if master := string(readZipEntry(t, outputPath, "ppt/slideMasters/slideMaster1.xml")); !strings.Contains(master, `val="abcdef"`) {
+		t.Error("expected master to be out of scope")
+	}
+}
+
+func TestRemoveIdentityModifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "single no-op collapses to self-closing",
+			input: `<a:schemeClr val="accent1"><a:lumMod val="100000"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"/>`,
+		},
+		{
+			name:  "all no-ops with whitespace collapse",
+			input: "<a:srgbClr val=\"AABBCC\">\n  <a:lumMod val=\"100000\"/>\n  <a:lumOff val=\"0\"/>\n</a:srgbClr>",
+			want:  `<a:srgbClr val="AABBCC"/>`,
+		},
+		{
+			name:  "mixed real and no-op modifiers keep the real ones",
+			input: `<a:schemeClr val="accent1"><a:lumMod val="75000"/><a:lumOff val="0"/><a:alpha val="100000"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`,
+		},
+		{
+			name:  "real modifiers untouched",
+			input: `<a:schemeClr val="accent1"><a:tint val="40000"/><a:satOff val="10000"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"><a:tint val="40000"/><a:satOff val="10000"/></a:schemeClr>`,
+		},
+		{
+			name:  "strict percentage values",
+			input: `<a:schemeClr val="accent2"><a:shade val="100%"/><a:hueOff val="0%"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent2"/>`,
+		},
+		{
+			name:  "self-closing elements untouched",
+			input: `<a:schemeClr val="accent1"/><a:lumMod val="100000"/>`,
+			want:  `<a:schemeClr val="accent1"/><a:lumMod val="100000"/>`,
+		},
+		{
+			name:  "modifiers of other elements untouched",
+			input: `<a:prstClr val="black"><a:lumMod val="100000"/></a:prstClr>`,
+			want:  `<a:prstClr val="black"><a:lumMod val="100000"/></a:prstClr>`,
+		},
+		{
+			name:  "comments untouched",
+			input: `<a:schemeClr val="accent1"><!-- <a:lumMod val="100000"/> --><a:lumOff val="0"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"><!-- <a:lumMod val="100000"/> --></a:schemeClr>`,
+		},
+		{
+			name:  "unbalanced container untouched",
+			input: `<a:schemeClr val="accent1"><a:lumMod val="100000"/>`,
+			want:  `<a:schemeClr val="accent1"><a:lumMod val="100000"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RemoveIdentityModifiers([]byte(tt.input))); got != tt.want {
+				t.Errorf("RemoveIdentityModifiers() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfCloseEmptyColors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty scheme container",
+			input: `<a:schemeClr val="accent1"></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"/>`,
+		},
+		{
+			name:  "whitespace-only hex container",
+			input: "<a:srgbClr val=\"AABBCC\">\n  </a:srgbClr>",
+			want:  `<a:srgbClr val="AABBCC"/>`,
+		},
+		{
+			name:  "space before the tag end is kept",
+			input: `<a:schemeClr val="accent1" ></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1" />`,
+		},
+		{
+			name:  "containers with modifiers untouched",
+			input: `<a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`,
+			want:  `<a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`,
+		},
+		{
+			name:  "self-closing elements untouched",
+			input: `<a:schemeClr val="accent1"/><a:srgbClr val="AABBCC"/>`,
+			want:  `<a:schemeClr val="accent1"/><a:srgbClr val="AABBCC"/>`,
+		},
+		{
+			name:  "other elements untouched",
+			input: `<a:prstClr val="black"></a:prstClr>`,
+			want:  `<a:prstClr val="black"></a:prstClr>`,
+		},
+		{
+			name:  "comments untouched",
+			input: `<!-- <a:schemeClr val="accent1"></a:schemeClr> -->`,
+			want:  `<!-- <a:schemeClr val="accent1"></a:schemeClr> -->`,
+		},
+		{
+			name:  "unbalanced container untouched",
+			input: `<a:schemeClr val="accent1">`,
+			want:  `<a:schemeClr val="accent1">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(SelfCloseEmptyColors([]byte(tt.input))); got != tt.want {
+				t.Errorf("SelfCloseEmptyColors() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ColorMap maps the background/text aliases used by schemeClr (bg1, tx1, bg2, tx2)
+// to the theme slots they resolve to (normally lt1, dk1, lt2, dk2)
+type ColorMap map[string]string
+
+// colorMapAliases are the scheme color names resolved through a color map
+var colorMapAliases = map[string]bool{
+	"bg1": true,
+	"tx1": true,
+	"bg2": true,
+	"tx2": true,
+}
+
+// defaultColorMap is the color map PowerPoint writes on slide masters
+var defaultColorMap = ColorMap{"bg1": "lt1", "tx1": "dk1", "bg2": "lt2", "tx2": "dk2"}
+
+// clrMapPattern matches a slide master's color map
+var clrMapPattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?clrMap\b([^>]*?)/?>`)
+
+// overrideClrMappingPattern matches a slide or layout color map override
+var overrideClrMappingPattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?clrMapOvr\b[^>]*>\s*<(?:[A-Za-z_][\w.\-]*:)?overrideClrMapping\b([^>]*?)/?>`)
+
+// colorMapAttrPattern matches one attribute of a color map element
+var colorMapAttrPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseColorMapOverride returns the color map a slide or layout declares with
+// <p:clrMapOvr><a:overrideClrMapping .../></p:clrMapOvr>, or false if it follows
+// its master's map (<a:masterClrMapping/>) or has no override
+func parseColorMapOverride(xmlContent []byte) (ColorMap, bool) {
+	match := overrideClrMappingPattern.FindSubmatch(xmlContent)
+	if match == nil {
+		return nil, false
+	}
+
+	return parseColorMapAttrs(match[1]), true
+}
+
+// parseColorMap returns the color map a slide master declares with <p:clrMap .../>,
+// or false if it has none
+func parseColorMap(xmlContent []byte) (ColorMap, bool) {
+	match := clrMapPattern.FindSubmatch(xmlContent)
+	if match == nil {
+		return nil, false
+	}
+	return parseColorMapAttrs(match[1]), true
+}
+
+// parseColorMapAttrs reads the alias attributes of a color map element. Aliases
+// that are missing or point at an unknown slot keep their default mapping.
+func parseColorMapAttrs(attrs []byte) ColorMap {
+	colorMap := make(ColorMap)
+	for alias, slot := range defaultColorMap {
+		colorMap[alias] = slot
+	}
+	for _, attr := range colorMapAttrPattern.FindAllSubmatch(attrs, -1) {
+		alias, slot := string(attr[1]), string(attr[2])
+		if colorMapAliases[alias] && ValidSchemeColors[slot] {
+			colorMap[alias] = slot
+		}
+	}
+	return colorMap
+}
+
+// Resolve returns the theme slot a scheme color name points to: aliases (bg1, tx1, ...)
+// are looked up in the map, other names are returned unchanged
+func (m ColorMap) Resolve(name string) string {
+	if slot, ok := m[name]; ok {
+		return slot
+	}
+	return name
+}
+
+// MasterColorMap is the color map declared by one slide master
+type MasterColorMap struct {
+	Master   string   `json:"master"`   // Slide master file (e.g., "slideMaster1.xml")
+	Theme    string   `json:"theme"`    // Theme file the master uses, empty if unresolved
+	ColorMap ColorMap `json:"colorMap"` // Alias → slot (e.g., "bg1" → "lt1")
+}
+
+// ReadMasterColorMaps returns the color map of every slide master, sorted by master
+// file. Masters without a clrMap element get PowerPoint's default map.
+func ReadMasterColorMaps(pptxPath string) ([]MasterColorMap, error) {
+	var result []MasterColorMap
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		masterMaps, err := readMasterColorMaps(tempDir)
+		if err != nil {
+			return err
+		}
+		masterToTheme, _ := buildThemeRelationships(tempDir)
+
+		for master, colorMap := range masterMaps {
+			result = append(result, MasterColorMap{
+				Master:   master,
+				Theme:    masterToTheme[master],
+				ColorMap: colorMap,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return naturalLess(result[i].Master, result[j].Master)
+	})
+	return result, nil
+}
+
+// readMasterColorMaps parses the color map of every slide master in an extracted
+// presentation, keyed by master file name
+func readMasterColorMaps(tempDir string) (map[string]ColorMap, error) {
+	masters, err := filepath.Glob(filepath.Join(docPath(tempDir, "slideMasters"), "slideMaster*.xml"))
+	if err != nil {
+		return nil, err
+	}
+
+	masterMaps := make(map[string]ColorMap, len(masters))
+	for _, master := range masters {
+		content, err := os.ReadFile(master)
+		if err != nil {
+			return nil, err
+		}
+		colorMap, ok := parseColorMap(content)
+		if !ok {
+			colorMap = defaultColorMap
+		}
+		masterMaps[filepath.Base(master)] = colorMap
+	}
+	return masterMaps, nil
+}
+
+// hasColorMapAliases reports whether any mapping uses an alias (bg1, tx1, ...) as a source
+func hasColorMapAliases(mappings ...map[string]string) bool {
+	for _, mapping := range mappings {
+		for source := range mapping {
+			if colorMapAliases[source] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveColorMapAliases rewrites alias sources of a mapping for a part whose color
+// map differs from the master's. An alias source names the slot it resolves to on
+// the master (bg1 → lt1), so on a part where bg1 and tx1 are flipped, "bg1:X" must
+// apply to tx1 references instead. Non-alias sources are returned unchanged.
+func resolveColorMapAliases(colorMapping map[string]string, master, part ColorMap) map[string]string {
+	if part == nil || !hasColorMapAliases(colorMapping) {
+		return colorMapping
+	}
+
+	resolved := make(map[string]string, len(colorMapping))
+	for source, target := range colorMapping {
+		if !colorMapAliases[source] {
+			resolved[source] = target
+		}
+	}
+	for source, target := range colorMapping {
+		if !colorMapAliases[source] {
+			continue
+		}
+		for alias, slot := range part {
+			if slot == master[source] {
+				resolved[alias] = target
+			}
+		}
+	}
+	return resolved
+}
+
+// partColorMap pairs the color map of a part's master with the map in effect for the part
+type partColorMap struct {
+	Master    ColorMap
+	Effective ColorMap
+}
+
+// buildPartColorMaps returns, for each layout, slide, and slide-owned part (charts,
+// diagrams) whose color map differs from its master's, both maps. Parts that follow
+// their master's map are not listed, nor are parts shared by slides that disagree.
+func buildPartColorMaps(tempDir string, layoutToMaster map[string]string) (map[string]partColorMap, error) {
+	masterMaps, err := readMasterColorMaps(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	masterMap := func(master string) ColorMap {
+		if colorMap, ok := masterMaps[master]; ok {
+			return colorMap
+		}
+		return defaultColorMap
+	}
+
+	root := documentRoot(tempDir)
+	partColorMaps := make(map[string]partColorMap)
+
+	layouts, err := filepath.Glob(filepath.Join(docPath(tempDir, "slideLayouts"), "slideLayout*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	for _, layout := range layouts {
+		content, err := os.ReadFile(layout)
+		if err != nil {
+			return nil, err
+		}
+		if colorMap, ok := parseColorMapOverride(content); ok {
+			partColorMaps[rootPath(root, "slideLayouts", filepath.Base(layout))] = partColorMap{
+				Master:    masterMap(layoutToMaster[filepath.Base(layout)]),
+				Effective: colorMap,
+			}
+		}
+	}
+
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		// Without a slide mapping only layouts can be resolved
+		return partColorMaps, nil
+	}
+
+	shared := make(map[string]bool)
+	for slideNum, slidePath := range slideMapping {
+		fullPath := filepath.Join(tempDir, slidePath)
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, err
+		}
+		maps := partColorMap{Master: masterMap(getSlideMaster(fullPath, layoutToMaster))}
+		maps.Effective = maps.Master
+		if colorMap, ok := parseColorMapOverride(content); ok {
+			maps.Effective = colorMap
+		}
+
+		parts, err := GetSlideContent(tempDir, []int{slideNum})
+		if err != nil {
+			return nil, err
+		}
+		for part := range parts {
+			// Notes follow the notes master's color map, not the slide's
+			if strings.HasPrefix(part, rootDir(root, "notesSlides")) {
+				continue
+			}
+			if existing, seen := partColorMaps[part]; seen &&
+				(!equalColorMaps(existing.Master, maps.Master) || !equalColorMaps(existing.Effective, maps.Effective)) {
+				shared[part] = true
+			}
+			partColorMaps[part] = maps
+		}
+	}
+	for part, maps := range partColorMaps {
+		if shared[part] || equalColorMaps(maps.Master, maps.Effective) {
+			delete(partColorMaps, part)
+		}
+	}
+
+	return partColorMaps, nil
+}
+
+// equalColorMaps reports whether two color maps resolve every alias the same way
+func equalColorMaps(a, b ColorMap) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for alias, slot := range a {
+		if b[alias] != slot {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// overrideSlideXML returns a slide referencing bg1 and tx1 whose color map flips them
+func overrideSlideXML(clrMapOvr string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>` +
+		`<p:sp><p:spPr><a:solidFill><a:schemeClr val="bg1"/></a:solidFill></p:spPr></p:sp>` +
+		`<p:sp><p:spPr><a:solidFill><a:schemeClr val="tx1"/></a:solidFill></p:spPr></p:sp>` +
+		`</p:spTree></p:cSld>` + clrMapOvr + `</p:sld>`
+}
+
+func TestParseColorMapOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    ColorMap
+		wantOvr bool
+	}{
+		{
+			name:    "flipped override",
+			xml:     `<p:clrMapOvr><a:overrideClrMapping bg1="dk1" tx1="lt1" bg2="dk2" tx2="lt2" accent1="accent1"/></p:clrMapOvr>`,
+			want:    ColorMap{"bg1": "dk1", "tx1": "lt1", "bg2": "dk2", "tx2": "lt2"},
+			wantOvr: true,
+		},
+		{
+			name:    "partial override keeps defaults",
+			xml:     `<p:clrMapOvr><a:overrideClrMapping bg1="dk1"/></p:clrMapOvr>`,
+			want:    ColorMap{"bg1": "dk1", "tx1": "dk1", "bg2": "lt2", "tx2": "dk2"},
+			wantOvr: true,
+		},
+		{
+			name: "master mapping",
+			xml:  `<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>`,
+		},
+		{
+			name: "no override",
+			xml:  `<p:cSld/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseColorMapOverride([]byte(tt.xml))
+			if ok != tt.wantOvr {
+				t.Fatalf("parseColorMapOverride() ok = %v, want %v", ok, tt.wantOvr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseColorMapOverride() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColorMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		xml   string
+		want  ColorMap
+		found bool
+	}{
+		{
+			name:  "default master",
+			xml:   `<p:cSld/><p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1"/>`,
+			want:  defaultColorMap,
+			found: true,
+		},
+		{
+			name:  "dark master",
+			xml:   `<p:clrMap bg1="dk1" tx1="lt1" bg2="dk2" tx2="lt2"/>`,
+			want:  ColorMap{"bg1": "dk1", "tx1": "lt1", "bg2": "dk2", "tx2": "lt2"},
+			found: true,
+		},
+		{
+			name: "override is not a master map",
+			xml:  `<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := parseColorMap([]byte(tt.xml))
+			if found != tt.found {
+				t.Fatalf("parseColorMap() found = %v, want %v", found, tt.found)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseColorMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorMapResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"bg1", "lt1"},
+		{"tx2", "dk2"},
+		{"accent1", "accent1"},
+	}
+
+	for _, tt := range tests {
+		if got := defaultColorMap.Resolve(tt.name); got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadMasterColorMaps(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	maps, err := ReadMasterColorMaps(testPPTX)
+	if err != nil {
+		t.Fatalf("ReadMasterColorMaps() error = %v", err)
+	}
+	if len(maps) != 3 {
+		t.Fatalf("expected 3 masters, got %d", len(maps))
+	}
+	for _, m := range maps {
+		if m.Theme == "" {
+			t.Errorf("%s: theme not resolved", m.Master)
+		}
+		if !reflect.DeepEqual(m.ColorMap, defaultColorMap) {
+			t.Errorf("%s: color map = %v, want default", m.Master, m.ColorMap)
+		}
+	}
+}
+
+func TestResolveColorMapAliases(t *testing.T) {
+	flipped := ColorMap{"bg1": "dk1", "tx1": "lt1", "bg2": "lt2", "tx2": "dk2"}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		master  ColorMap
+		part    ColorMap
+		want    map[string]string
+	}{
+		{
+			name:    "no override",
+			mapping: map[string]string{"bg1": "FF0000"},
+			master:  defaultColorMap,
+			want:    map[string]string{"bg1": "FF0000"},
+		},
+		{
+			name:    "flipped aliases swap targets",
+			mapping: map[string]string{"bg1": "FF0000", "tx1": "00FF00", "accent1": "accent2"},
+			master:  defaultColorMap,
+			part:    flipped,
+			want:    map[string]string{"tx1": "FF0000", "bg1": "00FF00", "accent1": "accent2"},
+		},
+		{
+			name:    "dark master, slide restores default",
+			mapping: map[string]string{"bg1": "FF0000"},
+			master:  flipped,
+			part:    defaultColorMap,
+			want:    map[string]string{"tx1": "FF0000"},
+		},
+		{
+			name:    "physical slots unchanged",
+			mapping: map[string]string{"lt1": "FF0000"},
+			master:  defaultColorMap,
+			part:    flipped,
+			want:    map[string]string{"lt1": "FF0000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveColorMapAliases(tt.mapping, tt.master, tt.part)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveColorMapAliases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessPPTXColorMapOverride(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 2,
+		Parts: map[string]string{
+			"ppt/slides/slide1.xml": overrideSlideXML(`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>`),
+			"ppt/slides/slide2.xml": overrideSlideXML(`<p:clrMapOvr><a:overrideClrMapping bg1="dk1" tx1="lt1" bg2="dk2" tx2="lt2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/></p:clrMapOvr>`),
+		},
+	})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	mapping, err := ParseColorMapping("bg1:FF0000")
+	if err != nil {
+		t.Fatalf("ParseColorMapping() error = %v", err)
+	}
+	if _, _, err := ProcessPPTX(inputPath, outputPath, mapping, nil, "content", nil); err != nil {
+		t.Fatalf("ProcessPPTX() error = %v", err)
+	}
+
+	tests := []struct {
+		slide   string
+		want    string
+		notWant string
+	}{
+		// Follows the master: the bg1 reference is the background
+		{"ppt/slides/slide1.xml", `<a:srgbClr val="FF0000"/></a:solidFill></p:spPr></p:sp><p:sp><p:spPr><a:solidFill><a:schemeClr val="tx1"/>`, ""},
+		// Flipped: tx1 now resolves to lt1, the master's background slot
+		{"ppt/slides/slide2.xml", `<a:schemeClr val="bg1"/></a:solidFill></p:spPr></p:sp><p:sp><p:spPr><a:solidFill><a:srgbClr val="FF0000"/>`, `val="tx1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.slide, func(t *testing.T) {
+			content := string(readZipEntry(t, outputPath, tt.slide))
+			if !strings.Contains(content, tt.want) {
+				t.Errorf("%s missing %q:\n%s", tt.slide, tt.want, content)
+			}
+			if tt.notWant != "" && strings.Contains(content, tt.notWant) {
+				t.Errorf("%s still contains %q:\n%s", tt.slide, tt.notWant, content)
+			}
+		})
+	}
+}
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 )
@@ -16,8 +24,20 @@ var colorCmd = &cobra.Command{
 var colorListCmd = &cobra.Command{
 	Use:   "list <input.pptx>",
 	Short: "List all color schemes in a PowerPoint file",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runColorList,
+	Long: `List the color scheme of every theme in a PowerPoint file, and the color map of
+each master using it.
+
+A theme can also carry extra color schemes (extraClrSchemeLst) that PowerPoint
+offers as alternatives; they color nothing until one is chosen. --include-extra
+lists them too. Other commands never change them.
+
+Examples:
+  pptx-toolkit color list input.pptx
+
+  # Also list the extra color schemes
+  pptx-toolkit color list input.pptx --include-extra`,
+	Args: cobra.ExactArgs(1),
+	RunE: runColorList,
 }
 
 var colorSwapCmd = &cobra.Command{
@@ -31,10 +51,154 @@ Scope options:
   all      - Process all files (default)
   content  - Process user content only (slides, charts, diagrams, notes)
   master   - Process master infrastructure only (slideMasters, slideLayouts, notesMasters, handoutMasters)
+  theme    - Process theme parts only: references inside themes, plus the color scheme
+             itself (as with --include-theme)
+  Combine scopes with commas, e.g. --scope content,theme.
+  References in a theme include its object defaults (objectDefaults), the colors of
+  new shapes, lines, and text boxes. A theme's extra color schemes (extraClrSchemeLst)
+  are never changed.
+
+Part globs:
+  --include and --exclude match archive paths (e.g., "ppt/charts/*") with shell-style
+  globs; "*" does not cross "/". They narrow the scope rather than replace it: a part is
+  processed if it is in scope, matches an --include (if any), and matches no --exclude.
+
+Charts, diagrams, and notes:
+  --no-charts, --no-diagrams, and --no-notes leave that kind of content untouched in
+  every scope, including what --slides pulls in with its slides. For example,
+  "--slides 3 --no-charts" recolors slide 3 but not its chart.
 
 Slide filtering:
   Use --slides to target specific slides. Automatically includes embedded content (charts, diagrams, notes).
   IMPORTANT: --slides can only be used with --scope content.
+  Slide numbers count hidden slides, as in the slide sorter. With --visible-index they
+  count only visible slides, as numbered in a slide show: if slide 2 is hidden,
+  "--slides 2 --visible-index" targets slide 3.
+  With --theme as well, it is an error if none of the slides use the selected themes;
+  pass --allow-empty to process nothing instead.
+  --section "Name" adds the slides of a named section (case-insensitive; repeatable).
+  Section slides are slide numbers, unaffected by --visible-index.
+  If the presentation's relationships (presentation.xml.rels) are missing or unreadable,
+  slides are numbered by file name (slide1.xml, slide2.xml, ...) with a warning; the
+  order may not match PowerPoint's.
+
+Hex casing:
+  Hex targets are written uppercase, as PowerPoint writes them. --preserve-case writes
+  them exactly as given (e.g., "accent1:aabbcc" writes aabbcc), including the
+  --map-unmatched-to color. Sources match any casing either way. Theme color scheme
+  slots recolored by --include-theme are always uppercase.
+
+Identity mappings:
+  A mapping whose target is its source (e.g., accent1:accent1) changes nothing. It is
+  accepted, with a note under --verbose; --strict rejects it as a likely mistake.
+
+Undefined targets:
+  Themes written by some tools define only part of their color scheme (e.g., accent1
+  to accent4). References swapped to a slot the theme does not define render black.
+  --validate-targets checks every scheme target, resolved through the default color
+  map (tx2 is dk2, ...), against the themes it applies to and warns about each slot
+  they leave undefined. With --strict the warnings are errors, exit status 5, and
+  nothing is written.
+
+Per-theme mappings:
+  --theme-mapping theme=mapping applies a mapping only to slides, layouts, masters, and
+  slide content governed by that theme, on top of the general mapping. Repeat it to
+  give each theme its own swap. Pass "" as the mapping to use theme mappings only.
+
+Mapping files:
+  --mapping-file reads mappings from a file, one or more per line, so a rebrand can be
+  kept as a documented spec. Lines starting with "#" and blank lines are ignored. A
+  "[theme1]" header scopes the mappings below it to that theme, as --theme-mapping
+  does; mappings before the first header apply everywhere. The file adds to the
+  mapping argument; pass "" to use the file alone.
+
+    # Rebrand 2026
+    accent1:1F4E79
+
+    [theme2]
+    accent2:C00000, AABBCC:accent1
+
+Background/text aliases:
+  Sources may be bg1, tx1, bg2, or tx2. An alias names the slot it resolves to on the
+  master (bg1 → lt1), so on slides that override the color map (clrMapOvr) the swap
+  follows the reference that actually points at that slot.
+
+Role assignment:
+  A mapping written as slot=source (e.g., "hlink=accent1") assigns theme slots
+  instead of swapping references: the source's current color is looked up in each
+  theme and written into the slot. This changes the theme, not references.
+  --scope and --slides do not apply; --theme does.
+
+Theme definitions:
+  By default only color references are swapped; the theme itself is untouched.
+  With --include-theme, scheme→hex and hex→hex mappings are also applied to the
+  theme's color scheme, e.g. "accent1:FF0000" repaints accent1 itself. This changes
+  every slide, layout, and master that uses the slot, not just the swapped references.
+
+Unmatched colors:
+  --map-unmatched-to COLOR replaces every scheme and hex color reference that the
+  mapping does not cover with COLOR, e.g. to produce a monochrome draft. It runs in
+  the same atomic pass, so references the mapping produces are never caught by it.
+  Explicit mappings win: a hex color that is also the target of a mapping is left
+  as is. Placeholder colors (phClr) and system colors are never matched.
+
+Protected colors:
+  --protect dk1,lt1 rejects any mapping whose source or target is a protected color,
+  and keeps --map-unmatched-to from replacing them. Protecting a slot also protects
+  the alias that normally refers to it (dk1 and tx1, lt1 and bg1, and so on).
+
+System colors:
+  Some fills use a system color (sysClr, e.g. windowText) that follows the viewer's
+  operating system and caches its last value in lastClr. Hex sources don't match them
+  unless --match-sysclr is given; then a sysClr whose lastClr equals the source is
+  replaced by the target, as a fixed hex or scheme color. The replacement no longer
+  follows the operating system. System colors in the theme's color scheme are not
+  references and are never matched.
+  --recache-sysclr is the conservative alternative: a hex→hex mapping rewrites only the
+  cached lastClr of matching system colors, keeping them system colors. Mappings to
+  scheme colors don't apply to them.
+
+Exit status:
+  "Successfully processed N files" counts the parts examined, not the parts changed; a
+  mapping whose sources never occur still processes every file. The number of color
+  references replaced is reported separately, and --fail-on-no-op turns a swap that
+  replaced nothing into an error with exit status 3 (the output is still written), to
+  catch stale mappings or the wrong input in CI. Other exit statuses: 1 for invalid
+  arguments and other errors, 2 if the output exists and is not overwritten, 4 if the
+  input is not a PowerPoint file.
+
+Summary only:
+  --summary-only replaces the header and per-file results with a single line of
+  totals: files processed, color references replaced, and elapsed time. In batch mode
+  the line covers the whole batch. Warnings and errors are still shown, so it suits
+  cron logs.
+
+Timing:
+  --timing prints, to stderr, the wall time of the swap and the input's size and
+  throughput in MB/s, followed by the time spent extracting the input, processing its
+  parts, and writing the output. It is always shown with --verbose.
+
+Batch mode:
+  With --output-dir, every argument after the mapping is an input, and each is written
+  to the directory under its own file name. Without --keep-going the batch stops at the
+  first input that fails; with it, the remaining inputs are still processed and the
+  failures are counted at the end. --jobs N processes up to N inputs at once; output
+  is still shown in input order. --record, --report, and --list-slides are not
+  available.
+
+Table styles:
+  Table style definitions (tableStyles.xml) and presentation defaults (presentation.xml)
+  are skipped unless --include-table-styles is given. They apply presentation-wide, so
+  they are not affected by --theme, and are never touched together with --slides.
+
+Images:
+  Colors inside raster images (PNG, JPEG, GIF, ...) are pixels, not color references,
+  so no swap changes them. --recolor-media lists the images slides reference and the
+  slides they appear on, so you know what to recolor by hand. Images are never modified.
+
+SmartArt:
+  A diagram's color definition and its cached drawing are always swapped together.
+  Re-open the output in PowerPoint to let it reconcile the diagram rendering.
 
 Examples:
   # Scheme to scheme
@@ -58,9 +222,52 @@ Examples:
   # Combine slides with theme filtering
   pptx-toolkit color swap "accent1:accent3" input.pptx output.pptx --slides 1-5 --theme theme1
 
+  # Repaint accent1 in the theme as well as swapping references
+  pptx-toolkit color swap "accent1:FF0000" input.pptx output.pptx --include-theme
+
+  # Also recolor built-in table styles
+  pptx-toolkit color swap "accent1:accent3" input.pptx output.pptx --include-table-styles
+
+  # Different swaps per theme
+  pptx-toolkit color swap "" input.pptx output.pptx --theme-mapping theme1=accent1:FF0000 --theme-mapping theme2=accent1:0000FF
+
+  # Everything from a mapping file
+  pptx-toolkit color swap "" input.pptx output.pptx --mapping-file rebrand.txt
+
+  # Make the hyperlink colors match accent1 and accent2 in the theme
+  pptx-toolkit color swap "hlink=accent1,folHlink=accent2" input.pptx output.pptx
+
+  # Only allow brand-approved hex targets
+  pptx-toolkit color swap "accent1:1F4E79" input.pptx output.pptx --allowed-colors brand.txt
+
+  # Gray out everything except the text and background colors
+  pptx-toolkit color swap "" input.pptx output.pptx --map-unmatched-to 808080 --protect dk1,lt1
+
+  # Record every change so the swap can be undone with "color undo"
+  pptx-toolkit color swap "accent1:FF0000" input.pptx output.pptx --record changes.json
+
+  # List what changed in each part (e.g., accent1→FF0000 ×12 in ppt/slides/slide3.xml)
+  pptx-toolkit color swap "accent1:FF0000" input.pptx output.pptx --report changes-by-part.json
+
+  # Same swap for many decks, written to out/ under their own names
+  pptx-toolkit color swap "accent1:FF0000" *.pptx --output-dir out/ --keep-going --jobs 4
+
+  # Only charts, or everything except speaker notes
+  pptx-toolkit color swap "accent1:accent3" input.pptx output.pptx --include "ppt/charts/*"
+  pptx-toolkit color swap "accent1:accent3" input.pptx output.pptx --exclude "ppt/notesSlides/*"
+
+  # Check which slides --slides targets before swapping (writes nothing)
+  pptx-toolkit color swap "accent1:accent3" input.pptx output.pptx --slides 3,4 --list-slides
+
   # Multiple mappings
   pptx-toolkit color swap "accent1:BBFFCC,AABBCC:accent2,FF0000:00FF00" input.pptx output.pptx`,
-	Args: cobra.ExactArgs(3),
+	Args: func(cmd *cobra.Command, args []string) error {
+		// With --output-dir every argument after the mapping is an input
+		if swapOutputDir != "" {
+			return cobra.MinimumNArgs(2)(cmd, args)
+		}
+		return cobra.ExactArgs(3)(cmd, args)
+	},
 	RunE: runColorSwap,
 }
 
@@ -70,6 +277,8 @@ var colorRenameCmd = &cobra.Command{
 	Long: `Rename colour scheme(s) in themes.
 
 By default, renames the colour scheme in all themes. Use --theme to target specific themes.
+Themes without a colour scheme (e.g., parts carrying only fonts or effects) are reported
+and skipped; the rename fails only if no selected theme could be renamed.
 
 Examples:
   # Rename in all themes
@@ -84,29 +293,494 @@ Examples:
 	RunE: runColorRename,
 }
 
+var colorFindCmd = &cobra.Command{
+	Use:   "find <color> <input.pptx>",
+	Short: "List the slides that use a color",
+	Long: `List the slides that reference a color.
+
+The color can be a scheme color (e.g., accent1) or a hex RGB value (e.g., AABBCC).
+Charts, diagrams, and notes count towards the slide that embeds them. Matches in
+parts not tied to a slide (masters, layouts) are listed separately.
+
+Examples:
+  # Which slides use accent1?
+  pptx-toolkit color find accent1 input.pptx
+
+  # Find a hardcoded hex color in slide content only
+  pptx-toolkit color find AABBCC input.pptx --scope content`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorFind,
+}
+
+var colorValidateCmd = &cobra.Command{
+	Use:   "validate <input.pptx>",
+	Short: "Report scheme color references that resolve to suspicious theme colors",
+	Long: `Report scheme color references that resolve to suspicious theme colors.
+
+Every scheme color reference (accent1, bg2, ...) in slides, charts, diagrams, notes,
+layouts, and masters is resolved against the theme that governs it, following the
+color map for bg1/tx1/bg2/tx2. A reference is reported if its theme slot is:
+  - not defined: the color scheme has no color for it, so it renders black
+  - black (000000) in a slot other than dk1 or dk2, usually a placeholder that was
+    never filled in
+
+Swapping to or from such a slot tends to give surprising results. Slots nothing
+references are not reported. Exits with an error if any issue is found.
+
+Examples:
+  pptx-toolkit color validate input.pptx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runColorValidate,
+}
+
+var colorUndoCmd = &cobra.Command{
+	Use:   "undo <changes.json> <input.pptx> <output.pptx>",
+	Short: "Reverse a swap recorded with --record",
+	Long: `Reverse a swap recorded with "color swap --record".
+
+Every recorded edit is undone in reverse order, restoring the exact original bytes
+of each changed part, even for many-to-one mappings. The input must be the file
+the swap wrote, unmodified since.
+
+Examples:
+  pptx-toolkit color swap "accent1:FF0000" input.pptx swapped.pptx --record changes.json
+  pptx-toolkit color undo changes.json swapped.pptx restored.pptx`,
+	Args: cobra.ExactArgs(3),
+	RunE: runColorUndo,
+}
+
+var colorNormalizeCmd = &cobra.Command{
+	Use:   "normalize <input.pptx> <output.pptx>",
+	Short: "Uppercase hex color values",
+	Long: `Uppercase every hex color value (srgbClr val and sysClr lastClr) without changing
+any color, so that "aabbcc" and "AABBCC" no longer show up as different colors.
+
+Only parts that contain lowercase values are rewritten.
+
+Scope options:
+  all      - Process all files (default)
+  content  - Process user content only (slides, charts, diagrams, notes)
+  master   - Process master infrastructure only (slideMasters, slideLayouts, notesMasters, handoutMasters)
+  theme    - Process theme parts only
+  Combine scopes with commas, e.g. --scope content,theme.
+
+Examples:
+  pptx-toolkit color normalize input.pptx output.pptx
+
+  # Slide content only
+  pptx-toolkit color normalize input.pptx output.pptx --scope content`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorNormalize,
+}
+
+var colorCleanCmd = &cobra.Command{
+	Use:   "clean <input.pptx> <output.pptx>",
+	Short: "Remove color modifiers that have no effect",
+	Long: `Remove color modifiers that have no effect, such as <a:lumMod val="100000"/>
+or <a:lumOff val="0"/>, from scheme and hex color references. A reference left
+with no modifiers becomes a plain self-closing element. Colors are unchanged.
+
+Only parts that contain such modifiers are rewritten.
+
+Scope options:
+  all      - Process all files (default)
+  content  - Process user content only (slides, charts, diagrams, notes)
+  master   - Process master infrastructure only (slideMasters, slideLayouts, notesMasters, handoutMasters)
+  theme    - Process theme parts only
+  Combine scopes with commas, e.g. --scope content,theme.
+
+Examples:
+  pptx-toolkit color clean input.pptx output.pptx
+
+  # Slide content only
+  pptx-toolkit color clean input.pptx output.pptx --scope content`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorClean,
+}
+
+var colorThemifyCmd = &cobra.Command{
+	Use:   "themify <input.pptx> <output.pptx>",
+	Short: "Turn hardcoded colors that match the theme into theme colors",
+	Long: `Replace hardcoded hex colors (srgbClr) that equal one of the theme's colors with a
+reference to that theme slot, so they follow the theme from then on.
+
+Each part is matched against the colors of its own theme: on a deck with two
+masters, 156082 becomes accent1 only on slides whose theme has accent1 = 156082.
+Parts that no single theme governs are left alone.
+
+Colors are matched against dk1, lt1, dk2, lt2, and accent1-6. When several slots
+share a value, the first in that order is used. Hyperlink colors (hlink, folHlink)
+are never used as targets. Modifiers such as alpha are kept.
+
+Scope options:
+  all      - Process all files (default)
+  content  - Process user content only (slides, charts, diagrams, notes)
+  master   - Process master infrastructure only (slideMasters, slideLayouts, notesMasters, handoutMasters)
+  theme    - Process theme parts only (references outside the color scheme)
+  Combine scopes with commas, e.g. --scope content,theme.
+
+Examples:
+  pptx-toolkit color themify input.pptx output.pptx
+
+  # Slide content of one theme only
+  pptx-toolkit color themify input.pptx output.pptx --scope content --theme theme1`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorThemify,
+}
+
+var colorGrayscaleCmd = &cobra.Command{
+	Use:   "grayscale <input.pptx> <output.pptx>",
+	Short: "Convert every color to a gray of the same brightness",
+	Long: `Convert every color to a gray of the same luma (0.299 R + 0.587 G + 0.114 B),
+e.g. for print proofing.
+
+Hex colors become grays directly. Scheme references are replaced with the gray of
+the color they resolve to in their theme, as fixed hex colors; modifiers on them
+(lumMod, tint, ...) are dropped, and parts no single theme governs keep their scheme
+references.
+
+With --keep-scheme, the theme palettes are turned gray instead: scheme references
+stay linked to the theme and keep their modifiers. Hex colors are still converted.
+
+System colors (sysClr) are left alone.
+
+Scope options:
+  all      - Process all files (default)
+  content  - Process user content only (slides, charts, diagrams, notes)
+  master   - Process master infrastructure only (slideMasters, slideLayouts, notesMasters, handoutMasters)
+  theme    - Process theme parts only
+  Combine scopes with commas, e.g. --scope content,theme.
+
+Examples:
+  pptx-toolkit color grayscale input.pptx output.pptx
+
+  # Gray theme palettes, scheme references kept
+  pptx-toolkit color grayscale input.pptx output.pptx --keep-scheme`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorGrayscale,
+}
+
+var colorHueShiftCmd = &cobra.Command{
+	Use:   "hue-shift <degrees> <input.pptx> <output.pptx>",
+	Short: "Rotate the hue of the theme palette",
+	Long: `Rotate the hue of every theme's accent and hyperlink colors by the given number
+of degrees, keeping their saturation and lightness. The theme definitions are
+edited, so every reference to those slots follows.
+
+dk1, lt1, dk2, and lt2 are kept unless --include-neutrals is given.
+
+Negative angles must follow "--", otherwise they are read as flags.
+
+Examples:
+  pptx-toolkit color hue-shift 30 input.pptx output.pptx
+
+  # Rotate the other way, including the dark and light colors
+  pptx-toolkit color hue-shift --include-neutrals -- -30 input.pptx output.pptx`,
+	Args: cobra.ExactArgs(3),
+	RunE: runColorHueShift,
+}
+
+var colorAdjustCmd = &cobra.Command{
+	Use:   "adjust <input.pptx> <output.pptx>",
+	Short: "Lighten, darken, or (de)saturate the theme palette",
+	Long: `Shift the lightness and saturation of every theme color by a number of percentage
+points in HSL space, e.g. --lightness +10 turns 40% lightness into 50%. Values are
+clamped at 0% and 100%; grays stay gray. The theme definitions are edited, so every
+reference to those slots follows.
+
+With --keep-black-white, a pure black dk1 and a pure white lt1 are left as they are,
+so body text and backgrounds keep full contrast.
+
+Examples:
+  pptx-toolkit color adjust --lightness 10 input.pptx output.pptx
+
+  # Darker and more muted, keeping black text on white
+  pptx-toolkit color adjust --lightness -10 --saturation -20 --keep-black-white input.pptx output.pptx`,
+	Args: cobra.ExactArgs(2),
+	RunE: runColorAdjust,
+}
+
+var colorFromImageCmd = &cobra.Command{
+	Use:   "from-image <image> <input.pptx> <output.pptx>",
+	Short: "Map the theme accents to the dominant colors of an image",
+	Long: `Find the dominant colors of a reference image (PNG, JPEG, or GIF) and set the
+theme's accent colors to them, most common first: accent1 gets the most common
+color, accent2 the next, and so on. The theme definitions are edited, so every
+reference to those slots follows.
+
+Colors are found by k-means clustering of the image's pixels; transparent pixels
+are ignored and large images are sampled evenly. The clustering starts from a fixed
+seed, so the same image always gives the same palette. --colors sets how many
+accents to fill (1-6); an image with fewer distinct colors fills fewer, and the
+remaining accents are left alone.
+
+Examples:
+  pptx-toolkit color from-image moodboard.png input.pptx output.pptx
+
+  # Only the first three accents, in theme1 only
+  pptx-toolkit color from-image --colors 3 --theme theme1 moodboard.jpg input.pptx output.pptx`,
+	Args: cobra.ExactArgs(3),
+	RunE: runColorFromImage,
+}
+
+var colorApplyPaletteCmd = &cobra.Command{
+	Use:   "apply-palette <palette.txt> <input.pptx> <output.pptx>",
+	Short: "Set the theme accents from a palette file",
+	Long: `Set accent1 to accent6 of every theme to the six hex colors listed in a palette
+file, in order, so an approved brand palette can be pushed to any deck. The theme
+definitions are edited, so every reference to those slots follows.
+
+Colors may be separated by newlines, commas, or spaces, and may start with '#'.
+Anything else after a '#' is a comment. It is an error if the file does not list
+exactly six colors.
+
+Example palette file:
+  # Brand palette, 2026
+  #1F4E79  # accent1
+  #C0504D
+  9BBB59, 8064A2, 4BACC6, F79646
+
+Examples:
+  pptx-toolkit color apply-palette brand.txt input.pptx output.pptx
+
+  # Only the theme of the second master
+  pptx-toolkit color apply-palette brand.txt input.pptx output.pptx --theme theme2`,
+	Args: cobra.ExactArgs(3),
+	RunE: runColorApplyPalette,
+}
+
 var (
-	themeFilter       []string
-	renameThemeFilter []string
-	scopeFilter       string
-	slideFilter       string
+	themeFilter        []string
+	themeByName        []string
+	renameThemeFilter  []string
+	renameThemeByName  []string
+	scopeFilter        string
+	slideFilter        string
+	sectionFilter      []string
+	visibleIndex       bool
+	allowEmpty         bool
+	strictMapping      bool
+	noNotes            bool
+	noCharts           bool
+	noDiagrams         bool
+	listSlides         bool
+	swapOutputDir      string
+	keepGoing          bool
+	swapJobs           int
+	failOnNoOp         bool
+	includeParts       []string
+	excludeParts       []string
+	matchSysClr        bool
+	recacheSysClr      bool
+	mapUnmatchedTo     string
+	verifyOutput       bool
+	recolorMedia       bool
+	includeTableStyles bool
+	includeTheme       bool
+	recordFile         string
+	reportFile         string
+	normalizeEmpty     bool
+	preserveCase       bool
+	allowedColorsFile  string
+	protectColors      []string
+	themeMappingFlags  []string
+	mappingFile        string
+	summaryOnly        bool
+	validateTargets    bool
+	showTiming         bool
+	normalizeScope     string
+	cleanScope         string
+	renameVerify       bool
+	findScopeFilter    string
+	themifyScope       string
+	themifyThemeFilter []string
+	grayscaleScope     string
+	grayscaleKeep      bool
+	hueIncludeNeutrals bool
+	adjustLightness    float64
+	adjustSaturation   float64
+	adjustKeepBW       bool
+	paletteThemeFilter []string
+	imageColors        int
+	listIncludeExtra   bool
 )
 
 func init() {
 	colorCmd.AddCommand(colorListCmd)
 	colorCmd.AddCommand(colorSwapCmd)
 	colorCmd.AddCommand(colorRenameCmd)
+	colorCmd.AddCommand(colorFindCmd)
+	colorCmd.AddCommand(colorValidateCmd)
+	colorCmd.AddCommand(colorUndoCmd)
+	colorCmd.AddCommand(colorNormalizeCmd)
+	colorCmd.AddCommand(colorCleanCmd)
+	colorCmd.AddCommand(colorThemifyCmd)
+	colorCmd.AddCommand(colorGrayscaleCmd)
+	colorCmd.AddCommand(colorHueShiftCmd)
+	colorCmd.AddCommand(colorAdjustCmd)
+	colorCmd.AddCommand(colorFromImageCmd)
+	colorCmd.AddCommand(colorApplyPaletteCmd)
+
+	// Add --include-extra flag to list command
+	colorListCmd.Flags().BoolVar(&listIncludeExtra, "include-extra", false, "Also list each theme's extra color schemes (extraClrSchemeLst)")
 
 	// Add --theme flag to swap command
 	colorSwapCmd.Flags().StringSliceVar(&themeFilter, "theme", nil, "Comma-separated list of themes to target (e.g., theme1,theme2)")
 
+	// Add --theme-by-name flag to swap command
+	colorSwapCmd.Flags().StringArrayVar(&themeByName, "theme-by-name", nil, "Target themes by color scheme or theme name, e.g. \"Corporate Brand\" (repeatable)")
+
 	// Add --scope flag to swap command
-	colorSwapCmd.Flags().StringVar(&scopeFilter, "scope", "all", "Processing scope (all, content, master)")
+	colorSwapCmd.Flags().StringVar(&scopeFilter, "scope", "all", "Processing scope (all, content, master, theme)")
 
 	// Add --slides flag to swap command
 	colorSwapCmd.Flags().StringVar(&slideFilter, "slides", "", "Comma-separated slide numbers or ranges (e.g., 1,3,5-8)")
 
+	// Add --section flag to swap command
+	colorSwapCmd.Flags().StringArrayVar(&sectionFilter, "section", nil, "Target the slides of a named section, e.g. \"Intro\" (repeatable; adds to --slides)")
+
+	// Add --visible-index flag to swap command
+	colorSwapCmd.Flags().BoolVar(&visibleIndex, "visible-index", false, "Count --slides numbers among visible slides only, skipping hidden slides")
+
+	// Add --allow-empty flag to swap command
+	colorSwapCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "Succeed without changes when no --slides use the --theme themes")
+
+	// Add --no-notes flag to swap command
+	colorSwapCmd.Flags().BoolVar(&noNotes, "no-notes", false, "Leave speaker notes (ppt/notesSlides) untouched, whatever the scope or --slides")
+
+	// Add --no-charts flag to swap command
+	colorSwapCmd.Flags().BoolVar(&noCharts, "no-charts", false, "Leave charts (ppt/charts) untouched, whatever the scope or --slides")
+
+	// Add --no-diagrams flag to swap command
+	colorSwapCmd.Flags().BoolVar(&noDiagrams, "no-diagrams", false, "Leave SmartArt diagrams (ppt/diagrams) untouched, whatever the scope or --slides")
+
+	// Add --strict flag to swap command
+	colorSwapCmd.Flags().BoolVar(&strictMapping, "strict", false, "Reject mappings whose target is the same as their source (e.g., accent1:accent1), and undefined targets found by --validate-targets")
+
+	// Add --validate-targets flag to swap command
+	colorSwapCmd.Flags().BoolVar(&validateTargets, "validate-targets", false, "Warn when a scheme target is a slot the affected themes do not define (errors with --strict)")
+
+	// Add --list-slides flag to swap command
+	colorSwapCmd.Flags().BoolVar(&listSlides, "list-slides", false, "Print each slide's number and title, marking those --slides targets, then exit without writing")
+
+	// Add --output-dir flag to swap command
+	colorSwapCmd.Flags().StringVar(&swapOutputDir, "output-dir", "", "Swap several inputs, writing each to this directory under its own file name")
+
+	// Add --keep-going flag to swap command
+	colorSwapCmd.Flags().BoolVar(&keepGoing, "keep-going", false, "With --output-dir, continue with the remaining inputs after one fails")
+
+	// Add --jobs flag to swap command
+	colorSwapCmd.Flags().IntVarP(&swapJobs, "jobs", "j", 1, "With --output-dir, number of inputs to process at once")
+
+	// Add --summary-only flag to swap command
+	colorSwapCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Print just one line with the files processed, replacements made, and elapsed time")
+
+	// Add --timing flag to swap command
+	colorSwapCmd.Flags().BoolVar(&showTiming, "timing", false, "Print the wall time, throughput, and time spent extracting, processing, and writing (always on with --verbose)")
+
+	// Add --fail-on-no-op flag to swap command
+	colorSwapCmd.Flags().BoolVar(&failOnNoOp, "fail-on-no-op", false, "Exit with an error if no color reference was replaced")
+
+	// Add --include flag to swap command
+	colorSwapCmd.Flags().StringArrayVar(&includeParts, "include", nil, "Only process parts whose archive path matches this glob, e.g. \"ppt/charts/*\" (repeatable)")
+
+	// Add --exclude flag to swap command
+	colorSwapCmd.Flags().StringArrayVar(&excludeParts, "exclude", nil, "Skip parts whose archive path matches this glob, e.g. \"ppt/notesSlides/*\" (repeatable)")
+
+	// Add --match-sysclr flag to swap command
+	colorSwapCmd.Flags().BoolVar(&matchSysClr, "match-sysclr", false, "Let hex sources match system colors (sysClr) by their lastClr value, replacing them with fixed colors")
+
+	// Add --recache-sysclr flag to swap command
+	colorSwapCmd.Flags().BoolVar(&recacheSysClr, "recache-sysclr", false, "Rewrite only the lastClr value of system colors (sysClr) matched by hex→hex mappings")
+	colorSwapCmd.MarkFlagsMutuallyExclusive("match-sysclr", "recache-sysclr")
+
+	// Add --map-unmatched-to flag to swap command
+	colorSwapCmd.Flags().StringVar(&mapUnmatchedTo, "map-unmatched-to", "", "Replace every color reference the mapping does not cover with this color (e.g., 808080)")
+
+	// Add --verify flag to swap command
+	colorSwapCmd.Flags().BoolVar(&verifyOutput, "verify", false, "Re-open the output after writing and check it is structurally intact")
+
+	// Add --recolor-media flag to swap command
+	colorSwapCmd.Flags().BoolVar(&recolorMedia, "recolor-media", false, "Report the raster images (PNG, JPEG, ...) the swap cannot recolor, and the slides using them")
+
+	// Add --include-table-styles flag to swap command
+	colorSwapCmd.Flags().BoolVar(&includeTableStyles, "include-table-styles", false, "Also swap colors in table styles and presentation defaults (tableStyles.xml, presentation.xml)")
+
+	// Add --include-theme flag to swap command
+	colorSwapCmd.Flags().BoolVar(&includeTheme, "include-theme", false, "Also recolor the theme's color scheme definitions (affects every slide using the theme)")
+
+	// Add --normalize-empty flag to swap command
+	colorSwapCmd.Flags().BoolVar(&normalizeEmpty, "normalize-empty", false, "Also rewrite empty schemeClr/srgbClr containers in processed parts as self-closing elements")
+
+	// Add --preserve-case flag to swap command
+	colorSwapCmd.Flags().BoolVar(&preserveCase, "preserve-case", false, "Write hex targets with the casing given in the mapping instead of uppercase")
+
+	// Add --record flag to swap command
+	colorSwapCmd.Flags().StringVar(&recordFile, "record", "", "Write every change made to a JSON file, for use with 'color undo'")
+
+	// Add --report flag to swap command
+	colorSwapCmd.Flags().StringVar(&reportFile, "report", "", "Write the distinct color changes made in each part, with counts, as JSON ('-' for stdout)")
+
+	// Add --theme-mapping flag to swap command
+	colorSwapCmd.Flags().StringArrayVar(&themeMappingFlags, "theme-mapping", nil, "Mapping applied only to parts using a theme, as theme=mapping (repeatable)")
+
+	// Add --mapping-file flag to swap command
+	colorSwapCmd.Flags().StringVar(&mappingFile, "mapping-file", "", "Read mappings from a file, with # comments and [theme] sections (adds to the mapping argument)")
+
+	// Add --allowed-colors flag to swap command
+	colorSwapCmd.Flags().StringVar(&allowedColorsFile, "allowed-colors", "", "File listing the only hex colors mappings may target (brand allow-list)")
+
+	// Add --protect flag to swap command
+	colorSwapCmd.Flags().StringSliceVar(&protectColors, "protect", nil, "Comma-separated colors no mapping or --map-unmatched-to may touch (e.g., dk1,lt1)")
+
 	// Add --theme flag to rename command
 	colorRenameCmd.Flags().StringSliceVar(&renameThemeFilter, "theme", nil, "Comma-separated list of themes to target (e.g., theme1,theme2)")
+
+	// Add --theme-by-name flag to rename command
+	colorRenameCmd.Flags().StringArrayVar(&renameThemeByName, "theme-by-name", nil, "Target themes by color scheme or theme name, e.g. \"Corporate Brand\" (repeatable)")
+
+	// Add --verify flag to rename command
+	colorRenameCmd.Flags().BoolVar(&renameVerify, "verify", false, "Re-open the output after writing and check it is structurally intact")
+
+	// Add --timing flag to rename command
+	colorRenameCmd.Flags().BoolVar(&showTiming, "timing", false, "Print the wall time, throughput, and time spent extracting, processing, and writing (always on with --verbose)")
+
+	// Add --scope flag to find command
+	colorFindCmd.Flags().StringVar(&findScopeFilter, "scope", "all", "Search scope (all, content, master, theme)")
+
+	// Add --scope flag to normalize command
+	colorNormalizeCmd.Flags().StringVar(&normalizeScope, "scope", "all", "Processing scope (all, content, master, theme)")
+
+	// Add --scope flag to clean command
+	colorCleanCmd.Flags().StringVar(&cleanScope, "scope", "all", "Processing scope (all, content, master, theme)")
+
+	// Add --scope flag to themify command
+	colorThemifyCmd.Flags().StringVar(&themifyScope, "scope", "all", "Processing scope (all, content, master, theme)")
+
+	// Add --theme flag to themify command
+	colorThemifyCmd.Flags().StringSliceVar(&themifyThemeFilter, "theme", nil, "Comma-separated list of themes to target (e.g., theme1,theme2)")
+
+	// Add --scope flag to grayscale command
+	colorGrayscaleCmd.Flags().StringVar(&grayscaleScope, "scope", "all", "Processing scope (all, content, master, theme)")
+
+	// Add --keep-scheme flag to grayscale command
+	colorGrayscaleCmd.Flags().BoolVar(&grayscaleKeep, "keep-scheme", false, "Turn the theme palettes gray instead of replacing scheme references")
+
+	// Add --include-neutrals flag to hue-shift command
+	colorHueShiftCmd.Flags().BoolVar(&hueIncludeNeutrals, "include-neutrals", false, "Also rotate dk1, lt1, dk2, and lt2")
+
+	// Add --lightness, --saturation, and --keep-black-white flags to adjust command
+	colorAdjustCmd.Flags().Float64Var(&adjustLightness, "lightness", 0, "Percentage points to add to each color's lightness (e.g., 10 or -5)")
+	colorAdjustCmd.Flags().Float64Var(&adjustSaturation, "saturation", 0, "Percentage points to add to each color's saturation (e.g., 10 or -5)")
+	colorAdjustCmd.Flags().BoolVar(&adjustKeepBW, "keep-black-white", false, "Leave a pure black dk1 and a pure white lt1 unchanged")
+
+	// Add --colors flag to from-image command
+	colorFromImageCmd.Flags().IntVar(&imageColors, "colors", len(accentSlots), "Number of dominant colors to extract, filling accent1 onwards (1-6)")
+
+	// Add --theme flag to the palette commands
+	for _, cmd := range []*cobra.Command{colorGrayscaleCmd, colorHueShiftCmd, colorAdjustCmd, colorFromImageCmd, colorApplyPaletteCmd} {
+		cmd.Flags().StringSliceVar(&paletteThemeFilter, "theme", nil, "Comma-separated list of themes to target (e.g., theme1,theme2)")
+	}
 }
 
 func runColorList(cmd *cobra.Command, args []string) error {
@@ -123,6 +797,12 @@ func runColorList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no themes found")
 	}
 
+	// Color maps resolve bg1/tx1/bg2/tx2 per master
+	colorMaps, err := ReadMasterColorMaps(inputFile)
+	if err != nil {
+		return fmt.Errorf("error reading color maps: %w", err)
+	}
+
 	// Display themes
 	cmd.Printf("\nFound %d theme(s) in %s:\n\n", len(themes), inputFile)
 
@@ -132,24 +812,54 @@ func runColorList(cmd *cobra.Command, args []string) error {
 		cmd.Printf("Color Scheme: %s\n", theme.ColorSchemeName)
 		cmd.Println()
 		cmd.Println("Colors:")
-		cmd.Printf("  dk1      (Dark 1):              #%s\n", theme.Colors.Dk1)
-		cmd.Printf("  lt1      (Light 1):             #%s\n", theme.Colors.Lt1)
-		cmd.Printf("  dk2      (Dark 2):              #%s\n", theme.Colors.Dk2)
-		cmd.Printf("  lt2      (Light 2):             #%s\n", theme.Colors.Lt2)
-		cmd.Printf("  accent1  (Accent 1):            #%s\n", theme.Colors.Accent1)
-		cmd.Printf("  accent2  (Accent 2):            #%s\n", theme.Colors.Accent2)
-		cmd.Printf("  accent3  (Accent 3):            #%s\n", theme.Colors.Accent3)
-		cmd.Printf("  accent4  (Accent 4):            #%s\n", theme.Colors.Accent4)
-		cmd.Printf("  accent5  (Accent 5):            #%s\n", theme.Colors.Accent5)
-		cmd.Printf("  accent6  (Accent 6):            #%s\n", theme.Colors.Accent6)
-		cmd.Printf("  hlink    (Hyperlink):           #%s\n", theme.Colors.Hlink)
-		cmd.Printf("  folHlink (Followed Hyperlink):  #%s\n", theme.Colors.FolHlink)
+		printSchemeColors(cmd, theme.Colors, "  ")
 		cmd.Println()
+
+		if listIncludeExtra && len(theme.ExtraColorSchemes) > 0 {
+			cmd.Println("Extra color schemes:")
+			for _, extra := range theme.ExtraColorSchemes {
+				cmd.Printf("  %s\n", extra.Name)
+				printSchemeColors(cmd, extra.Colors, "    ")
+			}
+			cmd.Println()
+		}
+
+		printed := false
+		for _, m := range colorMaps {
+			if m.Theme != theme.FileName {
+				continue
+			}
+			if !printed {
+				cmd.Println("Color map:")
+				printed = true
+			}
+			cmd.Printf("  %-18s bg1→%s  tx1→%s  bg2→%s  tx2→%s\n", m.Master,
+				m.ColorMap["bg1"], m.ColorMap["tx1"], m.ColorMap["bg2"], m.ColorMap["tx2"])
+		}
+		if printed {
+			cmd.Println()
+		}
 	}
 
 	return nil
 }
 
+// schemeColorLabels are the display names of the scheme color slots, by slot
+var schemeColorLabels = map[string]string{
+	"dk1": "Dark 1", "lt1": "Light 1", "dk2": "Dark 2", "lt2": "Light 2",
+	"accent1": "Accent 1", "accent2": "Accent 2", "accent3": "Accent 3",
+	"accent4": "Accent 4", "accent5": "Accent 5", "accent6": "Accent 6",
+	"hlink": "Hyperlink", "folHlink": "Followed Hyperlink",
+}
+
+// printSchemeColors prints the slots of a color scheme, one per line, each line
+// starting with indent
+func printSchemeColors(cmd *cobra.Command, colors ColorScheme, indent string) {
+	for _, name := range schemeColorNames {
+		cmd.Printf("%s%-8s %-22s #%s\n", indent, name, "("+schemeColorLabels[name]+"):", colors.Get(name))
+	}
+}
+
 func runColorSwap(cmd *cobra.Command, args []string) error {
 	// Suppress usage and errors for validation errors - syntax errors are
 	// already handled by Cobra's Args validator. We'll print errors ourselves.
@@ -157,72 +867,529 @@ func runColorSwap(cmd *cobra.Command, args []string) error {
 	cmd.SilenceErrors = true
 
 	mappingStr := args[0]
+
+	// Several inputs written into one directory
+	if swapOutputDir != "" {
+		return runColorSwapBatch(cmd, mappingStr, args[1:])
+	}
+
 	inputFile := args[1]
 	outputFile := args[2]
 
 	// Validate input file
 	if err := ValidateInputFile(inputFile); err != nil {
 		cmd.PrintErrln("Error:", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+		return exitWith(exitCodeFor(err))
+	}
+
+	// Preview the slides instead of swapping; nothing is written
+	if listSlides {
+		return printSlideList(cmd, inputFile)
 	}
 
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
 	// Prompt for overwrite if needed
 	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
-		return err
+		return exitWith(ExitAborted)
+	}
+
+	// Role assignment ("hlink=accent1") edits theme definitions instead of references
+	if isRoleAssignment(mappingStr) {
+		if err := checkRoleAssignmentFlags(cmd); err != nil {
+			return err
+		}
+		return runRoleAssignment(cmd, mappingStr, inputFile, outputFile)
 	}
 
-	// Parse color mapping
-	colorMapping, err := ParseColorMapping(mappingStr)
+	swap, err := parseSwapRequest(cmd, mappingStr)
 	if err != nil {
-		cmd.PrintErrln("Error:", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+		return err
 	}
 
-	// Parse slide filter if provided
-	var slides []int
-	if slideFilter != "" {
-		slides, err = ParseSlideRange(slideFilter)
+	start := time.Now()
+	var stats swapStats
+	if err := swap.run(cmd, inputFile, outputFile, &stats); err != nil {
+		return err
+	}
+	if summaryOnly {
+		printSwapSummary(cmd, stats, time.Since(start), "→ "+outputFile)
+	}
+	return nil
+}
+
+// swapStats totals what swaps did, for --summary-only
+type swapStats struct {
+	files        int // Parts processed
+	replacements int // Color references replaced
+}
+
+// printSwapSummary prints the single line --summary-only shows for a swap, or for a
+// whole batch, ending with where the output went
+func printSwapSummary(cmd *cobra.Command, stats swapStats, elapsed time.Duration, where string) {
+	cmd.Printf("✓ %d files processed, %d color reference(s) replaced in %s %s\n",
+		stats.files, stats.replacements, elapsed.Round(time.Millisecond), where)
+}
+
+// swapRequest is a parsed and validated color swap, ready to apply to any input
+type swapRequest struct {
+	colorMapping  map[string]string
+	themeMappings map[string]map[string]string
+	slides        []int
+	fallback      string   // --map-unmatched-to color, empty if not given
+	mappingStrs   []string // Mappings formatted for display
+}
+
+// parseSwapRequest parses the mapping and swap flags, printing any error itself
+func parseSwapRequest(cmd *cobra.Command, mappingStr string) (*swapRequest, error) {
+	// Parse theme-specific mappings
+	themeMappings := make(map[string]map[string]string)
+	for _, value := range themeMappingFlags {
+		theme, mapping, notices, err := parseThemeMapping(value)
 		if err != nil {
 			cmd.PrintErrln("Error:", err)
-			return fmt.Errorf("") // Return empty error to set exit code
+			return nil, errSilent
 		}
+		printNotices(cmd, notices)
+		if _, exists := themeMappings[theme]; exists {
+			cmd.PrintErrf("Error: --theme-mapping given more than once for %s\n", theme)
+			return nil, errSilent
+		}
+		themeMappings[theme] = mapping
 	}
 
-	// Validate scope compatibility with slides
-	if len(slides) > 0 {
-		// --slides can only be used with --scope content
-		if scopeFilter != "content" {
-			cmd.PrintErrln("Error: --slides can only be used with --scope content")
-			return fmt.Errorf("") // Return empty error to set exit code
+	// Load the mapping file, whose sections add theme-specific mappings
+	var spec *MappingFile
+	if mappingFile != "" {
+		var notices []string
+		var err error
+		spec, notices, err = LoadMappingFile(mappingFile)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+		printNotices(cmd, notices)
+		for theme, mapping := range spec.ThemeMappings {
+			if _, exists := themeMappings[theme]; exists {
+				cmd.PrintErrf("Error: %s is given both by --theme-mapping and in %s\n", strings.TrimSuffix(theme, ".xml"), mappingFile)
+				return nil, errSilent
+			}
+			themeMappings[theme] = mapping
 		}
 	}
 
-	// Format mappings for display
-	var mappingStrs []string
-	for source, target := range colorMapping {
-		mappingStrs = append(mappingStrs, fmt.Sprintf("%s→%s", source, target))
+	// Parse color mapping (may be empty when only theme-specific mappings or a
+	// mapping file are given)
+	var err error
+	colorMapping := map[string]string{}
+	if strings.TrimSpace(mappingStr) != "" || (len(themeMappings) == 0 && spec == nil) {
+		var notices []string
+		colorMapping, notices, err = parseColorMapping(mappingStr)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+		printNotices(cmd, notices)
 	}
-
-	filesProcessed, matchedSlides, err := ProcessPPTX(inputFile, outputFile, colorMapping, themeFilter, scopeFilter, slides)
-	if err != nil {
-		cmd.PrintErrf("\nError: %v\n", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+	if spec != nil {
+		for source, target := range spec.Mapping {
+			if existing, exists := colorMapping[source]; exists && existing != target {
+				cmd.PrintErrf("Error: %v\nThe mapping argument and %s disagree\n", &ErrConflictingMapping{Source: source, A: existing, B: target}, mappingFile)
+				return nil, errSilent
+			}
+			colorMapping[source] = target
+		}
 	}
 
-	// Print processing header after ProcessPPTX to include matched slides count
-	config := ProcessingConfig{
-		Mappings:      mappingStrs,
-		Themes:        themeFilter,
-		Slides:        slides,
-		SlidesMatched: matchedSlides,
-		Scope:         scopeFilter,
+	// Identity mappings are no-ops; --strict treats them as mistakes
+	if strictMapping {
+		for _, mapping := range append([]map[string]string{colorMapping}, mapValues(themeMappings)...) {
+			if err := ValidateNoIdentityMappings(mapping); err != nil {
+				cmd.PrintErrln("Error:", err)
+				return nil, errSilent
+			}
+		}
 	}
-	PrintProcessingHeader(cmd, inputFile, config)
 
-	PrintSuccess(cmd, filesProcessed, "files", outputFile)
-
-	return nil
-}
+	// A target that is also a source of the other kind reads like a cascade; say it isn't
+	general := make(map[MappingChain]bool)
+	for _, chain := range FindCrossTypeChains(colorMapping, nil) {
+		general[chain] = true
+		cmd.PrintErrln("Warning:", chain)
+	}
+	themeNames := make([]string, 0, len(themeMappings))
+	for theme := range themeMappings {
+		themeNames = append(themeNames, theme)
+	}
+	sortNatural(themeNames)
+	for _, theme := range themeNames {
+		for _, chain := range FindCrossTypeChains(colorMapping, themeMappings[theme]) {
+			if !general[chain] {
+				cmd.PrintErrf("Warning: %s: %s\n", strings.TrimSuffix(theme, ".xml"), chain)
+			}
+		}
+	}
+
+	// Parse catch-all color for unmatched references
+	var fallback string
+	if mapUnmatchedTo != "" {
+		fallback, err = ParseFallbackColor(mapUnmatchedTo)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+		if preserveCase && isValidHexColor(fallback) {
+			fallback = strings.TrimSpace(mapUnmatchedTo)
+		}
+	}
+
+	// Enforce brand allow-list if provided
+	if allowedColorsFile != "" {
+		allowed, err := LoadAllowedColors(allowedColorsFile)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+		for _, mapping := range append([]map[string]string{colorMapping}, mapValues(themeMappings)...) {
+			if err := ValidateAllowedColors(mapping, allowed, allowedColorsFile); err != nil {
+				cmd.PrintErrln("Error:", err)
+				return nil, errSilent
+			}
+		}
+		if isValidHexColor(fallback) && !allowed[fallback] {
+			cmd.PrintErrf("Error: --map-unmatched-to color '%s' is not in the allowed colors list (%s)\n", fallback, allowedColorsFile)
+			return nil, errSilent
+		}
+	}
+
+	// Keep mappings off protected colors
+	if len(protectColors) > 0 {
+		protected, err := ParseProtectedColors(protectColors)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+		for _, mapping := range append([]map[string]string{colorMapping}, mapValues(themeMappings)...) {
+			if err := ValidateProtectedColors(mapping, protected); err != nil {
+				cmd.PrintErrln("Error:", err)
+				return nil, errSilent
+			}
+		}
+		if given, ok := protected[protectedKey(fallback)]; ok {
+			cmd.PrintErrf("Error: --map-unmatched-to color '%s' touches protected color '%s'\n", fallback, given)
+			return nil, errSilent
+		}
+	}
+
+	// Parse slide filter if provided
+	var slides []int
+	if slideFilter != "" {
+		slides, err = ParseSlideRange(slideFilter)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return nil, errSilent
+		}
+	}
+
+	// --visible-index only changes how --slides numbers are read
+	if visibleIndex && len(slides) == 0 {
+		cmd.PrintErrln("Error: --visible-index requires --slides")
+		return nil, errSilent
+	}
+
+	// Validate scope compatibility with slides
+	if len(slides) > 0 || len(sectionFilter) > 0 {
+		flag := "--slides"
+		if len(slides) == 0 {
+			flag = "--section"
+		}
+
+		// --slides and --section can only be used with --scope content
+		if scopeFilter != "content" {
+			cmd.PrintErrf("Error: %s can only be used with --scope content\n", flag)
+			return nil, errSilent
+		}
+
+		// Theme colors apply to every slide, so they can't be limited to some slides
+		if includeTheme {
+			cmd.PrintErrf("Error: --include-theme cannot be used with %s\n", flag)
+			return nil, errSilent
+		}
+	}
+
+	// Format mappings for display
+	var mappingStrs []string
+	for source, target := range colorMapping {
+		mappingStrs = append(mappingStrs, fmt.Sprintf("%s→%s", source, target))
+	}
+	var themeMappingStrs []string
+	for theme, mapping := range themeMappings {
+		for source, target := range mapping {
+			themeMappingStrs = append(themeMappingStrs, fmt.Sprintf("%s: %s→%s", strings.TrimSuffix(theme, ".xml"), source, target))
+		}
+	}
+	sortNatural(themeMappingStrs)
+	mappingStrs = append(mappingStrs, themeMappingStrs...)
+	if fallback != "" {
+		mappingStrs = append(mappingStrs, fmt.Sprintf("unmatched→%s", fallback))
+	}
+
+	return &swapRequest{
+		colorMapping:  colorMapping,
+		themeMappings: themeMappings,
+		slides:        slides,
+		fallback:      fallback,
+		mappingStrs:   mappingStrs,
+	}, nil
+}
+
+// run applies the swap to one input, writing outputFile, and reports the result.
+// The files processed and replacements made are added to stats, if not nil; with
+// --summary-only nothing but warnings and errors is printed, leaving the summary
+// to the caller.
+func (swap *swapRequest) run(cmd *cobra.Command, inputFile, outputFile string, stats *swapStats) error {
+	colorMapping, themeMappings, slides := swap.colorMapping, swap.themeMappings, swap.slides
+
+	// A report written to stdout is the result; keep status lines off it
+	stdout := cmd.OutOrStdout()
+	if reportFile == "-" {
+		cmd.SetOut(cmd.ErrOrStderr())
+		defer cmd.SetOut(stdout)
+	}
+
+	selectedThemes, err := resolveThemeFilter(cmd, inputFile, themeFilter, themeByName)
+	if err != nil {
+		return err
+	}
+
+	// A slot the theme leaves undefined renders black; --strict refuses to swap to it
+	if validateTargets {
+		themes, err := ReadThemes(inputFile)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return errSilent
+		}
+		undefined := FindUndefinedTargets(themes, colorMapping, themeMappings, selectedThemes)
+		for _, target := range undefined {
+			if strictMapping {
+				cmd.PrintErrln("Error:", target)
+			} else {
+				cmd.PrintErrln("Warning:", target)
+			}
+		}
+		if strictMapping && len(undefined) > 0 {
+			return exitWith(ExitIssues)
+		}
+	}
+
+	opts := Options{
+		IncludeTableStyles: includeTableStyles,
+		IncludeTheme:       includeTheme,
+		ThemeMappings:      themeMappings,
+		Progress:           cliProgress(cmd),
+		VisibleIndex:       visibleIndex,
+		AllowEmpty:         allowEmpty,
+		Sections:           sectionFilter,
+		NoNotes:            noNotes,
+		NoCharts:           noCharts,
+		NoDiagrams:         noDiagrams,
+		Include:            includeParts,
+		Exclude:            excludeParts,
+		MatchSysClr:        matchSysClr,
+		RecacheSysClr:      recacheSysClr,
+		MapUnmatchedTo:     swap.fallback,
+		Protect:            protectColors,
+		NormalizeEmpty:     normalizeEmpty,
+		PreserveCase:       preserveCase,
+	}
+	var replacements int
+	opts.Replacements = &replacements
+	var impacts []ThemeImpact
+	opts.ThemeImpacts = &impacts
+	if recordFile != "" || reportFile != "" {
+		opts.Record = NewChangeLog(colorMapping)
+	}
+	if verbose {
+		// Show what will actually run, after canonicalization and theme overlays
+		cmd.PrintErrln("Note: effective mapping:", formatMappingPairs(EffectiveMapping(colorMapping, nil, preserveCase)))
+		themeNames := make([]string, 0, len(themeMappings))
+		for theme := range themeMappings {
+			themeNames = append(themeNames, theme)
+		}
+		sortNatural(themeNames)
+		for _, theme := range themeNames {
+			cmd.PrintErrf("Note: effective mapping for %s: %s\n", strings.TrimSuffix(theme, ".xml"),
+				formatMappingPairs(EffectiveMapping(colorMapping, themeMappings[theme], preserveCase)))
+		}
+
+		// Advisory only: a redundant conversion still links references to the theme
+		if themes, err := ReadThemes(inputFile); err == nil {
+			for _, conversion := range FindRedundantConversions(themes, colorMapping, themeMappings, selectedThemes) {
+				cmd.PrintErrln("Note:", conversion)
+			}
+		}
+		opts.Preflight = func(effects []MappingEffect) {
+			for _, effect := range effects {
+				cmd.PrintErrln("Note:", effect)
+			}
+		}
+	}
+	if len(slides) > 0 || len(sectionFilter) > 0 {
+		warnSlideOrder(cmd, inputFile)
+	}
+	var timings PhaseTimings
+	opts.Timings = &timings
+	start := time.Now()
+	filesProcessed, matchedSlides, err := ProcessPPTXWithOptions(inputFile, outputFile, colorMapping, selectedThemes, scopeFilter, slides, opts)
+	elapsed := time.Since(start)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	if stats != nil {
+		stats.files += filesProcessed
+		stats.replacements += replacements
+	}
+
+	// Print processing header after ProcessPPTX to include matched slides count
+	config := ProcessingConfig{
+		Mappings:      swap.mappingStrs,
+		Themes:        selectedThemes,
+		Slides:        slides,
+		Sections:      sectionFilter,
+		SlidesMatched: matchedSlides,
+		Scope:         scopeFilter,
+	}
+	if !summaryOnly {
+		PrintProcessingHeader(cmd, inputFile, config)
+	}
+
+	if verifyOutput {
+		if err := VerifyOutput(inputFile, outputFile); err != nil {
+			cmd.PrintErrf("\nError: verification failed: %v\n", err)
+			return errSilent
+		}
+	}
+
+	if recordFile != "" {
+		if err := WriteChangeLog(recordFile, opts.Record); err != nil {
+			cmd.PrintErrf("\nError: %v\n", err)
+			return errSilent
+		}
+	}
+
+	if reportFile != "" {
+		if err := writeChangeReport(stdout, reportFile, SummarizeChanges(opts.Record)); err != nil {
+			cmd.PrintErrf("\nError: %v\n", err)
+			return errSilent
+		}
+	}
+
+	if !summaryOnly {
+		PrintSuccess(cmd, filesProcessed, "files", outputFile)
+		cmd.Printf("✓ %d color reference(s) replaced\n", replacements)
+		printThemeImpacts(cmd, inputFile, impacts)
+		if verifyOutput {
+			cmd.Println("✓ Output verified")
+		}
+		if recordFile != "" {
+			cmd.Printf("✓ %d change(s) recorded to %s\n", len(opts.Record.Changes), recordFile)
+		}
+		if reportFile != "" && reportFile != "-" {
+			cmd.Printf("✓ Changes by part reported to %s\n", reportFile)
+		}
+	}
+	if showTiming || verbose {
+		printTiming(cmd, inputFile, timings, elapsed)
+	}
+
+	// Colors baked into images are out of reach; say so rather than leave users guessing
+	if recolorMedia {
+		media, err := RasterMedia(inputFile)
+		if err != nil {
+			cmd.PrintErrf("\nError: %v\n", err)
+			return errSilent
+		}
+		printRasterMedia(cmd, media)
+	}
+
+	// Processing files is not the same as changing them
+	if failOnNoOp && replacements == 0 {
+		cmd.PrintErrln("Error: no color references were replaced (--fail-on-no-op)")
+		return exitWith(ExitNoOp)
+	}
+
+	return nil
+}
+
+// writeChangeReport writes the per-part change summary to path, or to stdout for "-"
+func writeChangeReport(stdout io.Writer, path string, parts []PartChanges) error {
+	if path != "-" {
+		return WriteChangeReport(path, parts)
+	}
+	data, err := json.MarshalIndent(parts, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, string(data))
+	return nil
+}
+
+// printThemeImpacts prints a table of the parts changed and replacements made
+// under each theme, so it is clear which masters a swap reached
+func printThemeImpacts(cmd *cobra.Command, inputFile string, impacts []ThemeImpact) {
+	if len(impacts) == 0 {
+		return
+	}
+
+	names := make(map[string]string)
+	if themes, err := ReadThemes(inputFile); err == nil {
+		for _, theme := range themes {
+			names[theme.FileName] = theme.ThemeName
+		}
+	}
+
+	rows := [][]string{{"Theme", "Name", "Files", "Replacements"}}
+	for _, impact := range impacts {
+		theme, name := strings.TrimSuffix(impact.Theme, ".xml"), names[impact.Theme]
+		if impact.Theme == "" {
+			theme, name = "(shared)", "parts used by several themes or none"
+		}
+		rows = append(rows, []string{theme, name, strconv.Itoa(impact.Files), strconv.Itoa(impact.Replacements)})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	cmd.Println("\nBy theme:")
+	for _, row := range rows {
+		cmd.Printf("  %-*s  %-*s  %*s  %*s\n", widths[0], row[0], widths[1], row[1], widths[2], row[2], widths[3], row[3])
+	}
+}
+
+// printRasterMedia lists raster images a swap left unchanged, with their slides
+func printRasterMedia(cmd *cobra.Command, media []MediaUse) {
+	if len(media) == 0 {
+		if !summaryOnly {
+			cmd.Println("✓ No raster images on slides")
+		}
+		return
+	}
+
+	cmd.PrintErrf("\nWarning: raster images keep their colors (%d); recolor them in an image editor:\n", len(media))
+	for _, use := range media {
+		label := "slide"
+		if len(use.Slides) > 1 {
+			label = "slides"
+		}
+		cmd.PrintErrf("  %s (%s %s)\n", use.Path, label, formatSlides(use.Slides))
+	}
+}
 
 func runColorRename(cmd *cobra.Command, args []string) error {
 	// Suppress usage and errors for validation errors - syntax errors are
@@ -237,34 +1404,647 @@ func runColorRename(cmd *cobra.Command, args []string) error {
 	// Validate name
 	if err := ValidateName(newName); err != nil {
 		cmd.PrintErrln("Error:", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+		return errSilent
 	}
 
 	// Validate input file
 	if err := ValidateInputFile(inputFile); err != nil {
 		cmd.PrintErrln("Error:", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+		return exitWith(exitCodeFor(err))
 	}
 
+	selectedThemes, err := resolveThemeFilter(cmd, inputFile, renameThemeFilter, renameThemeByName)
+	if err != nil {
+		return err
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
 	// Prompt for overwrite if needed
 	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
-		return err
+		return exitWith(ExitAborted)
 	}
 
 	// Print processing header
 	config := ProcessingConfig{
 		NewName: newName,
-		Themes:  renameThemeFilter,
+		Themes:  selectedThemes,
 	}
 	PrintProcessingHeader(cmd, inputFile, config)
 
-	themesRenamed, err := RenameColorScheme(inputFile, outputFile, newName, renameThemeFilter)
+	var timings PhaseTimings
+	start := time.Now()
+	outcomes, err := RenameColorSchemeOutcomes(inputFile, outputFile, newName, selectedThemes, Options{Progress: cliProgress(cmd), Timings: &timings})
+	elapsed := time.Since(start)
+	for _, outcome := range outcomes {
+		if !outcome.Renamed {
+			cmd.PrintErrf("Skipped %s: %s\n", outcome.Theme, outcome.Reason)
+		}
+	}
 	if err != nil {
 		cmd.PrintErrf("\nError: %v\n", err)
-		return fmt.Errorf("") // Return empty error to set exit code
+		return exitWith(exitCodeFor(err))
 	}
+	themesRenamed := countRenamed(outcomes)
 
+	if renameVerify {
+		if err := VerifyOutput(inputFile, outputFile); err != nil {
+			cmd.PrintErrf("\nError: verification failed: %v\n", err)
+			return errSilent
+		}
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Renamed {
+			cmd.Printf("  %s: %s → %s\n", outcome.Theme, outcome.OldName, newName)
+		}
+	}
 	PrintSuccess(cmd, themesRenamed, "theme(s)", outputFile)
+	if renameVerify {
+		cmd.Println("✓ Output verified")
+	}
+	if showTiming || verbose {
+		printTiming(cmd, inputFile, timings, elapsed)
+	}
 
 	return nil
 }
+
+func runColorFind(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	color := args[0]
+	inputFile := args[1]
+
+	warnSlideOrder(cmd, inputFile)
+
+	var usage *ColorUsage
+	err := withExtractedPPTX(inputFile, func(tempDir string) error {
+		var err error
+		usage, err = FindColorUsage(tempDir, color, findScopeFilter)
+		return err
+	})
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	switch len(usage.Slides) {
+	case 0:
+		cmd.Printf("%s is not used on any slide\n", color)
+	case 1:
+		cmd.Printf("%s is used on 1 slide: %s\n", color, formatSlides(usage.Slides))
+	default:
+		cmd.Printf("%s is used on %d slides: %s\n", color, len(usage.Slides), formatSlides(usage.Slides))
+	}
+
+	if len(usage.OtherParts) > 0 {
+		cmd.Println("Also used in:")
+		for _, part := range usage.OtherParts {
+			cmd.Printf("  %s\n", part)
+		}
+	}
+
+	return nil
+}
+
+func runColorValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile := args[0]
+
+	issues, err := ValidateColors(inputFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	if len(issues) == 0 {
+		cmd.Printf("✓ Every scheme color reference in %s resolves to a defined theme color\n", inputFile)
+		return nil
+	}
+
+	cmd.Printf("Found %d suspicious theme color(s) in %s:\n", len(issues), inputFile)
+	for _, issue := range issues {
+		cmd.Printf("  %s\n", issue)
+		if len(issue.Slides) > 0 {
+			cmd.Printf("    slides: %s\n", formatSlides(issue.Slides))
+		}
+		for _, part := range issue.OtherParts {
+			cmd.Printf("    %s\n", part)
+		}
+	}
+	return exitWith(ExitIssues)
+}
+
+func runColorUndo(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	recordPath := args[0]
+	inputFile := args[1]
+	outputFile := args[2]
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	changeLog, err := ReadChangeLog(recordPath)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	cmd.Printf("Processing %s...\n", inputFile)
+	cmd.Printf("Undoing %d change(s) from %s\n", len(changeLog.Changes), recordPath)
+
+	partsRestored, err := UndoChanges(inputFile, outputFile, changeLog)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, partsRestored, "files", outputFile)
+
+	return nil
+}
+
+func runColorNormalize(cmd *cobra.Command, args []string) error {
+	return runRewrite(cmd, args[0], args[1], normalizeScope, NormalizeHexCase)
+}
+
+func runColorClean(cmd *cobra.Command, args []string) error {
+	return runRewrite(cmd, args[0], args[1], cleanScope, RemoveIdentityModifiers)
+}
+
+func runColorThemify(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile, outputFile := args[0], args[1]
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{
+		Themes: themifyThemeFilter,
+		Scope:  themifyScope,
+	})
+
+	if verbose {
+		themeMappings, err := ThemifyMappings(inputFile)
+		if err != nil {
+			cmd.PrintErrf("\nError: %v\n", err)
+			return errSilent
+		}
+		themes := make([]string, 0, len(themeMappings))
+		for theme := range themeMappings {
+			themes = append(themes, theme)
+		}
+		sort.Slice(themes, func(i, j int) bool { return naturalLess(themes[i], themes[j]) })
+		for _, theme := range themes {
+			cmd.PrintErrf("Note: %s: %s\n", theme, formatMappingPairs(EffectiveMapping(themeMappings[theme], nil, false)))
+		}
+	}
+
+	var replacements int
+	filesProcessed, err := Themify(inputFile, outputFile, themifyThemeFilter, themifyScope, Options{
+		Progress:     cliProgress(cmd),
+		Replacements: &replacements,
+	})
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	cmd.Printf("✓ %d color reference(s) replaced\n", replacements)
+	PrintSuccess(cmd, filesProcessed, "files", outputFile)
+
+	return nil
+}
+
+func runColorGrayscale(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile, outputFile := args[0], args[1]
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	// Validate scope
+	if err := validateScope(grayscaleScope); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{Themes: paletteThemeFilter, Scope: grayscaleScope})
+
+	if err := Grayscale(inputFile, outputFile, grayscaleScope, grayscaleKeep, paletteThemeFilter); err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	cmd.Printf("✓ Output saved to %s\n", outputFile)
+
+	return nil
+}
+
+func runColorHueShift(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile, outputFile := args[1], args[2]
+
+	degrees, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || math.IsInf(degrees, 0) || math.IsNaN(degrees) {
+		cmd.PrintErrf("Error: invalid angle '%s'. Expected a number of degrees (e.g., 30 or -45)\n", args[0])
+		return errSilent
+	}
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{Themes: paletteThemeFilter})
+	cmd.Printf("Hue shift: %g°\n", degrees)
+
+	themesProcessed, err := ShiftHue(inputFile, outputFile, degrees, hueIncludeNeutrals, paletteThemeFilter)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, themesProcessed, "theme(s)", outputFile)
+
+	return nil
+}
+
+func runColorAdjust(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile, outputFile := args[0], args[1]
+
+	if adjustLightness == 0 && adjustSaturation == 0 {
+		cmd.PrintErrln("Error: nothing to adjust; pass --lightness and/or --saturation")
+		return errSilent
+	}
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{Themes: paletteThemeFilter})
+	cmd.Printf("Lightness: %+g%%, saturation: %+g%%\n", adjustLightness, adjustSaturation)
+
+	themesProcessed, err := AdjustPalette(inputFile, outputFile, adjustLightness, adjustSaturation, adjustKeepBW, paletteThemeFilter)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, themesProcessed, "theme(s)", outputFile)
+
+	return nil
+}
+
+func runColorFromImage(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	imageFile, inputFile, outputFile := args[0], args[1], args[2]
+
+	if imageColors < 1 || imageColors > len(accentSlots) {
+		cmd.PrintErrf("Error: invalid --colors %d. Must be between 1 and %d\n", imageColors, len(accentSlots))
+		return errSilent
+	}
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	palette, err := LoadImagePalette(imageFile, imageColors)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	return applyAccentPalette(cmd, palette, inputFile, outputFile)
+}
+
+func runColorApplyPalette(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	paletteFile, inputFile, outputFile := args[0], args[1], args[2]
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	palette, err := LoadPaletteFile(paletteFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	return applyAccentPalette(cmd, palette, inputFile, outputFile)
+}
+
+// applyAccentPalette writes a palette into the accents of the --theme themes and
+// reports the result, for from-image and apply-palette
+func applyAccentPalette(cmd *cobra.Command, palette []string, inputFile, outputFile string) error {
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	changes := accentPaletteChanges(palette)
+	mappingStrs := make([]string, 0, len(changes))
+	for _, slot := range accentSlots[:len(changes)] {
+		mappingStrs = append(mappingStrs, fmt.Sprintf("%s=%s", slot, changes[slot]))
+	}
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{Mappings: mappingStrs, Themes: paletteThemeFilter})
+
+	themesProcessed, err := MapAccentsToPalette(inputFile, outputFile, palette, paletteThemeFilter)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, themesProcessed, "theme(s)", outputFile)
+
+	return nil
+}
+
+// runRewrite runs a content-preserving cleanup over the parts in scope
+func runRewrite(cmd *cobra.Command, inputFile, outputFile, scope string, rewrite func([]byte) []byte) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	// Validate scope
+	if err := validateScope(scope); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	WarnOutputExtension(cmd, inputFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{Scope: scope})
+
+	filesChanged, err := rewriteParts(inputFile, outputFile, Scope(scope), rewrite)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, filesChanged, "files", outputFile)
+
+	return nil
+}
+
+// checkRoleAssignmentFlags rejects swap flags that don't apply to role assignments:
+// slide filters, since the theme changes for every slide, and the mapping checks,
+// which role assignments don't go through
+func checkRoleAssignmentFlags(cmd *cobra.Command) error {
+	if slideFilter != "" || len(sectionFilter) > 0 {
+		cmd.PrintErrln("Error: --slides and --section cannot be used with role assignments, which change the theme")
+		return errSilent
+	}
+	if strictMapping || len(protectColors) > 0 || allowedColorsFile != "" {
+		cmd.PrintErrln("Error: --strict, --protect, and --allowed-colors cannot be used with role assignments")
+		return errSilent
+	}
+	return nil
+}
+
+// runRoleAssignment handles "color swap" with a slot=source role assignment
+func runRoleAssignment(cmd *cobra.Command, assignmentStr, inputFile, outputFile string) error {
+	assignments, err := ParseRoleAssignment(assignmentStr)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	var assignmentStrs []string
+	for slot, source := range assignments {
+		assignmentStrs = append(assignmentStrs, fmt.Sprintf("%s=%s", slot, source))
+	}
+	sort.Strings(assignmentStrs)
+
+	selectedThemes, err := resolveThemeFilter(cmd, inputFile, themeFilter, themeByName)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	themesProcessed, err := AssignThemeRoles(inputFile, outputFile, assignments, selectedThemes)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	if summaryOnly {
+		cmd.Printf("✓ %d theme(s) processed in %s → %s\n", themesProcessed, time.Since(start).Round(time.Millisecond), outputFile)
+		return nil
+	}
+	PrintProcessingHeader(cmd, inputFile, ProcessingConfig{
+		Mappings: assignmentStrs,
+		Themes:   selectedThemes,
+	})
+	PrintSuccess(cmd, themesProcessed, "theme(s)", outputFile)
+
+	return nil
+}
+
+// resolveThemeFilter returns the --theme filter extended with the themes of inputFile
+// that --theme-by-name selects, printing any error itself
+func resolveThemeFilter(cmd *cobra.Command, inputFile string, byFile, byName []string) ([]string, error) {
+	if len(byName) == 0 {
+		return byFile, nil
+	}
+
+	named, err := ResolveThemeNames(inputFile, byName)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return nil, errSilent
+	}
+	if verbose {
+		cmd.PrintErrf("Note: --theme-by-name selects %s\n", strings.Join(named, ", "))
+	}
+	return themeNames(append(append([]string{}, byFile...), named...)), nil
+}
+
+// printSlideList prints every slide's number and title for --list-slides, marking
+// the slides --slides selects (read as visible positions with --visible-index)
+func printSlideList(cmd *cobra.Command, inputFile string) error {
+	targets, err := ParseSlideRange(slideFilter)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+	targeted := make(map[int]bool, len(targets))
+	for _, slideNum := range targets {
+		targeted[slideNum] = true
+	}
+
+	// Section slides are targeted by slide number, even with --visible-index
+	sectionTargeted := make(map[int]bool)
+	if len(sectionFilter) > 0 {
+		err := withExtractedPPTX(inputFile, func(tempDir string) error {
+			sectionSlides, err := ResolveSections(tempDir, sectionFilter)
+			for _, slideNum := range sectionSlides {
+				sectionTargeted[slideNum] = true
+			}
+			return err
+		})
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return exitWith(exitCodeFor(err))
+		}
+	}
+
+	summaries, err := ListSlides(inputFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+	warnSlideOrder(cmd, inputFile)
+
+	cmd.Printf("Slides in %s:\n", inputFile)
+	visibleNum := 0
+	section := ""
+	for _, summary := range summaries {
+		position := summary.Slide
+		if visibleIndex {
+			position = 0
+			if !summary.Hidden {
+				visibleNum++
+				position = visibleNum
+			}
+		}
+
+		if summary.Section != section {
+			section = summary.Section
+			if section != "" {
+				cmd.Printf("  [%s]\n", section)
+			}
+		}
+
+		marker := "  "
+		if targeted[position] || sectionTargeted[summary.Slide] {
+			marker = "* "
+		}
+		title := summary.Title
+		if title == "" {
+			title = "(no text)"
+		} else if runes := []rune(title); len(runes) > 60 {
+			title = string(runes[:57]) + "..."
+		}
+		hidden := ""
+		if summary.Hidden {
+			hidden = " [hidden]"
+		}
+		cmd.Printf("%s%3d: %s%s\n", marker, summary.Slide, title, hidden)
+	}
+
+	if len(targets) > 0 || len(sectionFilter) > 0 {
+		cmd.Println("\n* targeted by --slides or --section")
+	}
+	cmd.PrintErrln("Nothing written; run again without --list-slides to swap.")
+	return nil
+}
+
+// warnSlideOrder prints a warning when the slides of a PPTX file are numbered by
+// file name (see checkSlideOrder). Files that can't be read are left to the command.
+func warnSlideOrder(cmd *cobra.Command, inputFile string) {
+	if byFileName, err := checkSlideOrder(inputFile); err == nil && byFileName {
+		cmd.PrintErrln("Warning: presentation relationships are missing or unreadable; slides are numbered by file name, which may not match PowerPoint's order")
+	}
+}
+
+// printNotices prints parser notices to stderr when --verbose is set
+func printNotices(cmd *cobra.Command, notices []string) {
+	if !verbose {
+		return
+	}
+	for _, notice := range notices {
+		cmd.PrintErrln("Note:", notice)
+	}
+}
+
+// mapValues returns the values of a map of mappings, in no particular order
+func mapValues(mappings map[string]map[string]string) []map[string]string {
+	values := make([]map[string]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		values = append(values, mapping)
+	}
+	return values
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSwapSummaryOnly(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 4})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs([]string{"color", "swap", "accent1:FF0000", inputPath, outputPath, "--summary-only"})
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		summaryOnly = false
+	})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v (stderr: %s)", err, stderr.String())
+	}
+
+	want := regexp.MustCompile(`^✓ [1-9]\d* files processed, [1-9]\d* color reference\(s\) replaced in \S+ → ` + regexp.QuoteMeta(outputPath) + "\n$")
+	if !want.MatchString(stdout.String()) {
+		t.Errorf("stdout = %q, want a single summary line", stdout.String())
+	}
+}
+
+func TestSwapValidateTargets(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         1,
+		ColorsPerSlide: 2,
+		Parts:          map[string]string{"ppt/theme/theme1.xml": minimalThemeXML()},
+	})
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		validateTargets = false
+		strictMapping = false
+	})
+
+	const warning = "accent1:accent6: accent6 is not defined in theme1"
+
+	// Advisory: the swap still runs
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	var stderr bytes.Buffer
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs([]string{"color", "swap", "accent1:accent6,accent2:accent4", inputPath, outputPath, "--validate-targets"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v (stderr: %s)", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: "+warning) {
+		t.Errorf("stderr = %q, want a warning about accent6", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "accent4") {
+		t.Errorf("stderr = %q, want no warning about the defined accent4", stderr.String())
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Errorf("output not written: %v", err)
+	}
+
+	// --strict refuses before writing anything
+	outputPath = filepath.Join(t.TempDir(), "output.pptx")
+	stderr.Reset()
+	rootCmd.SetArgs([]string{"color", "swap", "accent1:accent6", inputPath, outputPath, "--validate-targets", "--strict"})
+	err := rootCmd.Execute()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitIssues {
+		t.Fatalf("Execute() error = %v, want exit status %d", err, ExitIssues)
+	}
+	if !strings.Contains(stderr.String(), "Error: "+warning) {
+		t.Errorf("stderr = %q, want an error about accent6", stderr.String())
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("output written despite --strict (stat error: %v)", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// ThemeDuplicates groups theme parts that duplicate each other
+type ThemeDuplicates struct {
+	IdenticalThemes       [][]string `json:"identicalThemes"`       // Byte-identical theme parts
+	IdenticalColorSchemes [][]string `json:"identicalColorSchemes"` // Themes whose 12 scheme colors match
+}
+
+// FindDuplicateThemes reports theme parts that are byte-identical and themes
+// that share the same color scheme. Each group lists theme file names in order;
+// only groups with more than one member are returned.
+func FindDuplicateThemes(pptxPath string) (*ThemeDuplicates, error) {
+	themes, err := ReadThemes(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash whole theme parts
+	zipReader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX file: %w", err)
+	}
+	defer zipReader.Close()
+
+	byHash := make(map[[sha256.Size]byte][]string)
+	themeDir := rootPath(zipDocumentRoot(zipReader.File), "theme")
+	for _, file := range zipReader.File {
+		if path.Dir(file.Name) != themeDir || path.Ext(file.Name) != ".xml" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var sum [sha256.Size]byte
+		copy(sum[:], hash.Sum(nil))
+		byHash[sum] = append(byHash[sum], filepath.Base(file.Name))
+	}
+
+	// Group color schemes by their colors
+	byColors := make(map[ColorScheme][]string)
+	for _, theme := range themes {
+		byColors[theme.Colors] = append(byColors[theme.Colors], theme.FileName)
+	}
+
+	duplicates := &ThemeDuplicates{}
+	for _, group := range byHash {
+		if len(group) > 1 {
+			sortNatural(group)
+			duplicates.IdenticalThemes = append(duplicates.IdenticalThemes, group)
+		}
+	}
+	for _, group := range byColors {
+		if len(group) > 1 {
+			sortNatural(group)
+			duplicates.IdenticalColorSchemes = append(duplicates.IdenticalColorSchemes, group)
+		}
+	}
+
+	// Order groups by their first member for stable output
+	sortGroups := func(groups [][]string) {
+		sort.Slice(groups, func(i, j int) bool { return naturalLess(groups[i][0], groups[j][0]) })
+	}
+	sortGroups(duplicates.IdenticalThemes)
+	sortGroups(duplicates.IdenticalColorSchemes)
+
+	return duplicates, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateThemes(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	duplicates, err := FindDuplicateThemes(testPPTX)
+	if err != nil {
+		t.Fatalf("FindDuplicateThemes() error = %v", err)
+	}
+
+	// The notes and handout master themes are byte-identical copies
+	wantThemes := [][]string{{"theme4.xml", "theme5.xml"}}
+	if !reflect.DeepEqual(duplicates.IdenticalThemes, wantThemes) {
+		t.Errorf("IdenticalThemes = %v, want %v", duplicates.IdenticalThemes, wantThemes)
+	}
+
+	// ... and share the default Office palette with theme1
+	wantSchemes := [][]string{{"theme1.xml", "theme4.xml", "theme5.xml"}}
+	if !reflect.DeepEqual(duplicates.IdenticalColorSchemes, wantSchemes) {
+		t.Errorf("IdenticalColorSchemes = %v, want %v", duplicates.IdenticalColorSchemes, wantSchemes)
+	}
+}
+
+func TestFindDuplicateThemes_None(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1})
+
+	duplicates, err := FindDuplicateThemes(inputPath)
+	if err != nil {
+		t.Fatalf("FindDuplicateThemes() error = %v", err)
+	}
+	if len(duplicates.IdenticalThemes) != 0 || len(duplicates.IdenticalColorSchemes) != 0 {
+		t.Errorf("expected no duplicates, got %+v", duplicates)
+	}
+}
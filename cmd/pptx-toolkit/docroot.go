@@ -0,0 +1,116 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultPresentationPart is where PowerPoint puts the main presentation part, used
+// when the package does not say otherwise
+const defaultPresentationPart = "ppt/presentation.xml"
+
+// officeDocumentRelPattern matches the package relationship to the main document
+var officeDocumentRelPattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?Relationship\b[^>]*\bType="[^"]*/officeDocument"[^>]*>`)
+
+// presentationOverridePattern matches the content type override of the main
+// presentation part (presentation, slide show, template, and macro-enabled variants)
+var presentationOverridePattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?Override\b[^>]*\bContentType="application/vnd\.(?:openxmlformats-officedocument|ms-powerpoint)\.[\w.\-]*\.main(?:\.macroEnabled)?\+xml"[^>]*>`)
+
+// partNamePattern matches the PartName attribute of a content type override
+var partNamePattern = regexp.MustCompile(`\bPartName="([^"]*)"`)
+
+// parsePresentationPart returns the archive path of the main presentation part from
+// the package relationships (_rels/.rels) and, failing that, from the content types
+// ([Content_Types].xml). Either may be nil. Reports false if neither names it.
+func parsePresentationPart(packageRels, contentTypes []byte) (string, bool) {
+	if rel := officeDocumentRelPattern.Find(packageRels); rel != nil {
+		if match := relTargetPattern.FindSubmatch(rel); match != nil && len(match[2]) > 0 {
+			// Package relationships are relative to the package root
+			return path.Clean(strings.TrimPrefix(string(match[2]), "/")), true
+		}
+	}
+	if override := presentationOverridePattern.Find(contentTypes); override != nil {
+		if match := partNamePattern.FindSubmatch(override); match != nil && len(match[1]) > 0 {
+			return path.Clean(strings.TrimPrefix(string(match[1]), "/")), true
+		}
+	}
+	return "", false
+}
+
+// presentationPart returns the archive path of the main presentation part of an
+// extracted package (e.g., "ppt/presentation.xml")
+func presentationPart(tempDir string) string {
+	packageRels, _ := os.ReadFile(filepath.Join(tempDir, "_rels", ".rels"))
+	contentTypes, _ := os.ReadFile(filepath.Join(tempDir, "[Content_Types].xml"))
+	if part, ok := parsePresentationPart(packageRels, contentTypes); ok {
+		return part
+	}
+	return defaultPresentationPart
+}
+
+// documentRoot returns the archive directory holding the presentation part of an
+// extracted package, and by convention its slides, masters, and themes: "ppt" for
+// PowerPoint's own files, "" for packages with the presentation at the top level
+func documentRoot(tempDir string) string {
+	return partDir(presentationPart(tempDir))
+}
+
+// zipDocumentRoot is documentRoot for a package still in its archive
+func zipDocumentRoot(files []*zip.File) string {
+	return partDir(zipPresentationPart(files))
+}
+
+// zipPresentationPart is presentationPart for a package still in its archive
+func zipPresentationPart(files []*zip.File) string {
+	read := func(name string) []byte {
+		for _, file := range files {
+			if file.Name == name {
+				rc, err := file.Open()
+				if err != nil {
+					return nil
+				}
+				defer rc.Close()
+				content, _ := io.ReadAll(rc)
+				return content
+			}
+		}
+		return nil
+	}
+
+	part, ok := parsePresentationPart(read("_rels/.rels"), read("[Content_Types].xml"))
+	if !ok {
+		part = defaultPresentationPart
+	}
+	return part
+}
+
+// partDir returns the archive directory of a part, "" for the top level
+func partDir(part string) string {
+	dir := path.Dir(part)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// rootPath joins archive path elements under the document root (e.g., "ppt", "theme"
+// → "ppt/theme"); an empty root is ignored
+func rootPath(root string, elem ...string) string {
+	return path.Join(append([]string{root}, elem...)...)
+}
+
+// rootDir is rootPath for a directory prefix, with a trailing slash (e.g., "ppt/slides/")
+func rootDir(root string, elem ...string) string {
+	return rootPath(root, elem...) + "/"
+}
+
+// docPath returns the file system path of a part in an extracted package, given its
+// path under the document root (e.g., "theme", "theme1.xml")
+func docPath(tempDir string, elem ...string) string {
+	return filepath.Join(tempDir, filepath.FromSlash(rootPath(documentRoot(tempDir), elem...)))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePresentationPart(t *testing.T) {
+	tests := []struct {
+		name         string
+		packageRels  string
+		contentTypes string
+		want         string
+		wantOK       bool
+	}{
+		{
+			name:        "PowerPoint layout",
+			packageRels: syntheticRelsXML([3]string{"rId1", "officeDocument", "ppt/presentation.xml"}),
+			want:        "ppt/presentation.xml",
+			wantOK:      true,
+		},
+		{
+			name:        "top-level presentation with absolute target",
+			packageRels: syntheticRelsXML([3]string{"rId1", "extended-properties", "docProps/app.xml"}, [3]string{"rId2", "officeDocument", "/presentation.xml"}),
+			want:        "presentation.xml",
+			wantOK:      true,
+		},
+		{
+			name:         "content types when relationships are missing",
+			contentTypes: `<Types><Override PartName="/deck/main.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/></Types>`,
+			want:         "deck/main.xml",
+			wantOK:       true,
+		},
+		{
+			name:         "macro-enabled content type",
+			contentTypes: `<Types><Override PartName="/deck/presentation.xml" ContentType="application/vnd.ms-powerpoint.presentation.macroEnabled.main+xml"/></Types>`,
+			want:         "deck/presentation.xml",
+			wantOK:       true,
+		},
+		{
+			name:         "nothing names it",
+			packageRels:  syntheticRelsXML([3]string{"rId1", "extended-properties", "docProps/app.xml"}),
+			contentTypes: `<Types><Default Extension="xml" ContentType="application/xml"/></Types>`,
+			wantOK:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePresentationPart([]byte(tt.packageRels), []byte(tt.contentTypes))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parsePresentationPart() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// relocatedDeck returns the overrides that move a synthetic deck's "ppt/" parts under
+// root instead ("" for the top level), updating the package relationships and
+// content types to match
+func relocatedDeck(deck syntheticDeck, root string) syntheticDeck {
+	overrides := make(map[string]string)
+	for name, content := range syntheticParts(deck) {
+		if rest, ok := strings.CutPrefix(name, "ppt/"); ok {
+			overrides[name] = ""
+			overrides[rootPath(root, rest)] = content
+		}
+	}
+	overrides["_rels/.rels"] = syntheticRelsXML([3]string{"rId1", "officeDocument", rootPath(root, "presentation.xml")})
+	overrides["[Content_Types].xml"] = strings.Replace(syntheticParts(deck)["[Content_Types].xml"],
+		`PartName="/ppt/presentation.xml"`, `PartName="/`+rootPath(root, "presentation.xml")+`"`, 1)
+	deck.Parts = overrides
+	return deck
+}
+
+func TestProcessPPTX_DocumentRoot(t *testing.T) {
+	for _, root := range []string{"", "deck"} {
+		t.Run("root "+root, func(t *testing.T) {
+			inputPath := writeSyntheticPPTX(t, relocatedDeck(syntheticDeck{Slides: 2, ColorsPerSlide: 4}, root))
+			slidePart := rootPath(root, "slides", "slide2.xml")
+
+			themes, err := ReadThemes(inputPath)
+			if err != nil || len(themes) != 1 {
+				t.Fatalf("ReadThemes() = %d themes, %v; want 1", len(themes), err)
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			filesProcessed, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "accent6"},
+				[]string{"theme1"}, "content", []int{2}, Options{})
+			if err != nil {
+				t.Fatalf("ProcessPPTX() error = %v", err)
+			}
+			if filesProcessed != 1 {
+				t.Errorf("filesProcessed = %d, want 1", filesProcessed)
+			}
+			if got := strings.Count(string(readZipEntry(t, outputPath, slidePart)), `val="accent6"`); got != 1 {
+				t.Errorf("%s has %d accent6 references, want 1", slidePart, got)
+			}
+
+			// Theme definitions are found under the same root
+			if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "112233"},
+				nil, "all", nil, Options{IncludeTheme: true}); err != nil {
+				t.Fatalf("ProcessPPTX() with IncludeTheme error = %v", err)
+			}
+			themeAfter, err := ReadThemes(outputPath)
+			if err != nil || len(themeAfter) != 1 || themeAfter[0].Colors.Accent1 != "112233" {
+				t.Errorf("theme after IncludeTheme = %+v, %v; want accent1 112233", themeAfter, err)
+			}
+		})
+	}
+}
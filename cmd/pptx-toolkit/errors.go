@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidColor reports a mapping color that is neither a scheme color nor a
+// 6-digit hex value
+type ErrInvalidColor struct {
+	Color string // The color as given
+	Side  string // "source" or "target"
+}
+
+func (e *ErrInvalidColor) Error() string {
+	return fmt.Sprintf("invalid %s color: '%s'. Must be a valid scheme color (%s) or 6-digit hex color (e.g., AABBCC)",
+		e.Side, e.Color, getValidColorsString())
+}
+
+// ErrConflictingMapping reports a source color mapped to two different targets
+type ErrConflictingMapping struct {
+	Source string // The source color
+	A      string // The first target given
+	B      string // The conflicting target
+}
+
+func (e *ErrConflictingMapping) Error() string {
+	return fmt.Sprintf("conflicting mappings for '%s':\n  - %s → %s\n  - %s → %s",
+		e.Source, e.Source, e.A, e.Source, e.B)
+}
+
+// ErrIdentityMapping reports a mapping whose target is its source, rejected by --strict
+type ErrIdentityMapping struct {
+	Source string // The mapping's source
+	Target string // The mapping's target, the same color
+}
+
+func (e *ErrIdentityMapping) Error() string {
+	return fmt.Sprintf("mapping '%s:%s' maps a color to itself (did you forget to change the target?)", e.Source, e.Target)
+}
+
+// ErrThemeNotFound reports theme filter entries that match no theme in use
+type ErrThemeNotFound struct {
+	Names     []string // The requested themes that were not found, as given
+	Available []string // The themes available, without extension, sorted
+}
+
+func (e *ErrThemeNotFound) Error() string {
+	return fmt.Sprintf("theme(s) not found: %s\nAvailable themes: %s",
+		strings.Join(e.Names, ", "), strings.Join(e.Available, ", "))
+}
+
+// ErrThemeNameNotFound reports --theme-by-name entries that match no theme's name
+type ErrThemeNameNotFound struct {
+	Names     []string // The requested names that matched nothing, as given
+	Available []string // The themes available, as "name (file)", sorted by file
+}
+
+func (e *ErrThemeNameNotFound) Error() string {
+	return fmt.Sprintf("no theme named: %s\nAvailable themes:\n  %s",
+		strings.Join(e.Names, ", "), strings.Join(e.Available, "\n  "))
+}
+
+// ErrSectionNotFound reports section names that match no section of the presentation
+type ErrSectionNotFound struct {
+	Names     []string // The requested sections that were not found, as given
+	Available []string // The presentation's sections, in order; empty if it has none
+}
+
+func (e *ErrSectionNotFound) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("section(s) not found: %s\nThe presentation has no sections", strings.Join(e.Names, ", "))
+	}
+	return fmt.Sprintf("section(s) not found: %s\nAvailable sections: %s",
+		strings.Join(e.Names, ", "), strings.Join(e.Available, ", "))
+}
+
+// ErrNoSlidesMatched reports a slide filter and a theme filter with no slide in common
+type ErrNoSlidesMatched struct {
+	Slides []int    // The slide filter
+	Themes []string // The theme filter
+}
+
+func (e *ErrNoSlidesMatched) Error() string {
+	return fmt.Sprintf("no slides match both the slide and theme filters (slides: %s; themes: %s)",
+		formatSlides(e.Slides), strings.Join(e.Themes, ", "))
+}
+
+// ErrProtectedColor reports a mapping whose source or target is a protected color
+type ErrProtectedColor struct {
+	Color  string // The protected color, as given to --protect
+	Source string // The offending mapping's source
+	Target string // The offending mapping's target
+}
+
+func (e *ErrProtectedColor) Error() string {
+	return fmt.Sprintf("mapping '%s:%s' touches protected color '%s'", e.Source, e.Target, e.Color)
+}
+
+// ErrInvalidScope reports a scope that is not one of ValidScopes
+type ErrInvalidScope struct {
+	Scope string   // The invalid scope token
+	Valid []string // The valid scopes, sorted
+}
+
+func (e *ErrInvalidScope) Error() string {
+	return fmt.Sprintf("invalid scope '%s'. Valid values: %s", e.Scope, strings.Join(e.Valid, ", "))
+}
+
+// ErrInvalidTempDir reports a temp directory that does not exist, is not a
+// directory, or cannot be written to
+type ErrInvalidTempDir struct {
+	Dir string // The directory as given
+	Err error  // Why it cannot be used
+}
+
+func (e *ErrInvalidTempDir) Error() string {
+	return fmt.Sprintf("cannot use temp directory '%s': %v", e.Dir, e.Err)
+}
+
+func (e *ErrInvalidTempDir) Unwrap() error {
+	return e.Err
+}
+
+// ErrNotPPTX reports an input file that is not a ZIP package, so not a PowerPoint file
+type ErrNotPPTX struct {
+	Path string // The input as given
+	Err  error  // Why it could not be opened
+}
+
+func (e *ErrNotPPTX) Error() string {
+	return fmt.Sprintf("'%s' is not a PowerPoint file: %v", e.Path, e.Err)
+}
+
+func (e *ErrNotPPTX) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	masterToTheme := map[string]string{"slideMaster1.xml": "theme1.xml", "slideMaster2.xml": "theme2.xml"}
+
+	t.Run("invalid source color", func(t *testing.T) {
+		_, err := ParseColorMapping("accent9:accent1")
+		var target *ErrInvalidColor
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *ErrInvalidColor, got %T: %v", err, err)
+		}
+		if target.Color != "accent9" || target.Side != "source" {
+			t.Errorf("got %+v", target)
+		}
+	})
+
+	t.Run("invalid target color", func(t *testing.T) {
+		_, err := ParseColorMapping("accent1:GGGGGG")
+		var target *ErrInvalidColor
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *ErrInvalidColor, got %T: %v", err, err)
+		}
+		if target.Color != "GGGGGG" || target.Side != "target" {
+			t.Errorf("got %+v", target)
+		}
+	})
+
+	t.Run("conflicting mapping", func(t *testing.T) {
+		_, err := ParseColorMapping("accent1:accent3,accent1:accent2")
+		var target *ErrConflictingMapping
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *ErrConflictingMapping, got %T: %v", err, err)
+		}
+		want := ErrConflictingMapping{Source: "accent1", A: "accent3", B: "accent2"}
+		if *target != want {
+			t.Errorf("got %+v, want %+v", *target, want)
+		}
+	})
+
+	t.Run("wrapped by theme mapping", func(t *testing.T) {
+		_, _, err := ParseThemeMapping("theme1=accent1:nothex")
+		var target *ErrInvalidColor
+		if !errors.As(err, &target) {
+			t.Fatalf("expected wrapped *ErrInvalidColor, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("theme not found", func(t *testing.T) {
+		err := validateThemeFilter([]string{"theme1", "theme7"}, masterToTheme)
+		var target *ErrThemeNotFound
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *ErrThemeNotFound, got %T: %v", err, err)
+		}
+		if !reflect.DeepEqual(target.Names, []string{"theme7"}) ||
+			!reflect.DeepEqual(target.Available, []string{"theme1", "theme2"}) {
+			t.Errorf("got %+v", target)
+		}
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		err := validateScope("content,slides")
+		var target *ErrInvalidScope
+		if !errors.As(err, &target) {
+			t.Fatalf("expected *ErrInvalidScope, got %T: %v", err, err)
+		}
+		if target.Scope != "slides" || len(target.Valid) != len(ValidScopes) {
+			t.Errorf("got %+v", target)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Exit codes. Scripts can rely on these; don't renumber them.
+const (
+	ExitOK         = 0   // Success
+	ExitUsage      = 1   // Invalid arguments or flags, or any failure without a code of its own
+	ExitAborted    = 2   // An existing output was not overwritten (declined, or --no-prompt)
+	ExitNoOp       = 3   // Nothing was replaced and --fail-on-no-op was given
+	ExitNotPPTX    = 4   // An input is not a PowerPoint package
+	ExitIssues     = 5   // A check (e.g., color validate) found problems
+	ExitInterrupt  = 130 // Interrupted (Ctrl-C), 128+SIGINT
+	ExitTerminated = 143 // Terminated, 128+SIGTERM
+)
+
+// ExitError ends a command with an exit code. The command has already reported
+// what went wrong, so it carries no message of its own.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// errSilent ends a command that has already printed its error with ExitUsage. Unlike
+// an empty error, main never prints it, so no blank line is left on stderr.
+var errSilent error = &ExitError{Code: ExitUsage}
+
+// exitWith returns an *ExitError for a command that has printed its own error
+func exitWith(code int) error {
+	return &ExitError{Code: code}
+}
+
+// exitCodeFor returns the exit code for an error a command failed with: ExitNotPPTX
+// if an input is not a PowerPoint package, ExitUsage otherwise
+func exitCodeFor(err error) int {
+	var notPPTX *ErrNotPPTX
+	if errors.As(err, &notPPTX) || errors.Is(err, zip.ErrFormat) {
+		return ExitNotPPTX
+	}
+	return ExitUsage
+}
+
+// reportError prints the error a command returned to stderr, unless the command
+// already reported it (an *ExitError), and returns the exit code to end with
+func reportError(stderr io.Writer, err error) int {
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	fmt.Fprintln(stderr, err)
+	return exitCodeFor(err)
+}
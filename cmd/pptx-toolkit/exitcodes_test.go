@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportError(t *testing.T) {
+	notPPTX := filepath.Join(t.TempDir(), "notes.pptx")
+	if err := os.WriteFile(notPPTX, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   int
+		wantOutput bool
+	}{
+		{name: "already reported", err: exitWith(ExitNoOp), wantCode: ExitNoOp},
+		{name: "already reported, wrapped", err: fmt.Errorf("batch: %w", exitWith(ExitAborted)), wantCode: ExitAborted},
+		{name: "plain error", err: errors.New("accepts 3 arg(s), received 2"), wantCode: ExitUsage, wantOutput: true},
+		{name: "not a PowerPoint file", err: ValidateInputFile(notPPTX), wantCode: ExitNotPPTX, wantOutput: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if got := reportError(&stderr, tt.err); got != tt.wantCode {
+				t.Errorf("reportError() = %d, want %d", got, tt.wantCode)
+			}
+			if printed := stderr.Len() > 0; printed != tt.wantOutput {
+				t.Errorf("printed %q, want output = %v", stderr.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestValidateInputFile(t *testing.T) {
+	dir := t.TempDir()
+	notPPTX := filepath.Join(dir, "notes.pptx")
+	if err := os.WriteFile(notPPTX, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateInputFile(writeSyntheticPPTX(t, syntheticDeck{Slides: 1})); err != nil {
+		t.Errorf("ValidateInputFile() on a PPTX error = %v", err)
+	}
+
+	err := ValidateInputFile(notPPTX)
+	var target *ErrNotPPTX
+	if !errors.As(err, &target) || target.Path != notPPTX {
+		t.Errorf("ValidateInputFile() on a text file = %v, want *ErrNotPPTX", err)
+	}
+
+	err = ValidateInputFile(filepath.Join(dir, "missing.pptx"))
+	if err == nil || errors.As(err, &target) {
+		t.Errorf("ValidateInputFile() on a missing file = %v, want a not-found error", err)
+	}
+}
+
+func TestSilentErrorLeavesNoBlankLine(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs([]string{"info", "--format", "yaml", filepath.Join(t.TempDir(), "deck.pptx")})
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		infoFormat = "text"
+	})
+
+	err := rootCmd.Execute()
+	if !errors.Is(err, errSilent) {
+		t.Fatalf("Execute() error = %v, want errSilent", err)
+	}
+	if code := reportError(&stderr, err); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+
+	if !strings.HasPrefix(stderr.String(), "Error: ") || strings.Count(stderr.String(), "\n") != 1 {
+		t.Errorf("stderr = %q, want just the one error line", stderr.String())
+	}
+}
+
+func TestNotPPTXExitCode(t *testing.T) {
+	dir := t.TempDir()
+	notPPTX := filepath.Join(dir, "notes.pptx")
+	if err := os.WriteFile(notPPTX, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deck := writeSyntheticPPTX(t, syntheticDeck{Slides: 1})
+	palette := filepath.Join(dir, "palette.txt")
+	if err := os.WriteFile(palette, []byte("FF0000\n00FF00\n0000FF\n111111\n222222\n333333\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "output.pptx")
+
+	t.Cleanup(func() {
+		rootCmd.SetOut(os.Stdout)
+		rootCmd.SetErr(os.Stderr)
+		rootCmd.SetArgs(nil)
+		adjustLightness = 0
+	})
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"info", []string{"info", notPPTX}},
+		{"slide themes", []string{"slide", "themes", notPPTX}},
+		{"color list", []string{"color", "list", notPPTX}},
+		{"color swap", []string{"color", "swap", "accent1:FF0000", notPPTX, output}},
+		{"color rename", []string{"color", "rename", "Brand", notPPTX, output}},
+		{"color find", []string{"color", "find", "accent1", notPPTX}},
+		{"color validate", []string{"color", "validate", notPPTX}},
+		{"color normalize", []string{"color", "normalize", notPPTX, output}},
+		{"color clean", []string{"color", "clean", notPPTX, output}},
+		{"color themify", []string{"color", "themify", notPPTX, output}},
+		{"color grayscale", []string{"color", "grayscale", notPPTX, output}},
+		{"color hue-shift", []string{"color", "hue-shift", "30", notPPTX, output}},
+		{"color adjust", []string{"color", "adjust", notPPTX, output, "--lightness", "10"}},
+		{"color apply-palette", []string{"color", "apply-palette", palette, notPPTX, output}},
+		{"theme dedupe", []string{"theme", "dedupe", notPPTX}},
+		{"theme merge", []string{"theme", "merge", notPPTX, deck, output}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			rootCmd.SetOut(&bytes.Buffer{})
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(tt.args)
+			if code := reportError(&stderr, rootCmd.Execute()); code != ExitNotPPTX {
+				t.Errorf("exit status = %d, want %d (stderr: %s)", code, ExitNotPPTX, stderr.String())
+			}
+		})
+	}
+}
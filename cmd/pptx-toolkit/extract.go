@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+)
+
+// Color reference kinds reported by ExtractColors
+const (
+	ColorRefScheme = "schemeClr"
+	ColorRefSrgb   = "srgbClr"
+)
+
+// ColorRef is a single scheme or hex color reference found in a part
+type ColorRef struct {
+	Kind      string          `json:"kind"`                // ColorRefScheme or ColorRefSrgb
+	Value     string          `json:"value"`               // The val attribute as written (e.g., "accent1" or "aabbcc")
+	Modifiers []ColorModifier `json:"modifiers,omitempty"` // Child transforms in document order
+	Offset    int             `json:"offset"`              // Byte offset of the start tag
+}
+
+var (
+	// childTag matches any start, end, or self-closing tag, capturing the local name
+	childTag = regexp.MustCompile(`</?(?:[A-Za-z_][\w.\-]*:)?([A-Za-z_][\w.\-]*)(?:` + tagAttrPattern + `)*\s*/?>`)
+
+	// valAttr captures the val attribute of a tag
+	valAttr = regexp.MustCompile(`\sval\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// ExtractColors returns every schemeClr and srgbClr reference in XML content, in
+// document order, with any namespace prefix. Both the self-closing form and the
+// container form are reported; the direct children of a container are its
+// modifiers (e.g. lumMod, alpha). A container without a balancing end tag is
+// reported with no modifiers. References inside comments, CDATA, and processing
+// instructions are skipped.
+//
+// The content may be a whole part or a fragment; it is not required to be well-formed.
+func ExtractColors(xmlContent []byte) []ColorRef {
+	regions := findNonMarkup(xmlContent)
+
+	var refs []ColorRef
+	extract := func(kind string, startTag, tagPattern *regexp.Regexp) {
+		for _, match := range startTag.FindAllSubmatchIndex(xmlContent, -1) {
+			if inNonMarkup(match[0], regions) {
+				continue
+			}
+
+			ref := ColorRef{Kind: kind, Value: string(xmlContent[match[8]:match[9]]), Offset: match[0]}
+			if string(xmlContent[match[12]:match[13]]) == ">" {
+				if closeStart, _ := findClosingTag(xmlContent, match[1], tagPattern, regions); closeStart != -1 {
+					ref.Modifiers = colorModifiers(xmlContent, match[1], closeStart, regions)
+				}
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	extract(ColorRefScheme, schemeClrStartTag, schemeClrTag)
+	extract(ColorRefSrgb, srgbClrStartTag, srgbClrTag)
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Offset < refs[j].Offset })
+	return refs
+}
+
+// colorModifiers returns the direct children of a color element whose content
+// spans xmlContent[start:end], skipping anything nested deeper
+func colorModifiers(xmlContent []byte, start, end int, regions [][]int) []ColorModifier {
+	var modifiers []ColorModifier
+	depth := 0
+	for _, loc := range childTag.FindAllSubmatchIndex(xmlContent[start:end], -1) {
+		if inNonMarkup(start+loc[0], regions) {
+			continue
+		}
+
+		tag := xmlContent[start+loc[0] : start+loc[1]]
+		if bytes.HasPrefix(tag, []byte("</")) {
+			depth--
+			continue
+		}
+		if depth == 0 {
+			modifier := ColorModifier{Name: string(xmlContent[start+loc[2] : start+loc[3]])}
+			if val := valAttr.FindSubmatch(tag); val != nil {
+				modifier.Val = string(val[1]) + string(val[2])
+			}
+			modifiers = append(modifiers, modifier)
+		}
+		if !bytes.HasSuffix(tag, []byte("/>")) {
+			depth++
+		}
+	}
+	return modifiers
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractColors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []ColorRef
+	}{
+		{
+			name:  "self-closing scheme and hex",
+			input: `<a:solidFill><a:schemeClr val="accent1"/></a:solidFill><a:srgbClr val="aabbcc"/>`,
+			want: []ColorRef{
+				{Kind: ColorRefScheme, Value: "accent1", Offset: 13},
+				{Kind: ColorRefSrgb, Value: "aabbcc", Offset: 55},
+			},
+		},
+		{
+			name:  "container with modifiers",
+			input: `<a:schemeClr val="accent2"><a:lumMod val="75000"/><a:lumOff val="25%"/></a:schemeClr>`,
+			want: []ColorRef{{Kind: ColorRefScheme, Value: "accent2", Modifiers: []ColorModifier{
+				{Name: "lumMod", Val: "75000"},
+				{Name: "lumOff", Val: "25%"},
+			}}},
+		},
+		{
+			name:  "empty container",
+			input: `<a:srgbClr val="FF0000"></a:srgbClr>`,
+			want:  []ColorRef{{Kind: ColorRefSrgb, Value: "FF0000"}},
+		},
+		{
+			name: "gradient stops in document order",
+			input: `<a:gradFill><a:gsLst>` +
+				`<a:gs pos="0"><a:srgbClr val="112233"><a:alpha val="50000"/></a:srgbClr></a:gs>` +
+				`<a:gs pos="100000"><a:schemeClr val="bg1"><a:shade val="50000"/></a:schemeClr></a:gs>` +
+				`</a:gsLst></a:gradFill>`,
+			want: []ColorRef{
+				{Kind: ColorRefSrgb, Value: "112233", Modifiers: []ColorModifier{{Name: "alpha", Val: "50000"}}, Offset: 35},
+				{Kind: ColorRefScheme, Value: "bg1", Modifiers: []ColorModifier{{Name: "shade", Val: "50000"}}, Offset: 119},
+			},
+		},
+		{
+			name:  "only direct children are modifiers",
+			input: `<a:schemeClr val="tx1"><a:ext uri="x"><a:tint val="1"/></a:ext><a:tint val="40000"/></a:schemeClr>`,
+			want: []ColorRef{{Kind: ColorRefScheme, Value: "tx1", Modifiers: []ColorModifier{
+				{Name: "ext"},
+				{Name: "tint", Val: "40000"},
+			}}},
+		},
+		{
+			name:  "default namespace and other prefixes",
+			input: `<schemeClr val="accent3"/><c:srgbClr lang="x" val="0A0B0C"/>`,
+			want: []ColorRef{
+				{Kind: ColorRefScheme, Value: "accent3"},
+				{Kind: ColorRefSrgb, Value: "0A0B0C", Offset: 26},
+			},
+		},
+		{
+			name:  "unbalanced container has no modifiers",
+			input: `<a:schemeClr val="accent4"><a:lumMod val="50000"/>`,
+			want:  []ColorRef{{Kind: ColorRefScheme, Value: "accent4"}},
+		},
+		{
+			name:  "comments and CDATA skipped",
+			input: `<!--<a:schemeClr val="accent1"/>--><![CDATA[<a:srgbClr val="FFFFFF"/>]]><a:schemeClr val="accent5"><!--<a:lumMod val="1"/>--></a:schemeClr>`,
+			want:  []ColorRef{{Kind: ColorRefScheme, Value: "accent5", Offset: 72}},
+		},
+		{
+			name:  "invalid hex is not a reference",
+			input: `<a:srgbClr val="GGHHII"/>`,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractColors([]byte(tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractColors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
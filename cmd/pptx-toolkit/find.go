@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ColorUsage reports where a color is referenced in a presentation
+type ColorUsage struct {
+	Slides     []int    `json:"slides"`     // Visual slide numbers whose content references the color
+	OtherParts []string `json:"otherParts"` // Parts not tied to a slide (masters, layouts, etc.)
+}
+
+// containsColor reports whether XML content references a color.
+// Scheme colors match schemeClr val exactly; hex colors match srgbClr val case-insensitively.
+func containsColor(xmlContent []byte, color string) bool {
+	return countColor(xmlContent, color) > 0
+}
+
+// countColor returns how many times XML content references a color, matching as
+// containsColor does. References inside comments, CDATA, and processing instructions
+// are not counted.
+func countColor(xmlContent []byte, color string) int {
+	isHex := isValidHexColor(color)
+
+	count := 0
+	for _, ref := range ExtractColors(xmlContent) {
+		switch {
+		case isHex && ref.Kind == ColorRefSrgb && strings.EqualFold(ref.Value, color):
+			count++
+		case !isHex && ref.Kind == ColorRefScheme && ref.Value == color:
+			count++
+		}
+	}
+	return count
+}
+
+// buildPartToSlides maps each slide-owned part (the slide itself, its charts,
+// diagrams, and notes) to the visual slide numbers that use it
+func buildPartToSlides(tempDir string) (map[string][]int, error) {
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	partToSlides := make(map[string][]int)
+	for slideNum := range slideMapping {
+		parts, err := GetSlideContent(tempDir, []int{slideNum})
+		if err != nil {
+			return nil, err
+		}
+		for part := range parts {
+			partToSlides[part] = append(partToSlides[part], slideNum)
+		}
+	}
+
+	return partToSlides, nil
+}
+
+// FindColorUsage scans the parts in scope of an extracted presentation for a color
+// and translates matching part paths back to visual slide numbers
+func FindColorUsage(tempDir, color string, scope string) (*ColorUsage, error) {
+	if !isValidColor(color) {
+		return nil, fmt.Errorf("invalid color: '%s'. Must be a valid scheme color (%s) or 6-digit hex color (e.g., AABBCC)",
+			color, getValidColorsString())
+	}
+
+	if err := validateScope(scope); err != nil {
+		return nil, err
+	}
+
+	partToSlides, err := buildPartToSlides(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	xmlPatterns := getXMLPatternsUnder(documentRoot(tempDir), Scope(scope))
+	slides := make(map[int]bool)
+	usage := &ColorUsage{}
+
+	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(tempDir, path)
+		relPath = filepath.ToSlash(relPath)
+
+		inScope := false
+		for _, pattern := range xmlPatterns {
+			if strings.HasPrefix(relPath, pattern) {
+				inScope = true
+				break
+			}
+		}
+		if !inScope {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if !containsColor(content, color) {
+			return nil
+		}
+
+		if slideNums, ok := partToSlides[relPath]; ok {
+			for _, slideNum := range slideNums {
+				slides[slideNum] = true
+			}
+		} else {
+			usage.OtherParts = append(usage.OtherParts, relPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for slideNum := range slides {
+		usage.Slides = append(usage.Slides, slideNum)
+	}
+	sort.Ints(usage.Slides)
+	sortNatural(usage.OtherParts)
+
+	return usage, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContainsColor(t *testing.T) {
+	xml := createSampleXML([]string{"accent1", "dk2"})
+	rgbXML := createSampleXMLWithRgb([]string{"aabbcc"})
+
+	tests := []struct {
+		name    string
+		content []byte
+		color   string
+		want    bool
+	}{
+		{"scheme present", xml, "accent1", true},
+		{"scheme absent", xml, "accent2", false},
+		{"hex case-insensitive", rgbXML, "AABBCC", true},
+		{"hex absent", rgbXML, "112233", false},
+		{"scheme does not match hex element", rgbXML, "accent1", false},
+		{"comment ignored", []byte(`<sld><!--<a:schemeClr val="accent1"/>--></sld>`), "accent1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsColor(tt.content, tt.color); got != tt.want {
+				t.Errorf("containsColor(%s) = %v, want %v", tt.color, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindColorUsage(t *testing.T) {
+	// Slide 2 only uses dk2; the layout uses accent1
+	path := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         3,
+		ColorsPerSlide: 2,
+		Parts:          map[string]string{"ppt/slides/slide2.xml": string(createSampleXML([]string{"dk2"}))},
+	})
+
+	findIn := func(color, scope string) *ColorUsage {
+		t.Helper()
+		var usage *ColorUsage
+		err := withExtractedPPTX(path, func(tempDir string) error {
+			var err error
+			usage, err = FindColorUsage(tempDir, color, scope)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("FindColorUsage(%s, %s) error = %v", color, scope, err)
+		}
+		return usage
+	}
+
+	t.Run("scheme color in all scope", func(t *testing.T) {
+		usage := findIn("accent1", "all")
+		if !reflect.DeepEqual(usage.Slides, []int{1, 3}) {
+			t.Errorf("expected slides [1 3], got %v", usage.Slides)
+		}
+		if !reflect.DeepEqual(usage.OtherParts, []string{"ppt/slideLayouts/slideLayout1.xml"}) {
+			t.Errorf("expected layout in other parts, got %v", usage.OtherParts)
+		}
+	})
+
+	t.Run("content scope excludes layouts", func(t *testing.T) {
+		usage := findIn("accent1", "content")
+		if len(usage.OtherParts) != 0 {
+			t.Errorf("expected no other parts, got %v", usage.OtherParts)
+		}
+	})
+
+	t.Run("hex color", func(t *testing.T) {
+		usage := findIn("aabbcc", "content")
+		if !reflect.DeepEqual(usage.Slides, []int{1, 3}) {
+			t.Errorf("expected slides [1 3], got %v", usage.Slides)
+		}
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		err := withExtractedPPTX(path, func(tempDir string) error {
+			_, err := FindColorUsage(tempDir, "purple", "all")
+			return err
+		})
+		if err == nil {
+			t.Error("expected error for invalid color, got nil")
+		}
+	})
+}
+
+func TestFindColorUsage_EmbeddedContent(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	// Slide 3 holds a diagram and slide 4 a chart; both should be attributed to their slide
+	var usage *ColorUsage
+	err := withExtractedPPTX(testPPTX, func(tempDir string) error {
+		var err error
+		usage, err = FindColorUsage(tempDir, "accent1", "content")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FindColorUsage() error = %v", err)
+	}
+
+	for _, want := range []int{3, 4} {
+		found := false
+		for _, slide := range usage.Slides {
+			if slide == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected slide %d in %v", want, usage.Slides)
+		}
+	}
+}
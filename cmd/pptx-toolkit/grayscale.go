@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// grayHex returns the gray with the same luma as a hex color, using the ITU-R BT.601
+// weights (0.299 R + 0.587 G + 0.114 B). Grays map to themselves.
+func grayHex(hex string) string {
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return strings.ToUpper(hex)
+	}
+	r, g, b := float64(value>>16&0xFF), float64(value>>8&0xFF), float64(value&0xFF)
+	luma := uint8(0.299*r + 0.587*g + 0.114*b + 0.5)
+	return fmt.Sprintf("%02X%02X%02X", luma, luma, luma)
+}
+
+// grayColorScheme returns the slot → gray changes that turn a theme palette gray
+func grayColorScheme(colors ColorScheme) map[string]string {
+	changes := make(map[string]string, len(schemeColorNames))
+	for _, name := range schemeColorNames {
+		if hex := colors.Get(name); isValidHexColor(hex) {
+			changes[name] = grayHex(hex)
+		}
+	}
+	return changes
+}
+
+// grayscaleHexMapping returns the hex → gray mapping for every hex color value
+// (srgbClr val) in the XML parts of an extracted presentation
+func grayscaleHexMapping(tempDir string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	err := filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, ref := range ExtractColors(content) {
+			if ref.Kind == ColorRefSrgb {
+				hex := strings.ToUpper(ref.Value)
+				mapping[hex] = grayHex(hex)
+			}
+		}
+		return nil
+	})
+	return mapping, err
+}
+
+// grayscaleThemeMappings returns, for every theme used by a slide master, the mapping
+// that turns references to its scheme colors (and the bg1/tx1/bg2/tx2 aliases, as
+// resolved by the master's color map) into fixed grays, keyed by theme file name
+func grayscaleThemeMappings(tempDir string) (map[string]map[string]string, error) {
+	masterToTheme, err := buildThemeRelationships(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	masterMaps, err := readMasterColorMaps(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Masters sharing a theme use the first one's color map
+	masters := make([]string, 0, len(masterToTheme))
+	for master := range masterToTheme {
+		masters = append(masters, master)
+	}
+	sortNatural(masters)
+
+	themeMappings := make(map[string]map[string]string)
+	for _, master := range masters {
+		theme := masterToTheme[master]
+		if _, seen := themeMappings[theme]; seen {
+			continue
+		}
+		content, err := os.ReadFile(docPath(tempDir, "theme", theme))
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseThemeXML(content, theme)
+		if err != nil {
+			// Not a theme with a color scheme; nothing to resolve against
+			continue
+		}
+
+		mapping := grayColorScheme(parsed.Colors)
+		colorMap, ok := masterMaps[master]
+		if !ok {
+			colorMap = defaultColorMap
+		}
+		for alias := range colorMapAliases {
+			if gray, ok := mapping[colorMap.Resolve(alias)]; ok {
+				mapping[alias] = gray
+			}
+		}
+		themeMappings[theme] = mapping
+	}
+	return themeMappings, nil
+}
+
+// Grayscale converts the colors of a presentation to grays of the same luma, for
+// print proofing. Hex colors become grays directly. Scheme references are replaced
+// with the gray of the color they resolve to in their part's theme, as fixed hex
+// values; modifiers on them (lumMod, tint, ...) are dropped. Parts no single theme
+// governs keep their scheme references.
+//
+// With keepScheme, the theme palettes are turned gray instead, so scheme references
+// stay linked to the theme and keep their modifiers; hex colors are still converted.
+// themeFilter limits both the parts and the palettes converted.
+func Grayscale(inputPath, outputPath, scope string, keepScheme bool, themeFilter []string) error {
+	if err := validateScope(scope); err != nil {
+		return err
+	}
+
+	var hexMapping map[string]string
+	var themeMappings map[string]map[string]string
+	err := withExtractedPPTX(inputPath, func(tempDir string) error {
+		var err error
+		if hexMapping, err = grayscaleHexMapping(tempDir); err != nil {
+			return err
+		}
+		if !keepScheme {
+			themeMappings, err = grayscaleThemeMappings(tempDir)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !keepScheme {
+		_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, hexMapping, themeFilter, scope, nil, Options{ThemeMappings: themeMappings})
+		return err
+	}
+
+	// Gray palettes first, into an intermediate file; hex colors from there
+	intermediate, err := os.CreateTemp(defaultTempDir, "pptx-toolkit-*.pptx")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	intermediate.Close()
+	trackTemp(intermediate.Name())
+	defer removeTemp(intermediate.Name())
+
+	if _, err := rewriteThemeColors(inputPath, intermediate.Name(), themeFilter, grayColorScheme); err != nil {
+		return err
+	}
+	_, _, err = ProcessPPTXWithOptions(intermediate.Name(), outputPath, hexMapping, themeFilter, scope, nil, Options{})
+	return err
+}
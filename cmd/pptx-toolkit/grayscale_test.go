@@ -0,0 +1,88 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// srgbValuePattern captures the value of every srgbClr element
+var srgbValuePattern = regexp.MustCompile(`srgbClr val="([0-9A-Fa-f]{6})"`)
+
+func TestGrayHex(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{hex: "000000", want: "000000"},
+		{hex: "FFFFFF", want: "FFFFFF"},
+		{hex: "808080", want: "808080"},
+		{hex: "FF0000", want: "4C4C4C"},
+		{hex: "00ff00", want: "969696"},
+		{hex: "0000FF", want: "1D1D1D"},
+		{hex: "4F81BD", want: "797979"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			if got := grayHex(tt.hex); got != tt.want {
+				t.Errorf("grayHex(%q) = %q, want %q", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrayscale(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 8})
+
+	tests := []struct {
+		name       string
+		keepScheme bool
+	}{
+		{name: "fixed grays", keepScheme: false},
+		{name: "keep scheme", keepScheme: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if err := Grayscale(inputPath, outputPath, "all", tt.keepScheme, nil); err != nil {
+				t.Fatalf("Grayscale() error = %v", err)
+			}
+
+			parts := []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml", "ppt/slideLayouts/slideLayout1.xml"}
+			if tt.keepScheme {
+				parts = append(parts, "ppt/theme/theme1.xml")
+			}
+			for _, part := range parts {
+				content := string(readZipEntry(t, outputPath, part))
+				for _, match := range srgbValuePattern.FindAllStringSubmatch(content, -1) {
+					hex := strings.ToUpper(match[1])
+					if hex[0:2] != hex[2:4] || hex[2:4] != hex[4:6] {
+						t.Errorf("%s: srgbClr %s is not gray", part, hex)
+					}
+				}
+				hasScheme := strings.Contains(content, "<a:schemeClr ")
+				if part != "ppt/theme/theme1.xml" && hasScheme != tt.keepScheme {
+					t.Errorf("%s: scheme references present = %v, want %v", part, hasScheme, tt.keepScheme)
+				}
+			}
+
+			themes, err := ReadThemes(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantAccent1 := "4F81BD"
+			if tt.keepScheme {
+				wantAccent1 = "797979"
+			}
+			if themes[0].Colors.Accent1 != wantAccent1 {
+				t.Errorf("theme accent1 = %s, want %s", themes[0].Colors.Accent1, wantAccent1)
+			}
+			if err := VerifyOutput(inputPath, outputPath); err != nil {
+				t.Errorf("VerifyOutput() error = %v", err)
+			}
+		})
+	}
+}
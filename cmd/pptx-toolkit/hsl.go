@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// hslColor is a color in HSL space: H in degrees [0, 360), S and L in [0, 1]
+type hslColor struct {
+	H, S, L float64
+}
+
+// rgbColor is a color with R, G, and B in [0, 1]
+type rgbColor struct {
+	R, G, B float64
+}
+
+// hexToRGB converts a 6-digit hex color to RGB. Reports false for invalid input.
+func hexToRGB(hex string) (rgbColor, bool) {
+	if !isValidHexColor(hex) {
+		return rgbColor{}, false
+	}
+	value, _ := strconv.ParseUint(hex, 16, 32)
+	return rgbColor{
+		R: float64(value>>16&0xFF) / 255,
+		G: float64(value>>8&0xFF) / 255,
+		B: float64(value&0xFF) / 255,
+	}, true
+}
+
+// Hex converts the color to a 6-digit uppercase hex value, clamping each channel
+// to [0, 1] first
+func (c rgbColor) Hex() string {
+	toByte := func(v float64) int { return int(math.Round(clamp01(v) * 255)) }
+	return fmt.Sprintf("%02X%02X%02X", toByte(c.R), toByte(c.G), toByte(c.B))
+}
+
+// HSL converts the color to HSL
+func (c rgbColor) HSL() hslColor {
+	r, g, b := c.R, c.G, c.B
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	hsl := hslColor{L: (maxC + minC) / 2}
+
+	delta := maxC - minC
+	if delta == 0 {
+		// Achromatic: hue and saturation are undefined, use 0
+		return hsl
+	}
+
+	if hsl.L > 0.5 {
+		hsl.S = delta / (2 - maxC - minC)
+	} else {
+		hsl.S = delta / (maxC + minC)
+	}
+
+	switch maxC {
+	case r:
+		hsl.H = math.Mod((g-b)/delta+6, 6)
+	case g:
+		hsl.H = (b-r)/delta + 2
+	default:
+		hsl.H = (r-g)/delta + 4
+	}
+	hsl.H *= 60
+
+	return hsl
+}
+
+// hexToHSL converts a 6-digit hex color to HSL. Reports false for invalid input.
+func hexToHSL(hex string) (hslColor, bool) {
+	rgb, ok := hexToRGB(hex)
+	if !ok {
+		return hslColor{}, false
+	}
+	return rgb.HSL(), true
+}
+
+// RGB converts the color to RGB. S and L are clamped to [0, 1] and H is wrapped
+// into [0, 360) first.
+func (c hslColor) RGB() rgbColor {
+	h := math.Mod(c.H, 360)
+	if h < 0 {
+		h += 360
+	}
+	s := clamp01(c.S)
+	l := clamp01(c.L)
+
+	chroma := (1 - math.Abs(2*l-1)) * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - chroma/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = chroma, x, 0
+	case h < 120:
+		r, g, b = x, chroma, 0
+	case h < 180:
+		r, g, b = 0, chroma, x
+	case h < 240:
+		r, g, b = 0, x, chroma
+	case h < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	return rgbColor{R: r + m, G: g + m, B: b + m}
+}
+
+// Hex converts the color back to a 6-digit uppercase hex value, as RGB does
+func (c hslColor) Hex() string {
+	return c.RGB().Hex()
+}
+
+// clamp01 limits v to the range [0, 1]
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHexToHSL(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want hslColor
+	}{
+		{hex: "000000", want: hslColor{0, 0, 0}},
+		{hex: "FFFFFF", want: hslColor{0, 0, 1}},
+		{hex: "FF0000", want: hslColor{0, 1, 0.5}},
+		{hex: "00FF00", want: hslColor{120, 1, 0.5}},
+		{hex: "0000ff", want: hslColor{240, 1, 0.5}},
+		{hex: "808080", want: hslColor{0, 0, 128.0 / 255}},
+		{hex: "FF00FF", want: hslColor{300, 1, 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			got, ok := hexToHSL(tt.hex)
+			if !ok {
+				t.Fatalf("hexToHSL(%q) reported invalid", tt.hex)
+			}
+			if math.Abs(got.H-tt.want.H) > 1e-9 || math.Abs(got.S-tt.want.S) > 1e-9 || math.Abs(got.L-tt.want.L) > 1e-9 {
+				t.Errorf("hexToHSL(%q) = %+v, want %+v", tt.hex, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := hexToHSL("accent1"); ok {
+		t.Error("hexToHSL(accent1) should report invalid")
+	}
+}
+
+func TestHSLHex(t *testing.T) {
+	tests := []struct {
+		name string
+		hsl  hslColor
+		want string
+	}{
+		{name: "red", hsl: hslColor{0, 1, 0.5}, want: "FF0000"},
+		{name: "wraps past 360", hsl: hslColor{480, 1, 0.5}, want: "00FF00"},
+		{name: "wraps below 0", hsl: hslColor{-120, 1, 0.5}, want: "0000FF"},
+		{name: "clamps lightness", hsl: hslColor{0, 1, 1.5}, want: "FFFFFF"},
+		{name: "clamps saturation", hsl: hslColor{0, -1, 0.5}, want: "808080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hsl.Hex(); got != tt.want {
+				t.Errorf("%+v.Hex() = %q, want %q", tt.hsl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSLRoundTrip(t *testing.T) {
+	for _, hex := range []string{"4F81BD", "C0504D", "9BBB59", "8064A2", "4BACC6", "F79646", "1F497D", "EEECE1", "156082", "010203"} {
+		hsl, _ := hexToHSL(hex)
+		if got := hsl.Hex(); got != hex {
+			t.Errorf("round trip of %s = %s", hex, got)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// accentSlots are the accent slots of a color scheme, in the order palettes fill them
+var accentSlots = []string{"accent1", "accent2", "accent3", "accent4", "accent5", "accent6"}
+
+// paletteSeed seeds the k-means quantizer, so the same image always gives the same palette
+const paletteSeed = 1
+
+// maxPaletteSamples caps the pixels the quantizer clusters; larger images are sampled evenly
+const maxPaletteSamples = 20000
+
+// paletteIterations caps the k-means refinement rounds
+const paletteIterations = 50
+
+// accentPaletteChanges returns the slot → hex changes that set accent1, accent2, ...
+// to the colors of a palette, in order. Accents beyond the palette are left alone.
+func accentPaletteChanges(palette []string) map[string]string {
+	changes := make(map[string]string, len(palette))
+	for i, hex := range palette {
+		if i >= len(accentSlots) {
+			break
+		}
+		changes[accentSlots[i]] = hex
+	}
+	return changes
+}
+
+// DominantColors returns up to n dominant colors of an image as uppercase hex values,
+// most common first. Colors are found by k-means clustering of the image's opaque
+// pixels (sampled evenly on large images), seeded with k-means++ from a fixed seed:
+// the same image always gives the same palette. An image with fewer than n distinct
+// colors gives fewer colors.
+func DominantColors(img image.Image, n int) []string {
+	bounds := img.Bounds()
+	step := 1
+	if pixels := bounds.Dx() * bounds.Dy(); pixels > maxPaletteSamples {
+		step = int(math.Ceil(math.Sqrt(float64(pixels) / maxPaletteSamples)))
+	}
+
+	var samples [][3]float64
+	distinct := make(map[[3]float64]bool)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a == 0 {
+				continue // Fully transparent pixels have no color to speak of
+			}
+			// Undo alpha premultiplication and scale to 0-255
+			pixel := [3]float64{
+				math.Round(float64(r) * 255 / float64(a)),
+				math.Round(float64(g) * 255 / float64(a)),
+				math.Round(float64(b) * 255 / float64(a)),
+			}
+			samples = append(samples, pixel)
+			distinct[pixel] = true
+		}
+	}
+	if n > len(distinct) {
+		n = len(distinct)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	centers := seedCenters(samples, n, rand.New(rand.NewSource(paletteSeed)))
+	assignments := make([]int, len(samples))
+	counts := make([]int, n)
+	for iteration := 0; iteration < paletteIterations; iteration++ {
+		changed := iteration == 0
+		for i, sample := range samples {
+			nearest := nearestCenter(centers, sample)
+			if assignments[i] != nearest {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+
+		sums := make([][3]float64, n)
+		counts = make([]int, n)
+		for i, sample := range samples {
+			c := assignments[i]
+			counts[c]++
+			for channel := range sample {
+				sums[c][channel] += sample[channel]
+			}
+		}
+		for c := range centers {
+			if counts[c] == 0 {
+				continue
+			}
+			for channel := range centers[c] {
+				centers[c][channel] = sums[c][channel] / float64(counts[c])
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+
+	var palette []string
+	for _, c := range order {
+		if counts[c] == 0 {
+			continue
+		}
+		center := centers[c]
+		palette = append(palette, rgbColor{R: center[0] / 255, G: center[1] / 255, B: center[2] / 255}.Hex())
+	}
+	return palette
+}
+
+// seedCenters picks n initial cluster centers with k-means++: the first at random,
+// each next one with probability proportional to its squared distance from the
+// nearest center already picked
+func seedCenters(samples [][3]float64, n int, rng *rand.Rand) [][3]float64 {
+	centers := [][3]float64{samples[rng.Intn(len(samples))]}
+	distances := make([]float64, len(samples))
+	for len(centers) < n {
+		total := 0.0
+		for i, sample := range samples {
+			distances[i] = squaredDistance(sample, centers[nearestCenter(centers, sample)])
+			total += distances[i]
+		}
+
+		target := rng.Float64() * total
+		picked := -1
+		for i, distance := range distances {
+			if distance == 0 {
+				continue
+			}
+			picked = i
+			target -= distance
+			if target < 0 {
+				break
+			}
+		}
+		centers = append(centers, samples[picked])
+	}
+	return centers
+}
+
+// nearestCenter returns the index of the center closest to a sample
+func nearestCenter(centers [][3]float64, sample [3]float64) int {
+	nearest, best := 0, math.Inf(1)
+	for i, center := range centers {
+		if distance := squaredDistance(center, sample); distance < best {
+			nearest, best = i, distance
+		}
+	}
+	return nearest
+}
+
+// squaredDistance returns the squared Euclidean distance between two RGB colors
+func squaredDistance(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// LoadImagePalette decodes a PNG, JPEG, or GIF image and returns its n dominant
+// colors (see DominantColors)
+func LoadImagePalette(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", path, err)
+	}
+
+	palette := DominantColors(img, n)
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("no opaque pixels in %s", path)
+	}
+	return palette, nil
+}
+
+// MapAccentsToPalette sets accent1, accent2, ... of every theme, or of those in
+// themeFilter, to the colors of a palette, in order (see accentPaletteChanges).
+// Only theme definitions change; references follow the new colors. Returns the
+// number of themes processed.
+func MapAccentsToPalette(inputPath, outputPath string, palette []string, themeFilter []string) (int, error) {
+	return rewriteThemeColors(inputPath, outputPath, themeFilter, func(ColorScheme) map[string]string {
+		return accentPaletteChanges(palette)
+	})
+}
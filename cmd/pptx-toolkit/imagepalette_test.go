@@ -0,0 +1,140 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// stripedImage returns an image of vertical stripes, each stripe as many pixels
+// wide as its width
+func stripedImage(stripes []color.Color, widths []int) image.Image {
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	img := image.NewRGBA(image.Rect(0, 0, total, 4))
+	x := 0
+	for i, c := range stripes {
+		for end := x + widths[i]; x < end; x++ {
+			for y := 0; y < 4; y++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+func TestDominantColors(t *testing.T) {
+	red := color.RGBA{0xFF, 0, 0, 0xFF}
+	green := color.RGBA{0, 0x80, 0, 0xFF}
+	blue := color.RGBA{0, 0, 0xFF, 0xFF}
+	clear := color.RGBA{}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		n    int
+		want []string
+	}{
+		{
+			name: "most common first",
+			img:  stripedImage([]color.Color{red, green, blue}, []int{2, 5, 3}),
+			n:    3,
+			want: []string{"008000", "0000FF", "FF0000"},
+		},
+		{
+			name: "fewer distinct colors than asked",
+			img:  stripedImage([]color.Color{red, blue}, []int{3, 1}),
+			n:    6,
+			want: []string{"FF0000", "0000FF"},
+		},
+		{
+			name: "transparent pixels ignored",
+			img:  stripedImage([]color.Color{clear, blue}, []int{9, 1}),
+			n:    2,
+			want: []string{"0000FF"},
+		},
+		{
+			name: "fully transparent",
+			img:  stripedImage([]color.Color{clear}, []int{4}),
+			n:    2,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DominantColors(tt.img, tt.n)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DominantColors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The quantizer is seeded, so repeated runs agree even when clusters must merge
+	gradient := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			gradient.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 4), 0x80, 0xFF})
+		}
+	}
+	first := DominantColors(gradient, 4)
+	if len(first) != 4 {
+		t.Fatalf("DominantColors() on a gradient = %v, want 4 colors", first)
+	}
+	if again := DominantColors(gradient, 4); !reflect.DeepEqual(again, first) {
+		t.Errorf("DominantColors() is not deterministic: %v, then %v", first, again)
+	}
+}
+
+func TestMapAccentsToPalette(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "palette.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := stripedImage([]color.Color{color.RGBA{0x1F, 0x4E, 0x79, 0xFF}, color.RGBA{0xC0, 0x50, 0x4D, 0xFF}}, []int{3, 1})
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	palette, err := LoadImagePalette(imagePath, 6)
+	if err != nil {
+		t.Fatalf("LoadImagePalette() error = %v", err)
+	}
+
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := filepath.Join(dir, "output.pptx")
+	themesProcessed, err := MapAccentsToPalette(inputPath, outputPath, palette, nil)
+	if err != nil {
+		t.Fatalf("MapAccentsToPalette() error = %v", err)
+	}
+	if themesProcessed != 1 {
+		t.Errorf("themes processed = %d, want 1", themesProcessed)
+	}
+
+	before, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := before[0].Colors
+	want.Accent1, want.Accent2 = "1F4E79", "C0504D"
+	if after[0].Colors != want {
+		t.Errorf("colors = %+v, want %+v", after[0].Colors, want)
+	}
+
+	if _, err := LoadImagePalette(filepath.Join(dir, "output.pptx"), 6); err == nil {
+		t.Error("LoadImagePalette() of a non-image should fail")
+	}
+}
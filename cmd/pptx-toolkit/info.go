@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/spf13/cobra"
+)
+
+// emuPerInch is the number of English Metric Units in one inch
+const emuPerInch = 914400
+
+// SlideSize represents the slide dimensions declared in presentation.xml
+type SlideSize struct {
+	WidthEMU  int64 `json:"widthEmu"`
+	HeightEMU int64 `json:"heightEmu"`
+}
+
+// PaletteComplexity sums up how many different colors a presentation's slides,
+// layouts, and masters reference, as a gauge of how much work a rebrand will be
+type PaletteComplexity struct {
+	SchemeColors      int `json:"schemeColors"`      // Distinct scheme colors referenced (phClr not counted)
+	HexColors         int `json:"hexColors"`         // Distinct hardcoded hex colors
+	OffThemeHexColors int `json:"offThemeHexColors"` // Hex colors that match no color of any theme
+	Score             int `json:"score"`             // Every distinct color once, off-theme hex colors twice
+}
+
+// PresentationInfo is a quick overview of a PowerPoint file
+type PresentationInfo struct {
+	FileName     string            `json:"fileName"`
+	Slides       int               `json:"slides"`
+	HiddenSlides []int             `json:"hiddenSlides"`
+	Themes       int               `json:"themes"`
+	Masters      int               `json:"masters"`
+	SlideSize    SlideSize         `json:"slideSize"`
+	Palette      PaletteComplexity `json:"palette"`
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info <input.pptx>",
+	Short: "Show a quick overview of a PowerPoint file",
+	Long: `Show a quick overview of a PowerPoint file: slide count (and which slides
+are hidden), theme count, slide master count, and slide size.
+
+The palette line counts the distinct scheme colors and hardcoded hex colors that
+slides, layouts, and masters reference, and how many of those hex colors match no
+theme color. Its score counts every distinct color once and off-theme hex colors
+twice: the higher it is, the messier the deck is to rebrand.
+
+Examples:
+  pptx-toolkit info input.pptx
+
+  # Machine-readable output for scripting
+  pptx-toolkit info input.pptx --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInfo,
+}
+
+var infoFormat string
+
+func init() {
+	// Add --format flag to info command
+	infoCmd.Flags().StringVar(&infoFormat, "format", "text", "Output format (text, json)")
+}
+
+// ReadPresentationInfo gathers slide, theme, and master counts plus slide size
+func ReadPresentationInfo(pptxPath string) (*PresentationInfo, error) {
+	if _, err := os.Stat(pptxPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file not found: %s", pptxPath)
+	}
+
+	themes, err := ReadThemes(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PresentationInfo{
+		FileName: filepath.Base(pptxPath),
+		Themes:   len(themes),
+	}
+
+	err = withExtractedPPTX(pptxPath, func(tempDir string) error {
+		// Parse presentation.xml for slide list and size
+		presentationPath := filepath.Join(tempDir, filepath.FromSlash(presentationPart(tempDir)))
+		presentationFile, err := os.Open(presentationPath)
+		if err != nil {
+			return fmt.Errorf("failed to open presentation.xml: %w", err)
+		}
+		doc, err := xmlquery.Parse(presentationFile)
+		presentationFile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse presentation.xml: %w", err)
+		}
+
+		// A presentation may legitimately have no slides (e.g. a template)
+		if xmlquery.FindOne(doc, "//*[local-name()='sldIdLst']/*[local-name()='sldId']") != nil {
+			slideMapping, err := BuildSlideMapping(tempDir)
+			if err != nil {
+				return err
+			}
+			info.Slides = len(slideMapping)
+
+			info.HiddenSlides, err = FindHiddenSlides(tempDir)
+			if err != nil {
+				return err
+			}
+		}
+
+		masters, err := filepath.Glob(filepath.Join(docPath(tempDir, "slideMasters"), "slideMaster*.xml"))
+		if err != nil {
+			return err
+		}
+		info.Masters = len(masters)
+
+		if sldSz := xmlquery.FindOne(doc, "//*[local-name()='sldSz']"); sldSz != nil {
+			info.SlideSize.WidthEMU, _ = strconv.ParseInt(sldSz.SelectAttr("cx"), 10, 64)
+			info.SlideSize.HeightEMU, _ = strconv.ParseInt(sldSz.SelectAttr("cy"), 10, 64)
+		}
+
+		info.Palette, err = measurePalette(tempDir, themes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// measurePalette counts the distinct colors referenced by the parts of an extracted
+// presentation in the "all" scope. Hex colors are compared case-insensitively, and
+// against the colors of every theme, since a part's theme is not always known.
+func measurePalette(tempDir string, themes []*Theme) (PaletteComplexity, error) {
+	themeHex := make(map[string]bool)
+	for _, theme := range themes {
+		for _, name := range schemeColorNames {
+			themeHex[strings.ToUpper(theme.Colors.Get(name))] = true
+		}
+	}
+
+	schemeColors := make(map[string]bool)
+	hexColors := make(map[string]bool)
+	xmlPatterns := getXMLPatternsUnder(documentRoot(tempDir), ScopeAll)
+	err := filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(tempDir, path)
+		relPath = filepath.ToSlash(relPath)
+		inScope := false
+		for _, pattern := range xmlPatterns {
+			if strings.HasPrefix(relPath, pattern) {
+				inScope = true
+				break
+			}
+		}
+		if !inScope {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		for _, ref := range ExtractColors(content) {
+			switch {
+			case ref.Kind == ColorRefSrgb:
+				hexColors[strings.ToUpper(ref.Value)] = true
+			case ValidSchemeColors[ref.Value] || colorMapAliases[ref.Value]:
+				schemeColors[ref.Value] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return PaletteComplexity{}, err
+	}
+
+	palette := PaletteComplexity{SchemeColors: len(schemeColors), HexColors: len(hexColors)}
+	for hex := range hexColors {
+		if !themeHex[hex] {
+			palette.OffThemeHexColors++
+		}
+	}
+	palette.Score = palette.SchemeColors + palette.HexColors + palette.OffThemeHexColors
+	return palette, nil
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile := args[0]
+
+	if infoFormat != "text" && infoFormat != "json" {
+		cmd.PrintErrf("Error: invalid format '%s'. Valid values: json, text\n", infoFormat)
+		return errSilent
+	}
+
+	info, err := ReadPresentationInfo(inputFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	if infoFormat == "json" {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	cmd.Printf("File:       %s\n", inputFile)
+	if len(info.HiddenSlides) > 0 {
+		cmd.Printf("Slides:     %d (%d hidden: %s)\n", info.Slides, len(info.HiddenSlides), formatSlides(info.HiddenSlides))
+	} else {
+		cmd.Printf("Slides:     %d\n", info.Slides)
+	}
+	cmd.Printf("Themes:     %d\n", info.Themes)
+	cmd.Printf("Masters:    %d\n", info.Masters)
+	cmd.Printf("Slide size: %.2f × %.2f in (%d × %d EMU)\n",
+		float64(info.SlideSize.WidthEMU)/emuPerInch, float64(info.SlideSize.HeightEMU)/emuPerInch,
+		info.SlideSize.WidthEMU, info.SlideSize.HeightEMU)
+	cmd.Printf("Palette:    score %d (%d scheme colors, %d hex colors, %d off-theme)\n",
+		info.Palette.Score, info.Palette.SchemeColors, info.Palette.HexColors, info.Palette.OffThemeHexColors)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPresentationInfo(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	info, err := ReadPresentationInfo(testPPTX)
+	if err != nil {
+		t.Fatalf("ReadPresentationInfo() error = %v", err)
+	}
+
+	themes, err := ReadThemes(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Slides != 13 {
+		t.Errorf("expected 13 slides, got %d", info.Slides)
+	}
+	if info.Themes != len(themes) {
+		t.Errorf("expected %d themes, got %d", len(themes), info.Themes)
+	}
+	if info.Masters != 3 {
+		t.Errorf("expected 3 masters, got %d", info.Masters)
+	}
+	if info.SlideSize.WidthEMU != 12192000 || info.SlideSize.HeightEMU != 6858000 {
+		t.Errorf("unexpected slide size: %+v", info.SlideSize)
+	}
+}
+
+func TestReadPresentationInfo_NoSlides(t *testing.T) {
+	path := writeSyntheticPPTX(t, syntheticDeck{Slides: 0})
+
+	info, err := ReadPresentationInfo(path)
+	if err != nil {
+		t.Fatalf("ReadPresentationInfo() error = %v", err)
+	}
+
+	if info.Slides != 0 {
+		t.Errorf("expected 0 slides, got %d", info.Slides)
+	}
+	if info.Masters != 1 || info.Themes != 1 {
+		t.Errorf("expected 1 master and 1 theme, got %d and %d", info.Masters, info.Themes)
+	}
+}
+
+func TestReadPresentationInfo_Palette(t *testing.T) {
+	// Slide colors: accent1-4, and AABBCC, FF0000, 00FF00, 0000FF (the theme's hlink);
+	// the master's background adds bg1
+	path := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 8})
+
+	info, err := ReadPresentationInfo(path)
+	if err != nil {
+		t.Fatalf("ReadPresentationInfo() error = %v", err)
+	}
+
+	want := PaletteComplexity{SchemeColors: 5, HexColors: 4, OffThemeHexColors: 3, Score: 12}
+	if info.Palette != want {
+		t.Errorf("Palette = %+v, want %+v", info.Palette, want)
+	}
+}
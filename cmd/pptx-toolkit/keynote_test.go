@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// keynoteDeck returns a two-slide presentation in the style of Apple Keynote's
+// PowerPoint export: DrawingML and PresentationML bound to other prefixes (and the
+// theme to the default namespace), and a slide master with no relationship to its
+// theme
+func keynoteDeck(tb testing.TB) string {
+	tb.Helper()
+
+	slide := func(colors string) string {
+		return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<pml:sld xmlns:dml="` + drawingmlNS + `" xmlns:pml="` + presentationmlNS + `"><pml:cSld><pml:spTree>` +
+			`<pml:sp><pml:spPr><dml:solidFill>` + colors + `</dml:solidFill></pml:spPr></pml:sp>` +
+			`</pml:spTree></pml:cSld></pml:sld>`
+	}
+
+	return writeSyntheticPPTX(tb, syntheticDeck{Slides: 2, Parts: map[string]string{
+		"ppt/theme/theme1.xml": strings.NewReplacer(`xmlns:a=`, `xmlns=`, `<a:`, `<`, `</a:`, `</`).
+			Replace(syntheticThemeXML("Keynote Theme", "Keynote")),
+		"ppt/slideMasters/slideMaster1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<pml:sldMaster xmlns:dml="` + drawingmlNS + `" xmlns:pml="` + presentationmlNS + `">` +
+			`<pml:cSld><pml:bg><pml:bgPr><dml:solidFill><dml:schemeClr val="bg1"/></dml:solidFill></pml:bgPr></pml:bg></pml:cSld>` +
+			`<pml:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+			`</pml:sldMaster>`,
+		"ppt/slideMasters/_rels/slideMaster1.xml.rels": syntheticRelsXML([3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"}),
+		"ppt/slides/slide1.xml":                        slide(`<dml:schemeClr val="accent1"><dml:lumMod val="75000"/></dml:schemeClr>`),
+		"ppt/slides/slide2.xml":                        slide(`<dml:srgbClr val="4f81bd"/>`),
+	}})
+}
+
+func TestKeynoteExport(t *testing.T) {
+	path := keynoteDeck(t)
+
+	t.Run("color list", func(t *testing.T) {
+		themes, err := ReadThemes(path)
+		if err != nil {
+			t.Fatalf("ReadThemes() error = %v", err)
+		}
+		if len(themes) != 1 || themes[0].ColorSchemeName != "Keynote" || themes[0].Colors.Accent1 != "4F81BD" {
+			t.Fatalf("ReadThemes() = %+v, want the Keynote scheme with accent1 4F81BD", themes)
+		}
+	})
+
+	t.Run("theme resolution", func(t *testing.T) {
+		err := withExtractedPPTX(path, func(tempDir string) error {
+			masterToTheme, err := buildThemeRelationships(tempDir)
+			if err != nil {
+				return err
+			}
+			if want := map[string]string{"slideMaster1.xml": "theme1.xml"}; !reflect.DeepEqual(masterToTheme, want) {
+				t.Errorf("buildThemeRelationships() = %v, want %v", masterToTheme, want)
+			}
+
+			slideThemes, err := BuildSlideThemeMapping(tempDir)
+			if err != nil {
+				return err
+			}
+			for _, slideTheme := range slideThemes {
+				if slideTheme.Theme != "theme1.xml" {
+					t.Errorf("slide %d resolved to theme %q, want theme1.xml", slideTheme.Slide, slideTheme.Theme)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("color swap", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "output.pptx")
+		mapping := map[string]string{"accent1": "accent2", "4F81BD": "accent3"}
+		if _, _, err := ProcessPPTX(path, output, mapping, []string{"theme1"}, "all", nil); err != nil {
+			t.Fatalf("ProcessPPTX() error = %v", err)
+		}
+
+		slide1 := string(readZipEntry(t, output, "ppt/slides/slide1.xml"))
+		if !strings.Contains(slide1, `<dml:schemeClr val="accent2"><dml:lumMod val="75000"/></dml:schemeClr>`) {
+			t.Errorf("slide1.xml not swapped:\n%s", slide1)
+		}
+		slide2 := string(readZipEntry(t, output, "ppt/slides/slide2.xml"))
+		if !strings.Contains(slide2, `<dml:schemeClr val="accent3"/>`) {
+			t.Errorf("slide2.xml not swapped:\n%s", slide2)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -31,17 +33,73 @@ Source: https://github.com/pmdci/pptx-toolkit
 
 Brought to you by the letter P.`
 
+// quiet suppresses progress output
+var quiet bool
+
+// verbose shows notices about how input was interpreted
+var verbose bool
+
+// assumeYes overwrites existing outputs without asking
+var assumeYes bool
+
+// noPrompt refuses to overwrite existing outputs instead of asking
+var noPrompt bool
+
+// checkTempDir rejects an unusable --temp-dir before any command runs
+func checkTempDir(cmd *cobra.Command, args []string) error {
+	if defaultTempDir == "" {
+		return nil
+	}
+	if err := ValidateTempDir(defaultTempDir); err != nil {
+		cmd.SilenceUsage = true
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+	return nil
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "pptx-toolkit",
 	Short: "Microsoft® PowerPoint toolkit for colors, themes, and other utilities",
 	Long:  "Microsoft® PowerPoint manipulation toolkit.\n\nUse \"pptx-toolkit <group> <command> --help\" for command-specific help.",
+
+	PersistentPreRunE: checkTempDir,
 }
 
 func init() {
 	rootCmd.Flags().BoolP("version", "v", false, "version for pptx-toolkit")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress output")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Show notices about how input was interpreted")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Overwrite existing output files without asking")
+	rootCmd.PersistentFlags().BoolVar(&noPrompt, "no-prompt", false, "Fail instead of asking when an output file exists (wins over --yes)")
+	rootCmd.PersistentFlags().StringVar(&defaultTempDir, "temp-dir", "", "Directory to extract presentations in (default: the system temp directory)")
 	rootCmd.AddCommand(colorCmd)
+	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(slideCmd)
+	rootCmd.AddCommand(themeCmd)
 	// Silence errors - subcommands print their own errors
 	rootCmd.SilenceErrors = true
+	// Results go to stdout; errors, warnings, notes, and prompts to stderr. Cobra
+	// would otherwise send cmd.Print output to stderr as well.
+	rootCmd.SetOut(os.Stdout)
+	rootCmd.SetErr(os.Stderr)
+}
+
+// removeTempsOnSignal removes the temporary files in use when the process is
+// interrupted (Ctrl-C) or terminated, which would otherwise skip the deferred
+// cleanup and leave whole extracted decks behind, then exits with the shell's
+// conventional 128+signal status (ExitInterrupt or ExitTerminated)
+func removeTempsOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		removeAllTemps()
+		if sig == syscall.SIGTERM {
+			os.Exit(ExitTerminated)
+		}
+		os.Exit(ExitInterrupt)
+	}()
 }
 
 func main() {
@@ -54,8 +112,9 @@ func main() {
 		}
 	}
 
+	removeTempsOnSignal()
+
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(reportError(os.Stderr, err))
 	}
 }
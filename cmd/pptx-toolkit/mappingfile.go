@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MappingFile is a swap spec read from a mapping file (see LoadMappingFile)
+type MappingFile struct {
+	Mapping       map[string]string            // Mappings before any section header, for every theme
+	ThemeMappings map[string]map[string]string // Mappings under each [theme] header, by theme file name (e.g., "theme1.xml")
+}
+
+// LoadMappingFile reads a mapping file: one or more comma-separated source:target
+// mappings per line, validated as by ParseColorMapping. Blank lines and lines
+// starting with '#' are ignored. A "[theme1]" header scopes the mappings after it,
+// up to the next header, to that theme, as --theme-mapping does; mappings before
+// the first header apply to every theme. A header may be repeated, adding to its
+// theme's mappings. Errors name the file and line.
+//
+// Example:
+//
+//	# Rebrand 2026
+//	accent1:1F4E79
+//
+//	[theme2]
+//	accent2:C00000, AABBCC:accent1
+//
+// The returned notices are the mapping parser's, for display in verbose mode.
+func LoadMappingFile(path string) (*MappingFile, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	defer file.Close()
+
+	spec := &MappingFile{
+		Mapping:       make(map[string]string),
+		ThemeMappings: make(map[string]map[string]string),
+	}
+	var notices []string
+
+	section := spec.Mapping
+	sectionName := ""
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			theme, ok := strings.CutSuffix(line[1:], "]")
+			theme = strings.TrimSpace(theme)
+			if !ok || theme == "" {
+				return nil, nil, fmt.Errorf("%s:%d: invalid section header '%s'. Expected '[theme1]'", path, lineNum, line)
+			}
+			if !strings.HasSuffix(theme, ".xml") {
+				theme += ".xml"
+			}
+			if spec.ThemeMappings[theme] == nil {
+				spec.ThemeMappings[theme] = make(map[string]string)
+			}
+			section = spec.ThemeMappings[theme]
+			sectionName = strings.TrimSuffix(theme, ".xml")
+			continue
+		}
+
+		mapping, lineNotices, err := parseColorMapping(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		for _, notice := range lineNotices {
+			notices = append(notices, fmt.Sprintf("%s:%d: %s", path, lineNum, notice))
+		}
+
+		for source, target := range mapping {
+			if existing, exists := section[source]; exists && existing != target {
+				err := &ErrConflictingMapping{Source: source, A: existing, B: target}
+				if sectionName != "" {
+					return nil, nil, fmt.Errorf("%s:%d: [%s]: %w", path, lineNum, sectionName, err)
+				}
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+			}
+			section[source] = target
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	// A header with nothing under it is most likely an unfinished edit
+	for theme, mapping := range spec.ThemeMappings {
+		if len(mapping) == 0 {
+			return nil, nil, fmt.Errorf("%s: section [%s] has no mappings", path, strings.TrimSuffix(theme, ".xml"))
+		}
+	}
+	if len(spec.Mapping) == 0 && len(spec.ThemeMappings) == 0 {
+		return nil, nil, fmt.Errorf("no mappings found in %s", path)
+	}
+
+	return spec, notices, nil
+}
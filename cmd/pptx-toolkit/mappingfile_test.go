@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadMappingFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		want          map[string]string
+		wantThemes    map[string]map[string]string
+		wantErrSubstr string
+	}{
+		{
+			name:       "comments and blank lines",
+			content:    "# Rebrand 2026\n\naccent1:1F4E79\n   # indented comment\n\t\nAccent2:accent3, AABBCC:FF0000\n",
+			want:       map[string]string{"accent1": "1F4E79", "accent2": "accent3", "AABBCC": "FF0000"},
+			wantThemes: map[string]map[string]string{},
+		},
+		{
+			name:    "sections scope the mappings below them",
+			content: "accent1:1F4E79\n\n[theme2]\naccent2:C00000\n[ theme3.xml ]\naccent2:00B050\n[theme2]\nAABBCC:accent1\n",
+			want:    map[string]string{"accent1": "1F4E79"},
+			wantThemes: map[string]map[string]string{
+				"theme2.xml": {"accent2": "C00000", "AABBCC": "accent1"},
+				"theme3.xml": {"accent2": "00B050"},
+			},
+		},
+		{
+			name:       "sections only",
+			content:    "[theme1]\naccent1:accent2\n",
+			want:       map[string]string{},
+			wantThemes: map[string]map[string]string{"theme1.xml": {"accent1": "accent2"}},
+		},
+		{
+			name:       "same source in different sections",
+			content:    "accent1:accent2\n[theme1]\naccent1:accent3\n",
+			want:       map[string]string{"accent1": "accent2"},
+			wantThemes: map[string]map[string]string{"theme1.xml": {"accent1": "accent3"}},
+		},
+		{
+			name:          "invalid color names the line",
+			content:       "# header\naccent1:accent2\naccent9:FF0000\n",
+			wantErrSubstr: ":3: invalid source color: 'accent9'",
+		},
+		{
+			name:          "conflict across lines",
+			content:       "accent1:accent2\n[theme1]\naccent3:FF0000\naccent3:00FF00\n",
+			wantErrSubstr: ":4: [theme1]: conflicting mappings for 'accent3'",
+		},
+		{
+			name:          "unterminated header",
+			content:       "[theme1\naccent1:accent2\n",
+			wantErrSubstr: ":1: invalid section header '[theme1'",
+		},
+		{
+			name:          "empty section",
+			content:       "accent1:accent2\n[theme2]\n# to do\n",
+			wantErrSubstr: "section [theme2] has no mappings",
+		},
+		{
+			name:          "comments only",
+			content:       "# nothing yet\n\n",
+			wantErrSubstr: "no mappings found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec, _, err := LoadMappingFile(path)
+			if tt.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+					t.Fatalf("LoadMappingFile() error = %v, want it to contain %q", err, tt.wantErrSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMappingFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(spec.Mapping, tt.want) {
+				t.Errorf("Mapping = %v, want %v", spec.Mapping, tt.want)
+			}
+			if !reflect.DeepEqual(spec.ThemeMappings, tt.wantThemes) {
+				t.Errorf("ThemeMappings = %v, want %v", spec.ThemeMappings, tt.wantThemes)
+			}
+		})
+	}
+}
+
+func TestLoadMappingFile_TypedErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.txt")
+	if err := os.WriteFile(path, []byte("accent1:accent2\naccent1:accent3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := LoadMappingFile(path)
+	var conflict *ErrConflictingMapping
+	if !errors.As(err, &conflict) || conflict.Source != "accent1" {
+		t.Errorf("LoadMappingFile() error = %v, want *ErrConflictingMapping for accent1", err)
+	}
+}
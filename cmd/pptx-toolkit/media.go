@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rasterMediaExtensions are the image formats whose colors are baked into pixels,
+// so no swap can change them
+var rasterMediaExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".bmp": true, ".tif": true, ".tiff": true,
+}
+
+// MediaUse is a raster image part and the slides that reference it
+type MediaUse struct {
+	Path   string `json:"path"`   // Media part path (e.g., "ppt/media/image1.png")
+	Slides []int  `json:"slides"` // Visual slide numbers referencing it, in order
+}
+
+// FindRasterMedia returns the raster images referenced by the slides of an extracted
+// presentation, following each slide's image relationships. External images are
+// left out. The result is ordered by part path.
+func FindRasterMedia(tempDir string) ([]MediaUse, error) {
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	slideNums := make([]int, 0, len(slideMapping))
+	for slideNum := range slideMapping {
+		slideNums = append(slideNums, slideNum)
+	}
+	sort.Ints(slideNums)
+
+	uses := make(map[string][]int)
+	for _, slideNum := range slideNums {
+		slidePath := filepath.Join(tempDir, slideMapping[slideNum])
+		for _, rel := range readPartRels(slidePath) {
+			target := rel.SelectAttr("Target")
+			if !strings.HasSuffix(rel.SelectAttr("Type"), "/image") || target == "" || rel.SelectAttr("TargetMode") == "External" {
+				continue
+			}
+			if !rasterMediaExtensions[strings.ToLower(filepath.Ext(target))] {
+				continue
+			}
+
+			mediaRelPath, _ := filepath.Rel(tempDir, resolveRelativePath(tempDir, slidePath, target))
+			mediaRelPath = filepath.ToSlash(mediaRelPath)
+
+			// A slide may reference the same image several times
+			slides := uses[mediaRelPath]
+			if len(slides) == 0 || slides[len(slides)-1] != slideNum {
+				uses[mediaRelPath] = append(slides, slideNum)
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(uses))
+	for path := range uses {
+		paths = append(paths, path)
+	}
+	sortNatural(paths)
+
+	result := make([]MediaUse, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, MediaUse{Path: path, Slides: uses[path]})
+	}
+	return result, nil
+}
+
+// RasterMedia is FindRasterMedia for a PPTX file
+func RasterMedia(pptxPath string) ([]MediaUse, error) {
+	var result []MediaUse
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		var err error
+		result, err = FindRasterMedia(tempDir)
+		return err
+	})
+	return result, err
+}
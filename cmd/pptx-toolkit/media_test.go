@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRasterMedia(t *testing.T) {
+	layout := [3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"}
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         3,
+		ColorsPerSlide: 1,
+		Parts: map[string]string{
+			"ppt/slides/_rels/slide1.xml.rels": syntheticRelsXML(layout,
+				[3]string{"rId2", "image", "../media/image2.png"},
+				[3]string{"rId3", "image", "../media/image2.png"},
+				[3]string{"rId4", "image", "../media/image10.JPEG"}),
+			"ppt/slides/_rels/slide3.xml.rels": syntheticRelsXML(layout,
+				[3]string{"rId2", "image", "../media/image2.png"},
+				[3]string{"rId3", "image", "../media/image3.svg"},
+				[3]string{"rId4", "image", "../media/image4.emf"}),
+			"ppt/media/image2.png":   "png",
+			"ppt/media/image10.JPEG": "jpeg",
+			"ppt/media/image3.svg":   "<svg/>",
+			"ppt/media/image4.emf":   "emf",
+		},
+	})
+
+	got, err := RasterMedia(inputPath)
+	if err != nil {
+		t.Fatalf("RasterMedia() error = %v", err)
+	}
+	want := []MediaUse{
+		{Path: "ppt/media/image2.png", Slides: []int{1, 3}},
+		{Path: "ppt/media/image10.JPEG", Slides: []int{1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RasterMedia() = %+v, want %+v", got, want)
+	}
+}
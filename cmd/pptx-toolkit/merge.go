@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ThemeRename describes how one theme of the second presentation is renamed
+// so it no longer collides with a theme of the first
+type ThemeRename struct {
+	OldFile string `json:"oldFile"` // e.g., "theme1.xml"
+	NewFile string `json:"newFile"` // e.g., "theme6.xml" (same as OldFile if only the name collides)
+	OldName string `json:"oldName"` // e.g., "Office Theme"
+	NewName string `json:"newName"` // e.g., "Office Theme (2)" (same as OldName if only the file collides)
+}
+
+// themeFileNumberPattern extracts the number from a theme part name
+var themeFileNumberPattern = regexp.MustCompile(`^theme(\d+)\.xml$`)
+
+// themeNameAttrPattern matches the name attribute of a theme's root element
+var themeNameAttrPattern = regexp.MustCompile(`(<(?:[A-Za-z_][\w.\-]*:)?theme\b[^>]*?\sname=")([^"]*)(")`)
+
+// relTargetPattern matches the Target attribute of a relationship
+var relTargetPattern = regexp.MustCompile(`(\sTarget=")([^"]*)(")`)
+
+// themeFileNumber returns the number of a themeN.xml part, or 0 if it has none
+func themeFileNumber(fileName string) int {
+	match := themeFileNumberPattern.FindStringSubmatch(fileName)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// PlanThemeMerge compares the themes of two presentations and returns the renames
+// needed for the second presentation's themes to coexist with the first's: colliding
+// part names are renumbered after the highest theme number used by either file, and
+// colliding display names get a " (2)", " (3)", ... suffix. Themes that do not
+// collide are not listed.
+func PlanThemeMerge(firstPath, secondPath string) ([]ThemeRename, error) {
+	firstThemes, err := ReadThemes(firstPath)
+	if err != nil {
+		return nil, err
+	}
+	secondThemes, err := ReadThemes(secondPath)
+	if err != nil {
+		return nil, err
+	}
+
+	usedFiles := make(map[string]bool)
+	usedNames := make(map[string]bool)
+	nextNumber := 1
+	for _, theme := range firstThemes {
+		usedFiles[theme.FileName] = true
+		usedNames[theme.ThemeName] = true
+		nextNumber = max(nextNumber, themeFileNumber(theme.FileName)+1)
+	}
+	for _, theme := range secondThemes {
+		nextNumber = max(nextNumber, themeFileNumber(theme.FileName)+1)
+	}
+
+	var renames []ThemeRename
+	for _, theme := range secondThemes {
+		rename := ThemeRename{
+			OldFile: theme.FileName,
+			NewFile: theme.FileName,
+			OldName: theme.ThemeName,
+			NewName: theme.ThemeName,
+		}
+
+		if usedFiles[theme.FileName] {
+			rename.NewFile = fmt.Sprintf("theme%d.xml", nextNumber)
+			nextNumber++
+		}
+
+		if usedNames[theme.ThemeName] {
+			for n := 2; ; n++ {
+				candidate := fmt.Sprintf("%s (%d)", theme.ThemeName, n)
+				if !usedNames[candidate] {
+					rename.NewName = candidate
+					break
+				}
+			}
+		}
+		usedNames[rename.NewName] = true
+
+		if rename.NewFile != rename.OldFile || rename.NewName != rename.OldName {
+			renames = append(renames, rename)
+		}
+	}
+
+	return renames, nil
+}
+
+// ApplyThemeRenames writes a copy of a presentation with its themes renamed:
+// theme parts (and their relationship parts) are moved to their new names, every
+// relationship targeting them and the content types are updated, and the theme
+// display names are rewritten. Returns the number of themes renamed.
+func ApplyThemeRenames(inputPath, outputPath string, renames []ThemeRename) (int, error) {
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return 0, fmt.Errorf("input file not found: %s", inputPath)
+	}
+
+	tempDir, err := newTempDir("")
+	if err != nil {
+		return 0, err
+	}
+	defer removeTemp(tempDir)
+
+	if err := extractPPTX(inputPath, tempDir); err != nil {
+		return 0, err
+	}
+
+	themeDir := rootPath(documentRoot(tempDir), "theme")
+	changedFiles := make(map[string]bool)
+	renamedFiles := make(map[string]string)
+	fileRenames := make(map[string]string)
+
+	for _, rename := range renames {
+		oldPart := path.Join(themeDir, rename.OldFile)
+		newPart := path.Join(themeDir, rename.NewFile)
+
+		if rename.NewName != rename.OldName {
+			if err := renameThemeDisplayName(filepath.Join(tempDir, filepath.FromSlash(oldPart)), rename.NewName); err != nil {
+				return 0, fmt.Errorf("%s: %w", rename.OldFile, err)
+			}
+			changedFiles[oldPart] = true
+		}
+
+		if rename.NewFile == rename.OldFile {
+			continue
+		}
+		fileRenames[rename.OldFile] = rename.NewFile
+
+		// Move the theme part and its relationships part, if any
+		moves := [][2]string{
+			{oldPart, newPart},
+			{path.Join(themeDir, "_rels", rename.OldFile+".rels"), path.Join(themeDir, "_rels", rename.NewFile+".rels")},
+		}
+		for _, move := range moves {
+			oldPath := filepath.Join(tempDir, filepath.FromSlash(move[0]))
+			if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+				continue
+			}
+			if err := os.Rename(oldPath, filepath.Join(tempDir, filepath.FromSlash(move[1]))); err != nil {
+				return 0, err
+			}
+			renamedFiles[move[0]] = move[1]
+		}
+	}
+
+	if len(fileRenames) > 0 {
+		updated, err := retargetThemeRelationships(tempDir, fileRenames)
+		if err != nil {
+			return 0, err
+		}
+		for _, part := range updated {
+			changedFiles[part] = true
+		}
+
+		if err := renameContentTypeOverrides(filepath.Join(tempDir, "[Content_Types].xml"), themeDir, fileRenames); err != nil {
+			return 0, err
+		}
+		changedFiles["[Content_Types].xml"] = true
+	}
+
+	if err := writeRenamedPPTX(inputPath, outputPath, tempDir, changedFiles, renamedFiles); err != nil {
+		return 0, err
+	}
+
+	return len(renames), nil
+}
+
+// renameThemeDisplayName rewrites the name attribute of a theme part's root element
+func renameThemeDisplayName(themePath, newName string) error {
+	content, err := os.ReadFile(themePath)
+	if err != nil {
+		return err
+	}
+
+	modified, ok, err := setNameAttr(content, themeNameAttrPattern, newName)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("theme has no name attribute")
+	}
+
+	return os.WriteFile(themePath, modified, 0644)
+}
+
+// retargetThemeRelationships updates every relationship part whose targets point
+// at a renamed theme (old file name → new file name). Returns the archive paths
+// of the relationship parts that changed.
+func retargetThemeRelationships(tempDir string, fileRenames map[string]string) ([]string, error) {
+	var updated []string
+
+	err := filepath.Walk(tempDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(filePath, ".rels") {
+			return nil
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		var edits []byteEdit
+		for _, loc := range relTargetPattern.FindAllSubmatchIndex(content, -1) {
+			target := string(content[loc[4]:loc[5]])
+			if path.Base(path.Dir(target)) != "theme" {
+				continue
+			}
+			if newFile, ok := fileRenames[path.Base(target)]; ok {
+				edits = append(edits, byteEdit{loc[4], loc[5], []byte(path.Join(path.Dir(target), newFile))})
+			}
+		}
+		if len(edits) == 0 {
+			return nil
+		}
+
+		if err := os.WriteFile(filePath, applyEdits(content, edits), info.Mode()); err != nil {
+			return err
+		}
+
+		relPath, _ := filepath.Rel(tempDir, filePath)
+		updated = append(updated, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(updated)
+	return updated, nil
+}
+
+// renameContentTypeOverrides updates the content type overrides of theme parts
+// renamed within the themeDir archive folder
+func renameContentTypeOverrides(contentTypesPath, themeDir string, fileRenames map[string]string) error {
+	content, err := os.ReadFile(contentTypesPath)
+	if err != nil {
+		return fmt.Errorf("failed to read [Content_Types].xml: %w", err)
+	}
+
+	for oldFile, newFile := range fileRenames {
+		content = bytes.ReplaceAll(content,
+			[]byte(`PartName="/`+path.Join(themeDir, oldFile)+`"`),
+			[]byte(`PartName="/`+path.Join(themeDir, newFile)+`"`))
+	}
+
+	return os.WriteFile(contentTypesPath, content, 0644)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanThemeMerge(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	// Merging a deck with itself collides on every theme
+	renames, err := PlanThemeMerge(testPPTX, testPPTX)
+	if err != nil {
+		t.Fatalf("PlanThemeMerge() error = %v", err)
+	}
+	if len(renames) != 5 {
+		t.Fatalf("expected 5 renames, got %d: %+v", len(renames), renames)
+	}
+	for i, rename := range renames {
+		wantFile := fmt.Sprintf("theme%d.xml", 6+i)
+		if rename.NewFile != wantFile {
+			t.Errorf("rename %d: new file = %s, want %s", i, rename.NewFile, wantFile)
+		}
+		if !strings.HasSuffix(rename.NewName, ")") || rename.NewName == rename.OldName {
+			t.Errorf("rename %d: expected a suffixed display name, got %q", i, rename.NewName)
+		}
+	}
+
+	// A deck with distinct themes does not collide
+	synthetic := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts: map[string]string{
+			"ppt/theme/theme1.xml": "",
+			"ppt/theme/theme9.xml": syntheticThemeXML("Unique Theme", "Unique"),
+		},
+	})
+	renames, err = PlanThemeMerge(testPPTX, synthetic)
+	if err != nil {
+		t.Fatalf("PlanThemeMerge() error = %v", err)
+	}
+	if len(renames) != 0 {
+		t.Errorf("expected no collisions, got %+v", renames)
+	}
+}
+
+func TestApplyThemeRenames(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	renames, err := PlanThemeMerge(testPPTX, testPPTX)
+	if err != nil {
+		t.Fatalf("PlanThemeMerge() error = %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	count, err := ApplyThemeRenames(testPPTX, outputPath, renames)
+	if err != nil {
+		t.Fatalf("ApplyThemeRenames() error = %v", err)
+	}
+	if count != len(renames) {
+		t.Errorf("renamed %d themes, want %d", count, len(renames))
+	}
+
+	themes, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for _, theme := range themes {
+		names[theme.FileName] = theme.ThemeName
+	}
+	for _, rename := range renames {
+		if names[rename.NewFile] != rename.NewName {
+			t.Errorf("%s: name = %q, want %q", rename.NewFile, names[rename.NewFile], rename.NewName)
+		}
+		if _, ok := names[rename.OldFile]; ok {
+			t.Errorf("%s should have been moved", rename.OldFile)
+		}
+	}
+
+	// Slides must still resolve to the (renamed) themes
+	contentTypes := string(readZipEntry(t, outputPath, "[Content_Types].xml"))
+	err = withExtractedPPTX(outputPath, func(tempDir string) error {
+		slideThemes, err := BuildSlideThemeMapping(tempDir)
+		if err != nil {
+			return err
+		}
+		for _, st := range slideThemes {
+			if _, ok := names[st.Theme]; !ok {
+				t.Errorf("slide %d: theme %q does not exist", st.Slide, st.Theme)
+			}
+			if !strings.Contains(contentTypes, `PartName="/ppt/theme/`+st.Theme+`"`) {
+				t.Errorf("slide %d: no content type override for %s", st.Slide, st.Theme)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import "sort"
+
+// naturalLess compares strings so that runs of digits are ordered by their
+// numeric value: "theme2.xml" sorts before "theme10.xml"
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the digit runs by value: skip leading zeros, then the longer run is larger
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA, numB := trimZeros(a[startA:i]), trimZeros(b[startB:j])
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	return len(a)-i < len(b)-j
+}
+
+// sortNatural sorts strings in natural order (see naturalLess)
+func sortNatural(strs []string) {
+	sort.Slice(strs, func(i, j int) bool { return naturalLess(strs[i], strs[j]) })
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// trimZeros drops the leading zeros of a digit run, keeping at least one digit
+func trimZeros(digits string) string {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+	return digits
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"theme2.xml", "theme10.xml", true},
+		{"theme10.xml", "theme2.xml", false},
+		{"theme1.xml", "theme1.xml", false},
+		{"theme01", "theme2", true},
+		{"theme", "theme1", true},
+		{"slideLayout9.xml", "slideMaster1.xml", true},
+		{"a", "b", true},
+	}
+
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNaturalThemeOrder(t *testing.T) {
+	path := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts: map[string]string{
+			"ppt/theme/theme10.xml": syntheticThemeXML("Tenth", "Tenth"),
+			"ppt/theme/theme2.xml":  syntheticThemeXML("Second", "Second"),
+		},
+	})
+	want := []string{"theme1", "theme2", "theme10"}
+
+	names, err := ListThemeNames(path)
+	if err != nil {
+		t.Fatalf("ListThemeNames() error = %v", err)
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ListThemeNames() = %v, want %v", names, want)
+	}
+
+	themes, err := ReadThemes(path)
+	if err != nil {
+		t.Fatalf("ReadThemes() error = %v", err)
+	}
+	var files []string
+	for _, theme := range themes {
+		files = append(files, theme.FileName)
+	}
+	if !reflect.DeepEqual(files, []string{"theme1.xml", "theme2.xml", "theme10.xml"}) {
+		t.Errorf("ReadThemes() order = %v", files)
+	}
+
+	err = validateThemeFilter([]string{"theme3"}, map[string]string{
+		"slideMaster1.xml": "theme10.xml",
+		"slideMaster2.xml": "theme2.xml",
+		"slideMaster3.xml": "theme1.xml",
+	})
+	if err == nil || err.Error() != "theme(s) not found: theme3\nAvailable themes: theme1, theme2, theme10" {
+		t.Errorf("validateThemeFilter() error = %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"archive/zip"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,29 +16,74 @@ type ProcessingConfig struct {
 	NewName       string   // New name for rename operations
 	Themes        []string // Theme filter or nil for all
 	Slides        []int    // Slide filter or nil for all
+	Sections      []string // Section filter or nil for none
 	SlidesMatched *int     // Number of slides matched (nil if not applicable)
 	Scope         string   // "all", "content", "master"
 }
 
-// ValidateInputFile checks if the input file exists
+// ValidateInputFile checks that the input file exists and is a ZIP package, as
+// every PowerPoint file is. Other files are reported as *ErrNotPPTX.
 func ValidateInputFile(inputFile string) error {
 	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
 		return fmt.Errorf("input file not found: %s", inputFile)
 	}
+	zipReader, err := zip.OpenReader(inputFile)
+	if err != nil {
+		return &ErrNotPPTX{Path: inputFile, Err: err}
+	}
+	zipReader.Close()
 	return nil
 }
 
+// packageExtensions are the extensions of the PowerPoint packages the tool reads.
+// PowerPoint picks how to open a file by its extension, not its content.
+var packageExtensions = map[string]bool{".pptx": true, ".potx": true, ".ppsx": true, ".thmx": true}
+
+// expectedOutputExtension returns the input's extension, lowercased, if the output
+// has a different one and would not open in PowerPoint by double-click.
+// Returns "" if the extensions match or the input's is not in packageExtensions.
+func expectedOutputExtension(inputFile, outputFile string) string {
+	inputExt := strings.ToLower(filepath.Ext(inputFile))
+	if !packageExtensions[inputExt] || strings.ToLower(filepath.Ext(outputFile)) == inputExt {
+		return ""
+	}
+	return inputExt
+}
+
+// WarnOutputExtension prints a warning, suggesting the expected file name, when the
+// output's extension differs from the input's (see expectedOutputExtension). The
+// output is still written: the extension may be deliberate.
+func WarnOutputExtension(cmd *cobra.Command, inputFile, outputFile string) {
+	ext := expectedOutputExtension(inputFile, outputFile)
+	if ext == "" {
+		return
+	}
+	suggested := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ext
+	cmd.PrintErrf("Warning: output '%s' does not end in %s like the input; PowerPoint may not open it by double-click (did you mean '%s'?)\n", outputFile, ext, suggested)
+}
+
 // PromptOverwrite prompts the user if the output file already exists
-// Returns true if user wants to overwrite, false if aborted
+// Returns true if user wants to overwrite, false if aborted. With --no-prompt an
+// existing output is refused instead: the error is printed and a non-nil error
+// returned. With --yes it is overwritten without asking; --no-prompt wins if both
+// are given. Commands end with ExitAborted when the output is not overwritten.
 func PromptOverwrite(cmd *cobra.Command, outputFile string) (bool, error) {
 	if _, err := os.Stat(outputFile); err == nil {
+		switch {
+		case noPrompt:
+			cmd.PrintErrf("Error: output file '%s' already exists (not overwriting with --no-prompt)\n", outputFile)
+			return false, exitWith(ExitAborted)
+		case assumeYes:
+			return true, nil
+		}
+
 		// File exists, prompt for overwrite
-		cmd.Printf("Output file '%s' already exists. Overwrite? (y/n): ", outputFile)
+		cmd.PrintErrf("Output file '%s' already exists. Overwrite? (y/n): ", outputFile)
 		var response string
 		fmt.Scanln(&response)
 		response = strings.ToLower(strings.TrimSpace(response))
 		if response != "y" && response != "yes" {
-			cmd.Println("Aborted.")
+			cmd.PrintErrln("Aborted.")
 			return false, nil
 		}
 	}
@@ -68,6 +115,9 @@ func PrintProcessingHeader(cmd *cobra.Command, inputFile string, config Processi
 	if len(config.Slides) > 0 {
 		cmd.Printf("Slides: %s\n", formatSlides(config.Slides))
 	}
+	if len(config.Sections) > 0 {
+		cmd.Printf("Sections: %s\n", strings.Join(config.Sections, ", "))
+	}
 
 	// Print scope (only when not default "all")
 	if config.Scope != "" && config.Scope != "all" {
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestExpectedOutputExtension(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		want   string
+	}{
+		{"same extension", "deck.pptx", "out.pptx", ""},
+		{"case-insensitive", "deck.PPTX", "out.pptx", ""},
+		{"zip output", "deck.pptx", "out.zip", ".pptx"},
+		{"no output extension", "deck.pptx", "out", ".pptx"},
+		{"template", "brand.potx", "brand-new.pptx", ".potx"},
+		{"slide show", "talk.ppsx", "talk.zip", ".ppsx"},
+		{"theme", "office.thmx", "office.xml", ".thmx"},
+		{"uppercase input suggests lowercase", "DECK.PPTX", "OUT.ZIP", ".pptx"},
+		{"other input type", "deck.zip", "out.pptx", ""},
+		{"directories in path", "in/deck.pptx", "out.v2/deck", ".pptx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedOutputExtension(tt.input, tt.output); got != tt.want {
+				t.Errorf("expectedOutputExtension(%q, %q) = %q, want %q", tt.input, tt.output, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Package is a presentation opened for several operations, for callers that would
+// otherwise pay for ReadThemes, ProcessPPTX, and RenameColorScheme each re-opening
+// the archive. It is extracted once; operations edit the extraction in place and
+// Save writes the result. A Package is not safe for concurrent use, and Close must
+// be called to remove the extraction.
+//
+// An operation that fails may leave parts it already edited; open the file again to
+// start over.
+type Package struct {
+	path    string          // Input PPTX file, the source of entries Save copies verbatim
+	tempDir string          // Extraction, empty once closed
+	themes  []*Theme        // Parsed themes, nil until read and after each edit
+	changed map[string]bool // Archive paths of the parts edited so far
+}
+
+// OpenPackage extracts a PPTX file for use with the Package methods
+func OpenPackage(pptxPath string) (*Package, error) {
+	if _, err := os.Stat(pptxPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file not found: %s", pptxPath)
+	}
+
+	tempDir, err := newTempDir("")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := extractPPTX(pptxPath, tempDir); err != nil {
+		removeTemp(tempDir)
+		return nil, err
+	}
+
+	return &Package{path: pptxPath, tempDir: tempDir, changed: make(map[string]bool)}, nil
+}
+
+// open returns the extraction, or an error if the package was closed
+func (p *Package) open() (string, error) {
+	if p.tempDir == "" {
+		return "", fmt.Errorf("package is closed: %s", p.path)
+	}
+	return p.tempDir, nil
+}
+
+// Themes returns the themes of the presentation as ReadThemes does, including any
+// edits made so far. The result is cached until the next edit.
+func (p *Package) Themes() ([]*Theme, error) {
+	tempDir, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.themes == nil {
+		if p.themes, err = ReadThemesDir(tempDir); err != nil {
+			return nil, err
+		}
+	}
+	return p.themes, nil
+}
+
+// Swap replaces color references as ProcessPPTXWithOptions does
+// Returns: filesProcessed, matchedSlides (nil if not applicable), error
+func (p *Package) Swap(colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int, opts Options) (int, *int, error) {
+	tempDir, err := p.open()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	opts, err = checkSwapOptions(scope, slideFilter, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	p.themes = nil
+	filesProcessed, matchedSlides, changed, err := swapExtracted(tempDir, colorMapping, themeFilter, scope, slideFilter, opts)
+	p.markChanged(changed)
+	return filesProcessed, matchedSlides, err
+}
+
+// Rename renames colour schemes as RenameColorSchemeOutcomes does
+func (p *Package) Rename(newName string, themeFilter []string) ([]RenameOutcome, error) {
+	tempDir, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+
+	p.themes = nil
+	outcomes, changed, err := renameExtracted(tempDir, newName, themeFilter, Options{})
+	p.markChanged(changed)
+	return outcomes, err
+}
+
+// markChanged adds parts an operation edited to those Save recompresses
+func (p *Package) markChanged(changed map[string]bool) {
+	for relPath := range changed {
+		p.changed[relPath] = true
+	}
+}
+
+// Save writes the presentation with all edits so far to outputPath. Edited parts
+// are recompressed; all other entries are copied verbatim from the input file.
+func (p *Package) Save(outputPath string) error {
+	tempDir, err := p.open()
+	if err != nil {
+		return err
+	}
+
+	return writePPTX(p.path, outputPath, tempDir, p.changed)
+}
+
+// Close removes the extraction. The package cannot be used afterwards.
+func (p *Package) Close() error {
+	if p.tempDir == "" {
+		return nil
+	}
+	err := removeTemp(p.tempDir)
+	p.tempDir = ""
+	p.themes = nil
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPackage(t *testing.T) {
+	inputPath := filepath.Join("testdata", "test.pptx")
+	mapping := map[string]string{"accent1": "accent3", "accent2": "FF0000"}
+
+	// The same edits through the path-based functions
+	dir := t.TempDir()
+	swapped := filepath.Join(dir, "swapped.pptx")
+	want := filepath.Join(dir, "want.pptx")
+	if _, _, err := ProcessPPTX(inputPath, swapped, mapping, []string{"theme1"}, "all", nil); err != nil {
+		t.Fatalf("ProcessPPTX() error = %v", err)
+	}
+	if _, err := RenameColorScheme(swapped, want, "Rebranded", []string{"theme1"}); err != nil {
+		t.Fatalf("RenameColorScheme() error = %v", err)
+	}
+
+	pkg, err := OpenPackage(inputPath)
+	if err != nil {
+		t.Fatalf("OpenPackage() error = %v", err)
+	}
+	defer pkg.Close()
+
+	themes, err := pkg.Themes()
+	if err != nil {
+		t.Fatalf("Themes() error = %v", err)
+	}
+	readThemes, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatalf("ReadThemes() error = %v", err)
+	}
+	if !reflect.DeepEqual(themes, readThemes) {
+		t.Errorf("Themes() differs from ReadThemes()")
+	}
+
+	if _, _, err := pkg.Swap(mapping, []string{"theme1"}, "all", nil, Options{}); err != nil {
+		t.Fatalf("Swap() error = %v", err)
+	}
+	if _, err := pkg.Rename("Rebranded", []string{"theme1"}); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	// The cached themes must not outlive the rename
+	themes, err = pkg.Themes()
+	if err != nil {
+		t.Fatalf("Themes() error = %v", err)
+	}
+	if themes[0].ColorSchemeName != "Rebranded" {
+		t.Errorf("Themes()[0].ColorSchemeName = %q after Rename, want %q", themes[0].ColorSchemeName, "Rebranded")
+	}
+
+	got := filepath.Join(dir, "got.pptx")
+	if err := pkg.Save(got); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	gotBytes, _ := os.ReadFile(got)
+	wantBytes, _ := os.ReadFile(want)
+	if !bytes.Equal(gotBytes, wantBytes) {
+		t.Errorf("Save() output differs from ProcessPPTX followed by RenameColorScheme")
+	}
+
+	if err := pkg.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := pkg.Themes(); err == nil {
+		t.Errorf("Themes() after Close() should fail")
+	}
+}
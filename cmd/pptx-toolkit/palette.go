@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// neutralSlots are the dark and light theme slots, left alone by palette edits
+// unless asked for
+var neutralSlots = map[string]bool{"dk1": true, "lt1": true, "dk2": true, "lt2": true}
+
+// hueShiftChanges returns the slot → hex changes that rotate the hue of a theme's
+// accent and hyperlink colors by degrees, and of dk1, lt1, dk2, and lt2 as well if
+// includeNeutrals is set. Saturation and lightness are kept.
+func hueShiftChanges(colors ColorScheme, degrees float64, includeNeutrals bool) map[string]string {
+	changes := make(map[string]string)
+	for _, name := range schemeColorNames {
+		if neutralSlots[name] && !includeNeutrals {
+			continue
+		}
+		hsl, ok := hexToHSL(colors.Get(name))
+		if !ok {
+			continue
+		}
+		hsl.H += degrees
+		changes[name] = hsl.Hex()
+	}
+	return changes
+}
+
+// ShiftHue rotates the hue of every theme's palette, or of those in themeFilter, by
+// degrees (see hueShiftChanges).
+// Only theme definitions change; references follow the new colors. Returns the
+// number of themes processed.
+func ShiftHue(inputPath, outputPath string, degrees float64, includeNeutrals bool, themeFilter []string) (int, error) {
+	return rewriteThemeColors(inputPath, outputPath, themeFilter, func(colors ColorScheme) map[string]string {
+		return hueShiftChanges(colors, degrees, includeNeutrals)
+	})
+}
+
+// adjustChanges returns the slot → hex changes that shift the lightness and
+// saturation of every color in a theme by the given percentage points (e.g., 10 for
+// +10%), clamping at 0% and 100%. Grays stay gray. With keepBlackWhite, a dk1 of
+// pure black and an lt1 of pure white are left as they are.
+func adjustChanges(colors ColorScheme, lightness, saturation float64, keepBlackWhite bool) map[string]string {
+	changes := make(map[string]string)
+	for _, name := range schemeColorNames {
+		hex := strings.ToUpper(colors.Get(name))
+		if keepBlackWhite && ((name == "dk1" && hex == "000000") || (name == "lt1" && hex == "FFFFFF")) {
+			continue
+		}
+		hsl, ok := hexToHSL(hex)
+		if !ok {
+			continue
+		}
+		hsl.L = clamp01(hsl.L + lightness/100)
+		if hsl.S > 0 {
+			// Grays have no hue, so saturating them would tint them red
+			hsl.S = clamp01(hsl.S + saturation/100)
+		}
+		changes[name] = hsl.Hex()
+	}
+	return changes
+}
+
+// AdjustPalette shifts the lightness and saturation of every theme's palette, or of
+// those in themeFilter (see adjustChanges). Only theme definitions change; references follow the new colors.
+// Returns the number of themes processed.
+func AdjustPalette(inputPath, outputPath string, lightness, saturation float64, keepBlackWhite bool, themeFilter []string) (int, error) {
+	return rewriteThemeColors(inputPath, outputPath, themeFilter, func(colors ColorScheme) map[string]string {
+		return adjustChanges(colors, lightness, saturation, keepBlackWhite)
+	})
+}
+
+// LoadPaletteFile reads a palette of exactly six hex colors, for accent1 to accent6 in
+// order. Colors may be separated by newlines, commas, or spaces, and may carry a
+// leading '#' (e.g., #1F4E79). Anything after a '#' that does not start a hex color
+// is a comment, as are blank lines. Colors are returned uppercased.
+func LoadPaletteFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read palette: %w", err)
+	}
+	defer file.Close()
+
+	var palette []string
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		for _, field := range fields {
+			color := strings.TrimPrefix(field, "#")
+			if !isValidHexColor(color) {
+				if strings.HasPrefix(field, "#") {
+					break // Comment runs to the end of the line
+				}
+				return nil, fmt.Errorf("%s:%d: invalid hex color '%s'", path, lineNum, field)
+			}
+			palette = append(palette, strings.ToUpper(color))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read palette: %w", err)
+	}
+
+	if len(palette) != len(accentSlots) {
+		return nil, fmt.Errorf("palette %s has %d color(s); expected %d, one for each of accent1-accent6", path, len(palette), len(accentSlots))
+	}
+
+	return palette, nil
+}
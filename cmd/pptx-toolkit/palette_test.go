@@ -0,0 +1,339 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHueShiftChanges(t *testing.T) {
+	colors := ColorScheme{
+		Dk1: "000000", Lt1: "FFFFFF", Dk2: "1F497D", Lt2: "EEECE1",
+		Accent1: "FF0000", Accent2: "00FF00", Accent3: "0000FF", Accent4: "808080",
+		Accent5: "FF0000", Accent6: "FF0000", Hlink: "FF0000", FolHlink: "FF0000",
+	}
+
+	tests := []struct {
+		name            string
+		degrees         float64
+		includeNeutrals bool
+		want            map[string]string
+	}{
+		{
+			name:    "accents only",
+			degrees: 120,
+			want: map[string]string{
+				"accent1": "00FF00", "accent2": "0000FF", "accent3": "FF0000", "accent4": "808080",
+				"accent5": "00FF00", "accent6": "00FF00", "hlink": "00FF00", "folHlink": "00FF00",
+			},
+		},
+		{
+			name:            "with neutrals",
+			degrees:         -120,
+			includeNeutrals: true,
+			want: map[string]string{
+				"dk1": "000000", "lt1": "FFFFFF", "dk2": "497D1F", "lt2": "ECE1EE",
+				"accent1": "0000FF", "accent2": "FF0000", "accent3": "00FF00", "accent4": "808080",
+				"accent5": "0000FF", "accent6": "0000FF", "hlink": "0000FF", "folHlink": "0000FF",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hueShiftChanges(colors, tt.degrees, tt.includeNeutrals)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hueShiftChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShiftHue(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	themesProcessed, err := ShiftHue(inputPath, outputPath, 360, false, nil)
+	if err != nil {
+		t.Fatalf("ShiftHue() error = %v", err)
+	}
+	if themesProcessed != 1 {
+		t.Errorf("themes processed = %d, want 1", themesProcessed)
+	}
+
+	before, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before[0].Colors != after[0].Colors {
+		t.Errorf("a full turn should keep the palette: got %+v, want %+v", after[0].Colors, before[0].Colors)
+	}
+
+	outputPath = filepath.Join(t.TempDir(), "output.pptx")
+	if _, err := ShiftHue(inputPath, outputPath, 180, false, nil); err != nil {
+		t.Fatalf("ShiftHue() error = %v", err)
+	}
+	after, err = ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after[0].Colors.Accent1 == before[0].Colors.Accent1 {
+		t.Error("accent1 should change with a 180° shift")
+	}
+	if after[0].Colors.Dk2 != before[0].Colors.Dk2 {
+		t.Error("dk2 should be kept without includeNeutrals")
+	}
+}
+
+func TestAdjustChanges(t *testing.T) {
+	colors := ColorScheme{
+		Dk1: "000000", Lt1: "FFFFFF", Dk2: "1F497D", Lt2: "EEECE1",
+		Accent1: "FF0000", Accent2: "808080", Accent3: "0000FF", Accent4: "FF0000",
+		Accent5: "FF0000", Accent6: "FF0000", Hlink: "FF0000", FolHlink: "FF0000",
+	}
+
+	tests := []struct {
+		name           string
+		lightness      float64
+		saturation     float64
+		keepBlackWhite bool
+		check          map[string]string
+		skipped        []string
+	}{
+		{
+			name:      "lighten",
+			lightness: 25,
+			check:     map[string]string{"accent1": "FF8080", "accent2": "C0C0C0", "dk1": "404040", "lt1": "FFFFFF"},
+		},
+		{
+			name:      "clamps at white",
+			lightness: 200,
+			check:     map[string]string{"accent1": "FFFFFF", "dk1": "FFFFFF", "dk2": "FFFFFF"},
+		},
+		{
+			name:      "clamps at black",
+			lightness: -200,
+			check:     map[string]string{"accent1": "000000", "lt1": "000000", "lt2": "000000"},
+		},
+		{
+			name:       "desaturate fully",
+			saturation: -100,
+			check:      map[string]string{"accent1": "808080", "accent3": "808080", "accent2": "808080"},
+		},
+		{
+			name:       "saturation clamps at 100%",
+			saturation: 500,
+			check:      map[string]string{"accent1": "FF0000", "accent2": "808080"}, // Grays have no hue to saturate
+		},
+		{
+			name:           "keep black and white",
+			lightness:      10,
+			keepBlackWhite: true,
+			check:          map[string]string{"accent1": "FF3333"},
+			skipped:        []string{"dk1", "lt1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adjustChanges(colors, tt.lightness, tt.saturation, tt.keepBlackWhite)
+			for slot, want := range tt.check {
+				if got[slot] != want {
+					t.Errorf("%s = %q, want %q", slot, got[slot], want)
+				}
+			}
+			for _, slot := range tt.skipped {
+				if value, changed := got[slot]; changed {
+					t.Errorf("%s should be left alone, got %q", slot, value)
+				}
+			}
+		})
+	}
+}
+
+func TestAdjustPalette(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	if _, err := AdjustPalette(inputPath, outputPath, -10, 0, true, nil); err != nil {
+		t.Fatalf("AdjustPalette() error = %v", err)
+	}
+
+	themes, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colors := themes[0].Colors
+	if colors.Lt1 != "FFFFFF" {
+		t.Errorf("lt1 = %s, want FFFFFF kept", colors.Lt1)
+	}
+	before, _ := hexToHSL("4F81BD")
+	after, _ := hexToHSL(colors.Accent1)
+	if diff := before.L - after.L; diff < 0.09 || diff > 0.11 {
+		t.Errorf("accent1 lightness changed by %.3f, want about 0.10", diff)
+	}
+}
+
+func TestPaletteThemeFilter(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("Test file not found")
+	}
+
+	before, err := ReadThemes(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(outputPath string, themeFilter []string) error
+	}{
+		{name: "hue-shift", edit: func(outputPath string, themeFilter []string) error {
+			_, err := ShiftHue(testPPTX, outputPath, 90, false, themeFilter)
+			return err
+		}},
+		{name: "adjust", edit: func(outputPath string, themeFilter []string) error {
+			_, err := AdjustPalette(testPPTX, outputPath, 10, 0, false, themeFilter)
+			return err
+		}},
+		{name: "grayscale", edit: func(outputPath string, themeFilter []string) error {
+			return Grayscale(testPPTX, outputPath, "all", true, themeFilter)
+		}},
+		{name: "apply-palette", edit: func(outputPath string, themeFilter []string) error {
+			_, err := MapAccentsToPalette(testPPTX, outputPath, []string{"123457", "123458", "123459", "12345A", "12345B", "12345C"}, themeFilter)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if err := tt.edit(outputPath, []string{"theme2"}); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+
+			after, err := ReadThemes(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, theme := range after {
+				changed := theme.Colors.Accent1 != before[i].Colors.Accent1
+				if changed != (theme.FileName == "theme2.xml") {
+					t.Errorf("%s: accent1 changed = %v", theme.FileName, changed)
+				}
+			}
+
+			if err := tt.edit(filepath.Join(t.TempDir(), "output.pptx"), []string{"theme9"}); err == nil {
+				t.Error("expected error for unknown theme")
+			}
+		})
+	}
+}
+
+func TestLoadPaletteFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "one per line with comments",
+			content: "# Brand palette\n#1f4e79  # accent1\nC0504D\n\n9BBB59\n8064A2\n4BACC6\nF79646\n",
+			want:    []string{"1F4E79", "C0504D", "9BBB59", "8064A2", "4BACC6", "F79646"},
+		},
+		{
+			name:    "comma separated",
+			content: "1F4E79, C0504D,9BBB59 8064A2\t4BACC6,F79646",
+			want:    []string{"1F4E79", "C0504D", "9BBB59", "8064A2", "4BACC6", "F79646"},
+		},
+		{
+			name:    "too few colors",
+			content: "1F4E79\nC0504D\n",
+			wantErr: true,
+		},
+		{
+			name:    "too many colors",
+			content: "1F4E79 C0504D 9BBB59 8064A2 4BACC6 F79646 000000",
+			wantErr: true,
+		},
+		{
+			name:    "invalid color",
+			content: "1F4E79 C0504D 9BBB59 8064A2 4BACC6 orange",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "palette.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadPaletteFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPaletteFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadPaletteFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPaletteRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	palettePath := filepath.Join(dir, "palette.txt")
+	if err := os.WriteFile(palettePath, []byte("#1F4E79\n#C0504D\n#9BBB59\n#8064A2\n#4BACC6\n#F79646\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	palette, err := LoadPaletteFile(palettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := filepath.Join(dir, "output.pptx")
+	if _, err := MapAccentsToPalette(inputPath, outputPath, palette, nil); err != nil {
+		t.Fatalf("MapAccentsToPalette() error = %v", err)
+	}
+
+	before, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, slot := range accentSlots {
+		if got := after[0].Colors.Get(slot); got != palette[i] {
+			t.Errorf("%s = %s, want %s", slot, got, palette[i])
+		}
+	}
+	for _, slot := range []string{"dk1", "lt1", "dk2", "lt2", "hlink", "folHlink"} {
+		if got, want := after[0].Colors.Get(slot), before[0].Colors.Get(slot); got != want {
+			t.Errorf("%s = %s, want it unchanged (%s)", slot, got, want)
+		}
+	}
+
+	// Applying the same palette again changes nothing
+	againPath := filepath.Join(dir, "again.pptx")
+	if _, err := MapAccentsToPalette(outputPath, againPath, palette, nil); err != nil {
+		t.Fatalf("MapAccentsToPalette() error = %v", err)
+	}
+	again, err := ReadThemes(againPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].Colors != after[0].Colors {
+		t.Errorf("reapplying the palette changed the colors: %+v, want %+v", again[0].Colors, after[0].Colors)
+	}
+}
@@ -26,11 +26,31 @@ var ValidSchemeColors = map[string]bool{
 // hexColorPattern matches 6-character hex color codes (case-insensitive)
 var hexColorPattern = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
 
+// letterHexPattern matches hex colors written only with the letters A-F
+var letterHexPattern = regexp.MustCompile(`^[A-Fa-f]{6}$`)
+
 // isValidHexColor checks if a string is a valid 6-character hex color
 func isValidHexColor(color string) bool {
 	return hexColorPattern.MatchString(color)
 }
 
+// canonicalSchemeColor returns the canonical spelling of a scheme color name or
+// color map alias matched case-insensitively (e.g., "ACCENT1" → "accent1",
+// "folhlink" → "folHlink"). Returns false if the name is neither.
+func canonicalSchemeColor(name string) (string, bool) {
+	for color := range ValidSchemeColors {
+		if strings.EqualFold(name, color) {
+			return color, true
+		}
+	}
+	for alias := range colorMapAliases {
+		if strings.EqualFold(name, alias) {
+			return alias, true
+		}
+	}
+	return "", false
+}
+
 // isValidColor checks if a color is either a valid scheme color or hex color
 func isValidColor(color string) bool {
 	return ValidSchemeColors[color] || isValidHexColor(color)
@@ -39,6 +59,8 @@ func isValidColor(color string) bool {
 // ParseColorMapping parses a color mapping string into a validated map.
 //
 // Supports both scheme colors (e.g., accent1, dk1) and hex colors (e.g., AABBCC, FF0000).
+// Sources may also be color map aliases (bg1, tx1, bg2, tx2). Scheme names are
+// matched case-insensitively and returned in their canonical case (e.g., "Accent1" → "accent1").
 //
 // Examples:
 //   - "accent1:accent3,accent5:accent3" -> scheme to scheme
@@ -51,74 +73,139 @@ func isValidColor(color string) bool {
 // - Format is invalid
 // - Color values are invalid (not a scheme color or valid 6-digit hex)
 // - Conflicting mappings exist (e.g., accent1:accent3,accent1:accent2)
+//
+// Invalid colors are reported as *ErrInvalidColor and conflicts as *ErrConflictingMapping.
 func ParseColorMapping(mappingStr string) (map[string]string, error) {
+	mappings, _, err := parseColorMapping(mappingStr)
+	return mappings, err
+}
+
+// parseColorMapping is ParseColorMapping, also returning notices about how the input
+// was interpreted, for display in verbose mode
+func parseColorMapping(mappingStr string) (map[string]string, []string, error) {
+	var notices []string
+	noticed := make(map[string]bool)
+
 	mappingStr = strings.TrimSpace(mappingStr)
 	if mappingStr == "" {
-		return nil, fmt.Errorf("mapping string cannot be empty")
+		return nil, nil, fmt.Errorf("mapping string cannot be empty")
 	}
 
 	mappings := make(map[string]string)
 	pairs := strings.Split(mappingStr, ",")
 
-	for _, pair := range pairs {
+	for i, pair := range pairs {
 		pair = strings.TrimSpace(pair)
 		if pair == "" {
+			// Tolerated, but a stray comma may hide a mapping the user meant to write
+			notices = append(notices, fmt.Sprintf("skipped empty mapping segment %d of %d (stray comma?)", i+1, len(pairs)))
 			continue
 		}
 
 		if !strings.Contains(pair, ":") {
-			return nil, fmt.Errorf("invalid mapping format: '%s'. Expected 'source:target'", pair)
+			return nil, nil, fmt.Errorf("invalid mapping format: '%s'. Expected 'source:target'", pair)
 		}
 
 		parts := strings.Split(pair, ":")
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid mapping format: '%s'. Expected exactly one ':'", pair)
+			return nil, nil, fmt.Errorf("invalid mapping format: '%s'. Expected exactly one ':'", pair)
 		}
 
 		source := strings.TrimSpace(parts[0])
 		target := strings.TrimSpace(parts[1])
 
 		if source == "" || target == "" {
-			return nil, fmt.Errorf("invalid mapping: '%s'. Source and target cannot be empty", pair)
+			return nil, nil, fmt.Errorf("invalid mapping: '%s'. Source and target cannot be empty", pair)
 		}
 
-		// Validate colors (scheme names or hex values)
-		if !isValidColor(source) {
-			if isValidHexColor(source) {
-				// Already valid hex, shouldn't reach here
-				return nil, fmt.Errorf("internal error validating source color: '%s'", source)
+		// Scheme names are fixed-case in PowerPoint; accept any casing.
+		// No scheme name is six hex digits, so hex values are never affected.
+		if canonical, ok := canonicalSchemeColor(source); ok {
+			source = canonical
+		}
+		if canonical, ok := canonicalSchemeColor(target); ok {
+			target = canonical
+		}
+
+		// A hex value spelled only with letters (e.g., "facade") may have been meant as a name
+		for _, color := range []string{source, target} {
+			if letterHexPattern.MatchString(color) && !noticed[color] {
+				noticed[color] = true
+				notices = append(notices, fmt.Sprintf("treating '%s' as hex color", color))
 			}
-			return nil, fmt.Errorf("invalid source color: '%s'. Must be a valid scheme color (%s) or 6-digit hex color (e.g., AABBCC)",
-				source, getValidColorsString())
+		}
+
+		// Validate colors (scheme names or hex values); sources may also be
+		// background/text aliases (bg1, tx1, bg2, tx2) resolved per slide
+		if !isValidColor(source) && !colorMapAliases[source] {
+			return nil, nil, &ErrInvalidColor{Color: source, Side: "source"}
 		}
 
 		if !isValidColor(target) {
-			if isValidHexColor(target) {
-				// Already valid hex, shouldn't reach here
-				return nil, fmt.Errorf("internal error validating target color: '%s'", target)
-			}
-			return nil, fmt.Errorf("invalid target color: '%s'. Must be a valid scheme color (%s) or 6-digit hex color (e.g., AABBCC)",
-				target, getValidColorsString())
+			return nil, nil, &ErrInvalidColor{Color: target, Side: "target"}
 		}
 
 		// Check for conflicts
 		if existingTarget, exists := mappings[source]; exists {
 			if existingTarget != target {
-				return nil, fmt.Errorf("conflicting mappings for '%s':\n  - %s → %s\n  - %s → %s",
-					source, source, existingTarget, source, target)
+				return nil, nil, &ErrConflictingMapping{Source: source, A: existingTarget, B: target}
 			}
 			// Duplicate identical mapping, skip
 			continue
 		}
 
+		// Kept for compatibility, but a target left unchanged is often a copy-paste slip
+		if isIdentityMapping(source, target) {
+			notices = append(notices, fmt.Sprintf("mapping '%s' maps %s to itself and changes nothing", pair, source))
+		}
+
 		mappings[source] = target
 	}
 
 	if len(mappings) == 0 {
-		return nil, fmt.Errorf("no valid mappings found")
+		return nil, nil, fmt.Errorf("no valid mappings found")
+	}
+
+	return mappings, notices, nil
+}
+
+// isIdentityMapping reports whether a mapping's target is its source; hex values
+// compare case-insensitively
+func isIdentityMapping(source, target string) bool {
+	return strings.EqualFold(source, target)
+}
+
+// ValidateNoIdentityMappings returns an *ErrIdentityMapping for the first mapping,
+// in sorted order, whose target is its source
+func ValidateNoIdentityMappings(mapping map[string]string) error {
+	sources := make([]string, 0, len(mapping))
+	for source := range mapping {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		if isIdentityMapping(source, mapping[source]) {
+			return &ErrIdentityMapping{Source: source, Target: mapping[source]}
+		}
 	}
+	return nil
+}
 
-	return mappings, nil
+// ParseFallbackColor validates the color given to --map-unmatched-to: a scheme color
+// (any casing) or a hex value, which is uppercased
+func ParseFallbackColor(color string) (string, error) {
+	color = strings.TrimSpace(color)
+	if canonical, ok := canonicalSchemeColor(color); ok {
+		color = canonical
+	}
+	if isValidHexColor(color) {
+		color = strings.ToUpper(color)
+	}
+	if !isValidColor(color) {
+		return "", &ErrInvalidColor{Color: color, Side: "fallback"}
+	}
+	return color, nil
 }
 
 // getValidColorsString returns a sorted, comma-separated string of valid color names
@@ -130,3 +217,138 @@ func getValidColorsString() string {
 	sort.Strings(colors)
 	return strings.Join(colors, ", ")
 }
+
+// ParseThemeMapping parses a theme-scoped mapping of the form "theme=mapping"
+// (e.g., "theme1=accent1:FF0000,accent2:00FF00"). The theme may be given with or
+// without its extension; it is returned as a file name (e.g., "theme1.xml").
+func ParseThemeMapping(value string) (string, map[string]string, error) {
+	theme, mapping, _, err := parseThemeMapping(value)
+	return theme, mapping, err
+}
+
+// parseThemeMapping is ParseThemeMapping, also returning the mapping's parser notices
+func parseThemeMapping(value string) (string, map[string]string, []string, error) {
+	theme, mappingStr, found := strings.Cut(value, "=")
+	theme = strings.TrimSpace(theme)
+	if !found || theme == "" {
+		return "", nil, nil, fmt.Errorf("invalid theme mapping: '%s'. Expected 'theme=source:target'", value)
+	}
+
+	mapping, notices, err := parseColorMapping(mappingStr)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("theme mapping for %s: %w", theme, err)
+	}
+
+	if !strings.HasSuffix(theme, ".xml") {
+		theme += ".xml"
+	}
+
+	return theme, mapping, notices, nil
+}
+
+// MappingPair is one source → target entry of a mapping
+type MappingPair struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// String formats the pair as shown in processing output (e.g., "accent1→FF0000")
+func (p MappingPair) String() string {
+	return p.Source + "→" + p.Target
+}
+
+// EffectiveMapping returns the mapping actually applied to parts governed by a theme:
+// the general mapping overlaid with the theme's mapping (nil if none), in canonical
+// form. Hex sources are uppercased, since they are matched case-insensitively; so are
+// hex targets, as they are written, unless preserveCase is set (see ReplaceOptions).
+// Pairs are sorted with scheme sources first, then hex sources, each naturally.
+func EffectiveMapping(general, theme map[string]string, preserveCase bool) []MappingPair {
+	merged := make(map[string]string, len(general)+len(theme))
+	for _, mapping := range []map[string]string{general, theme} {
+		for source, target := range mapping {
+			if isValidHexColor(source) {
+				source = strings.ToUpper(source)
+			}
+			if isValidHexColor(target) && !preserveCase {
+				target = strings.ToUpper(target)
+			}
+			merged[source] = target
+		}
+	}
+
+	pairs := make([]MappingPair, 0, len(merged))
+	for source, target := range merged {
+		pairs = append(pairs, MappingPair{Source: source, Target: target})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		iHex, jHex := isValidHexColor(pairs[i].Source), isValidHexColor(pairs[j].Source)
+		if iHex != jHex {
+			return !iHex
+		}
+		return naturalLess(pairs[i].Source, pairs[j].Source)
+	})
+	return pairs
+}
+
+// MappingChain is a mapping whose target is itself mapped, by a mapping whose source
+// is the other kind of color (scheme vs hex), e.g. accent1:AABBCC with AABBCC:accent2.
+// Replacements don't cascade, so each reference changes once: accent1 becomes AABBCC.
+type MappingChain struct {
+	Source string // The first mapping's source
+	Via    string // The first mapping's target, also the second mapping's source
+	Target string // The second mapping's target
+}
+
+// Cycle reports whether the chain leads back to its source, swapping the two colors
+func (c MappingChain) Cycle() bool {
+	return strings.EqualFold(c.Source, c.Target)
+}
+
+// String explains the chain's effect
+func (c MappingChain) String() string {
+	if c.Cycle() {
+		return fmt.Sprintf("%s:%s and %s:%s swap the two colors; each reference changes once",
+			c.Source, c.Via, c.Via, c.Target)
+	}
+	return fmt.Sprintf("%s:%s and %s:%s chain: references to %s become %s, not %s (replacements don't cascade)",
+		c.Source, c.Via, c.Via, c.Target, c.Source, c.Via, c.Target)
+}
+
+// FindCrossTypeChains returns the chains (see MappingChain) in the mapping applied
+// under a theme (see EffectiveMapping), in EffectiveMapping's order. A cycle is
+// reported once, from its scheme color. Chains between two scheme or two hex
+// mappings are not reported.
+func FindCrossTypeChains(general, theme map[string]string) []MappingChain {
+	pairs := EffectiveMapping(general, theme, false)
+	targets := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		targets[pair.Source] = pair.Target
+	}
+
+	var chains []MappingChain
+	for _, pair := range pairs {
+		next, ok := targets[pair.Target]
+		if !ok || isValidHexColor(pair.Source) == isValidHexColor(pair.Target) {
+			continue
+		}
+		chain := MappingChain{Source: pair.Source, Via: pair.Target, Target: next}
+		if chain.Cycle() && isValidHexColor(pair.Source) {
+			continue
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// formatMappingPairs joins pairs for display (e.g., "accent1→FF0000, AABBCC→accent2"),
+// or returns "(none)" if there are none
+func formatMappingPairs(pairs []MappingPair) string {
+	if len(pairs) == 0 {
+		return "(none)"
+	}
+	strs := make([]string, len(pairs))
+	for i, pair := range pairs {
+		strs[i] = pair.String()
+	}
+	return strings.Join(strs, ", ")
+}
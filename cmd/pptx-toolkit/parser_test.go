@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -59,6 +61,29 @@ func TestParseColorMapping_Valid(t *testing.T) {
 				"accent1": "accent3",
 			},
 		},
+		{
+			name:  "mixed-case scheme names",
+			input: "Accent1:ACCENT3,HLINK:folhlink,Dk1:ffeedd",
+			expected: map[string]string{
+				"accent1": "accent3",
+				"hlink":   "folHlink",
+				"dk1":     "ffeedd",
+			},
+		},
+		{
+			name:  "mixed-case duplicate",
+			input: "accent1:accent3,ACCENT1:Accent3",
+			expected: map[string]string{
+				"accent1": "accent3",
+			},
+		},
+		{
+			name:  "mixed-case alias",
+			input: "BG1:accent2",
+			expected: map[string]string{
+				"bg1": "accent2",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -134,6 +159,16 @@ func TestParseColorMapping_Invalid(t *testing.T) {
 			input:       "accent1:accent3,accent1:accent2",
 			errContains: "conflicting mappings",
 		},
+		{
+			name:        "conflicting mixed-case mappings",
+			input:       "accent1:accent3,Accent1:accent2",
+			errContains: "conflicting mappings",
+		},
+		{
+			name:        "misspelled scheme name",
+			input:       "Accent7:accent1",
+			errContains: "invalid source color",
+		},
 		{
 			name:        "only commas",
 			input:       ",,,",
@@ -343,3 +378,208 @@ func TestParseColorMapping_InvalidHexColors(t *testing.T) {
 		})
 	}
 }
+
+func TestParseColorMappingNotices(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"digits in hex", "accent1:FF0000", nil},
+		{"letter-only hex target", "accent1:abcdef", []string{"treating 'abcdef' as hex color"}},
+		{"letter-only hex source", "FACADE:accent2", []string{"treating 'FACADE' as hex color"}},
+		{"reported once", "accent1:DEDEDE,accent2:DEDEDE", []string{"treating 'DEDEDE' as hex color"}},
+		{"scheme names", "accent1:accent2", nil},
+		{"trailing commas", "accent1:accent3,,", []string{
+			"skipped empty mapping segment 2 of 3 (stray comma?)",
+			"skipped empty mapping segment 3 of 3 (stray comma?)",
+		}},
+		{"leading comma", ",accent1:accent3", []string{"skipped empty mapping segment 1 of 2 (stray comma?)"}},
+		{"identity mapping", "accent1:accent1,accent2:accent3", []string{"mapping 'accent1:accent1' maps accent1 to itself and changes nothing"}},
+		{"identity hex mapping", "a1b2c3:A1B2C3", []string{"mapping 'a1b2c3:A1B2C3' maps a1b2c3 to itself and changes nothing"}},
+		{"identity mapping reported once", "accent1:accent1,accent1:accent1", []string{"mapping 'accent1:accent1' maps accent1 to itself and changes nothing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, notices, err := parseColorMapping(tt.input)
+			if err != nil {
+				t.Fatalf("parseColorMapping() error = %v", err)
+			}
+			if !reflect.DeepEqual(notices, tt.want) {
+				t.Errorf("notices = %q, want %q", notices, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNoIdentityMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		want    *ErrIdentityMapping
+	}{
+		{"no identity", map[string]string{"accent1": "accent2", "AABBCC": "112233"}, nil},
+		{"scheme identity", map[string]string{"accent1": "accent2", "accent3": "accent3"}, &ErrIdentityMapping{Source: "accent3", Target: "accent3"}},
+		{"hex identity in any case", map[string]string{"aabbcc": "AABBCC"}, &ErrIdentityMapping{Source: "aabbcc", Target: "AABBCC"}},
+		{"first in sorted order", map[string]string{"accent4": "accent4", "accent2": "accent2"}, &ErrIdentityMapping{Source: "accent2", Target: "accent2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNoIdentityMappings(tt.mapping)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("ValidateNoIdentityMappings() error = %v, want nil", err)
+				}
+				return
+			}
+			var identityErr *ErrIdentityMapping
+			if !errors.As(err, &identityErr) {
+				t.Fatalf("ValidateNoIdentityMappings() error = %v, want *ErrIdentityMapping", err)
+			}
+			if *identityErr != *tt.want {
+				t.Errorf("ValidateNoIdentityMappings() = %+v, want %+v", *identityErr, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParseThemeMapping(t *testing.T) {
+	theme, mapping, err := ParseThemeMapping("theme1=accent1:FF0000,accent2:00FF00")
+	if err != nil {
+		t.Fatalf("ParseThemeMapping() error = %v", err)
+	}
+	if theme != "theme1.xml" || mapping["accent1"] != "FF0000" || mapping["accent2"] != "00FF00" {
+		t.Errorf("ParseThemeMapping() = %s, %v", theme, mapping)
+	}
+
+	for _, input := range []string{"accent1:FF0000", "=accent1:FF0000", "theme1=", "theme1=accent1:blue"} {
+		if _, _, err := ParseThemeMapping(input); err == nil {
+			t.Errorf("ParseThemeMapping(%q): expected error", input)
+		}
+	}
+}
+
+func TestEffectiveMapping(t *testing.T) {
+	tests := []struct {
+		name         string
+		general      map[string]string
+		theme        map[string]string
+		preserveCase bool
+		want         string
+	}{
+		{
+			name:    "hex uppercased and sorted scheme first",
+			general: map[string]string{"ff0000": "accent1", "accent2": "1cade4", "bg1": "dk1", "AABBCC": "00ff00"},
+			want:    "accent2→1CADE4, bg1→dk1, AABBCC→00FF00, FF0000→accent1",
+		},
+		{
+			name:    "theme mapping overrides general",
+			general: map[string]string{"accent1": "accent2", "aabbcc": "accent3"},
+			theme:   map[string]string{"accent1": "FF0000", "AABBCC": "accent4"},
+			want:    "accent1→FF0000, AABBCC→accent4",
+		},
+		{
+			name:         "preserve case keeps hex targets as given",
+			general:      map[string]string{"accent1": "c0ffee", "aabbcc": "00Ff00"},
+			preserveCase: true,
+			want:         "accent1→c0ffee, AABBCC→00Ff00",
+		},
+		{
+			name:    "hex targets uppercased without preserve case",
+			general: map[string]string{"accent1": "c0ffee", "aabbcc": "00Ff00"},
+			want:    "accent1→C0FFEE, AABBCC→00FF00",
+		},
+		{
+			name: "empty",
+			want: "(none)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMappingPairs(EffectiveMapping(tt.general, tt.theme, tt.preserveCase)); got != tt.want {
+				t.Errorf("EffectiveMapping() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCrossTypeChains(t *testing.T) {
+	tests := []struct {
+		name    string
+		general map[string]string
+		theme   map[string]string
+		want    []MappingChain
+	}{
+		{
+			name:    "scheme to hex and back",
+			general: map[string]string{"accent1": "AABBCC", "aabbcc": "accent1"},
+			want:    []MappingChain{{Source: "accent1", Via: "AABBCC", Target: "accent1"}},
+		},
+		{
+			name:    "hex to scheme and on to hex",
+			general: map[string]string{"AABBCC": "accent2", "accent2": "112233"},
+			want:    []MappingChain{{Source: "AABBCC", Via: "accent2", Target: "112233"}},
+		},
+		{
+			name:    "scheme to hex and on to scheme",
+			general: map[string]string{"accent1": "FF0000", "FF0000": "accent3"},
+			want:    []MappingChain{{Source: "accent1", Via: "FF0000", Target: "accent3"}},
+		},
+		{
+			name:    "chain completed by a theme mapping",
+			general: map[string]string{"accent1": "AABBCC"},
+			theme:   map[string]string{"AABBCC": "accent1"},
+			want:    []MappingChain{{Source: "accent1", Via: "AABBCC", Target: "accent1"}},
+		},
+		{name: "scheme chain within one pass", general: map[string]string{"accent1": "accent2", "accent2": "accent3"}},
+		{name: "hex chain within one pass", general: map[string]string{"AABBCC": "FF0000", "FF0000": "00FF00"}},
+		{name: "no chain", general: map[string]string{"accent1": "AABBCC", "FF0000": "accent2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindCrossTypeChains(tt.general, tt.theme)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindCrossTypeChains() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	cycle := MappingChain{Source: "accent1", Via: "AABBCC", Target: "accent1"}
+	if !cycle.Cycle() || cycle.String() != "accent1:AABBCC and AABBCC:accent1 swap the two colors; each reference changes once" {
+		t.Errorf("cycle = %v (Cycle() = %v)", cycle, cycle.Cycle())
+	}
+	chain := MappingChain{Source: "accent1", Via: "FF0000", Target: "accent3"}
+	if chain.Cycle() || chain.String() != "accent1:FF0000 and FF0000:accent3 chain: references to accent1 become FF0000, not accent3 (replacements don't cascade)" {
+		t.Errorf("chain = %v (Cycle() = %v)", chain, chain.Cycle())
+	}
+}
+
+func TestParseFallbackColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "808080", want: "808080"},
+		{input: "abcdef", want: "ABCDEF"},
+		{input: "Accent1", want: "accent1"},
+		{input: "tx1", wantErr: true},
+		{input: "gray", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFallbackColor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFallbackColor(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFallbackColor(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,32 +5,39 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/antchfx/xmlquery"
 )
 
 // buildThemeRelationships builds a mapping of slide masters to their themes
 func buildThemeRelationships(tempDir string) (map[string]string, error) {
-	mapping := make(map[string]string)
-	relsDir := filepath.Join(tempDir, "ppt", "slideMasters", "_rels")
+	return readMasterThemes(os.DirFS(tempDir), documentRoot(tempDir))
+}
 
-	if _, err := os.Stat(relsDir); os.IsNotExist(err) {
-		return mapping, nil
-	}
+// readMasterThemes builds a mapping of slide masters to their themes from the
+// master relationship parts of a package whose document root is root. fsys may
+// be an extracted package (os.DirFS) or the archive itself (zip.Reader).
+// Masters with no theme relationship, as some exporters (e.g. Keynote) write
+// them, are resolved from the theme directory (see fallbackMasterTheme).
+func readMasterThemes(fsys fs.FS, root string) (map[string]string, error) {
+	mapping := make(map[string]string)
 
-	files, err := filepath.Glob(filepath.Join(relsDir, "slideMaster*.xml.rels"))
+	relsFiles, err := fs.Glob(fsys, path.Join(rootPath(root, "slideMasters", "_rels"), "slideMaster*.xml.rels"))
 	if err != nil {
 		return mapping, err
 	}
 
-	for _, relsFile := range files {
-		masterName := strings.TrimSuffix(filepath.Base(relsFile), ".rels")
+	for _, relsFile := range relsFiles {
+		masterName := strings.TrimSuffix(path.Base(relsFile), ".rels")
 
-		file, err := os.Open(relsFile)
+		file, err := fsys.Open(relsFile)
 		if err != nil {
 			continue
 		}
@@ -52,7 +59,21 @@ func buildThemeRelationships(tempDir string) (map[string]string, error) {
 		if node != nil {
 			themeTarget := node.SelectAttr("Target")
 			// themeTarget is like "../theme/theme1.xml"
-			themeName := filepath.Base(themeTarget)
+			themeName := path.Base(themeTarget)
+			mapping[masterName] = themeName
+		}
+	}
+
+	masters, err := fs.Glob(fsys, path.Join(rootPath(root, "slideMasters"), "slideMaster*.xml"))
+	if err != nil {
+		return mapping, err
+	}
+	for _, master := range masters {
+		masterName := path.Base(master)
+		if _, ok := mapping[masterName]; ok {
+			continue
+		}
+		if themeName := fallbackMasterTheme(fsys, root, masterName); themeName != "" {
 			mapping[masterName] = themeName
 		}
 	}
@@ -60,10 +81,57 @@ func buildThemeRelationships(tempDir string) (map[string]string, error) {
 	return mapping, nil
 }
 
+// fallbackMasterTheme picks the theme of a slide master that has no theme
+// relationship: the theme with the master's number (slideMaster2.xml → theme2.xml),
+// as PowerPoint numbers them, or else the package's only theme. Returns "" if
+// neither exists.
+func fallbackMasterTheme(fsys fs.FS, root, masterName string) string {
+	themeDir := rootPath(root, "theme")
+	numbered := "theme" + strings.TrimPrefix(masterName, "slideMaster")
+	if _, err := fs.Stat(fsys, path.Join(themeDir, numbered)); err == nil {
+		return numbered
+	}
+
+	themes, _ := fs.Glob(fsys, path.Join(themeDir, "theme*.xml"))
+	if len(themes) == 1 {
+		return path.Base(themes[0])
+	}
+	return ""
+}
+
+// checkThemeFilter validates theme filters against a PPTX file as validateThemeFilter
+// does, reading only the slide master relationships from the archive, so a mistyped
+// theme fails before a large package is extracted
+func checkThemeFilter(pptxPath string, themeFilters ...[]string) error {
+	var themes []string
+	for _, filter := range themeFilters {
+		themes = append(themes, filter...)
+	}
+	if len(themes) == 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(pptxPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", pptxPath)
+	}
+
+	zipReader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return fmt.Errorf("failed to open PPTX: %w", err)
+	}
+	defer zipReader.Close()
+
+	masterToTheme, err := readMasterThemes(&zipReader.Reader, zipDocumentRoot(zipReader.File))
+	if err != nil {
+		return err
+	}
+	return validateThemeFilter(themes, masterToTheme)
+}
+
 // buildLayoutToMasterMapping builds a mapping of slide layouts to their masters
 func buildLayoutToMasterMapping(tempDir string) (map[string]string, error) {
 	mapping := make(map[string]string)
-	relsDir := filepath.Join(tempDir, "ppt", "slideLayouts", "_rels")
+	relsDir := docPath(tempDir, "slideLayouts", "_rels")
 
 	if _, err := os.Stat(relsDir); os.IsNotExist(err) {
 		return mapping, nil
@@ -144,21 +212,38 @@ func filterSlidesByTheme(tempDir string, slideNums []int, themeFilter []string,
 
 // getSlideTheme determines which theme a slide uses
 func getSlideTheme(slidePath string, layoutToMaster, masterToTheme map[string]string) (string, error) {
+	masterName := getSlideMaster(slidePath, layoutToMaster)
+	if masterName == "" {
+		return "", nil
+	}
+
+	// Find theme for this master
+	themeName, exists := masterToTheme[masterName]
+	if !exists {
+		return "", nil
+	}
+
+	return themeName, nil
+}
+
+// getSlideMaster resolves the slide master file a slide uses through its layout,
+// or "" if the chain cannot be followed
+func getSlideMaster(slidePath string, layoutToMaster map[string]string) string {
 	slideName := filepath.Base(slidePath)
 	relsFile := filepath.Join(filepath.Dir(slidePath), "_rels", slideName+".rels")
 
 	if _, err := os.Stat(relsFile); os.IsNotExist(err) {
-		return "", nil
+		return ""
 	}
 
 	file, err := os.Open(relsFile)
 	if err != nil {
-		return "", nil
+		return ""
 	}
 	doc, err := xmlquery.Parse(file)
 	file.Close()
 	if err != nil {
-		return "", nil
+		return ""
 	}
 
 	// Find slideLayout relationship
@@ -166,7 +251,7 @@ func getSlideTheme(slidePath string, layoutToMaster, masterToTheme map[string]st
 	node := xmlquery.FindOne(doc, xpath)
 
 	if node == nil {
-		return "", nil
+		return ""
 	}
 
 	layoutTarget := node.SelectAttr("Target")
@@ -174,18 +259,7 @@ func getSlideTheme(slidePath string, layoutToMaster, masterToTheme map[string]st
 	layoutName := filepath.Base(layoutTarget)
 
 	// Find master for this layout
-	masterName, exists := layoutToMaster[layoutName]
-	if !exists {
-		return "", nil
-	}
-
-	// Find theme for this master
-	themeName, exists := masterToTheme[masterName]
-	if !exists {
-		return "", nil
-	}
-
-	return themeName, nil
+	return layoutToMaster[layoutName]
 }
 
 // shouldProcessFile determines if a file should be processed based on theme filter
@@ -212,9 +286,10 @@ func shouldProcessFile(filePath, tempDir string, themeFilter []string,
 	}
 
 	relPath = filepath.ToSlash(relPath)
+	root := documentRoot(tempDir)
 
 	// For slides, check which theme they use
-	if strings.HasPrefix(relPath, "ppt/slides/slide") {
+	if strings.HasPrefix(relPath, rootPath(root, "slides", "slide")) {
 		theme, _ := getSlideTheme(filePath, layoutToMaster, masterToTheme)
 		if theme != "" {
 			for _, tf := range themeFiles {
@@ -227,7 +302,7 @@ func shouldProcessFile(filePath, tempDir string, themeFilter []string,
 	}
 
 	// For slide layouts, check via master
-	if strings.HasPrefix(relPath, "ppt/slideLayouts/slideLayout") {
+	if strings.HasPrefix(relPath, rootPath(root, "slideLayouts", "slideLayout")) {
 		layoutName := filepath.Base(filePath)
 		if masterName, exists := layoutToMaster[layoutName]; exists {
 			if themeName, exists := masterToTheme[masterName]; exists {
@@ -242,7 +317,7 @@ func shouldProcessFile(filePath, tempDir string, themeFilter []string,
 	}
 
 	// For slide masters, check directly
-	if strings.HasPrefix(relPath, "ppt/slideMasters/slideMaster") {
+	if strings.HasPrefix(relPath, rootPath(root, "slideMasters", "slideMaster")) {
 		masterName := filepath.Base(filePath)
 		if themeName, exists := masterToTheme[masterName]; exists {
 			for _, tf := range themeFiles {
@@ -254,11 +329,38 @@ func shouldProcessFile(filePath, tempDir string, themeFilter []string,
 		}
 	}
 
+	// For theme parts, check the file itself
+	if strings.HasPrefix(relPath, rootDir(root, "theme")) {
+		for _, tf := range themeFiles {
+			if filepath.Base(filePath) == tf {
+				return true
+			}
+		}
+		return false
+	}
+
 	// For other files (charts, diagrams, etc.), process by default
 	return true
 }
 
-// validateThemeFilter checks if all themes in the filter exist in the presentation
+// themeNames normalizes theme file names for display: extensions are dropped
+// (theme1.xml → theme1), duplicates removed, and the result sorted naturally
+func themeNames(themeFiles []string) []string {
+	unique := make(map[string]bool)
+	for _, theme := range themeFiles {
+		unique[strings.TrimSuffix(theme, ".xml")] = true
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sortNatural(names)
+	return names
+}
+
+// validateThemeFilter checks if all themes in the filter exist in the presentation.
+// Missing themes are reported as *ErrThemeNotFound.
 func validateThemeFilter(themeFilter []string, masterToTheme map[string]string) error {
 	if len(themeFilter) == 0 {
 		return nil
@@ -283,32 +385,13 @@ func validateThemeFilter(themeFilter []string, masterToTheme map[string]string)
 	}
 
 	if len(notFound) > 0 {
-		// Get sorted list of available themes for error message
-		uniqueThemes := make(map[string]bool)
+		available := make([]string, 0, len(masterToTheme))
 		for _, theme := range masterToTheme {
-			themeBase := strings.TrimSuffix(theme, ".xml")
-			uniqueThemes[themeBase] = true
-		}
-
-		var available []string
-		for theme := range uniqueThemes {
 			available = append(available, theme)
 		}
+		available = themeNames(available)
 
-		// Sort for consistent error messages
-		if len(available) > 1 {
-			for i := 0; i < len(available)-1; i++ {
-				for j := i + 1; j < len(available); j++ {
-					if available[i] > available[j] {
-						available[i], available[j] = available[j], available[i]
-					}
-				}
-			}
-		}
-
-		return fmt.Errorf("theme(s) not found: %s\nAvailable themes: %s",
-			strings.Join(notFound, ", "),
-			strings.Join(available, ", "))
+		return &ErrThemeNotFound{Names: notFound, Available: available}
 	}
 
 	return nil
@@ -321,6 +404,7 @@ const (
 	ScopeAll     Scope = "all"
 	ScopeContent Scope = "content"
 	ScopeMaster  Scope = "master"
+	ScopeTheme   Scope = "theme"
 )
 
 // ValidScopes defines all valid scope values
@@ -328,37 +412,90 @@ var ValidScopes = map[Scope]bool{
 	ScopeAll:     true,
 	ScopeContent: true,
 	ScopeMaster:  true,
+	ScopeTheme:   true,
+}
+
+// splitScope splits a comma-separated scope (e.g., "content,master") into its tokens
+func splitScope(scope string) []Scope {
+	var scopes []Scope
+	for _, token := range strings.Split(scope, ",") {
+		scopes = append(scopes, Scope(strings.TrimSpace(token)))
+	}
+	return scopes
+}
+
+// scopeIncludes reports whether a (possibly comma-separated) scope names the given scope
+func scopeIncludes(scope string, want Scope) bool {
+	for _, s := range splitScope(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
 }
 
-// validateScope checks if a scope value is valid
+// validateScope checks if a scope value is valid. Several scopes can be
+// combined with commas (e.g., "content,master"); each must be valid.
+// An invalid token is reported as *ErrInvalidScope.
 func validateScope(scope string) error {
-	if !ValidScopes[Scope(scope)] {
+	for _, s := range splitScope(scope) {
+		if ValidScopes[s] {
+			continue
+		}
 		var validList []string
 		for s := range ValidScopes {
 			validList = append(validList, string(s))
 		}
 		// Sort for consistent error messages
 		sort.Strings(validList)
-		return fmt.Errorf("invalid scope '%s'. Valid values: %s",
-			scope, strings.Join(validList, ", "))
+		return &ErrInvalidScope{Scope: string(s), Valid: validList}
 	}
 	return nil
 }
 
-// getXMLPatterns returns the file patterns to process based on scope
+// getXMLPatterns returns the file patterns to process based on scope, for a package
+// with the usual "ppt" document root (see getXMLPatternsUnder)
 func getXMLPatterns(scope Scope) []string {
+	return getXMLPatternsUnder(partDir(defaultPresentationPart), scope)
+}
+
+// getXMLPatternsUnder returns the file patterns to process based on scope, as
+// archive path prefixes under the document root. A comma-separated scope returns
+// the union of each scope's patterns.
+func getXMLPatternsUnder(root string, scope Scope) []string {
+	scopes := splitScope(string(scope))
+	if len(scopes) > 1 {
+		var union []string
+		seen := make(map[string]bool)
+		for _, s := range scopes {
+			for _, pattern := range getXMLPatternsUnder(root, s) {
+				if !seen[pattern] {
+					seen[pattern] = true
+					union = append(union, pattern)
+				}
+			}
+		}
+		return union
+	}
+	scope = scopes[0]
+
 	contentPatterns := []string{
-		"ppt/slides/",
-		"ppt/charts/",
-		"ppt/diagrams/",
-		"ppt/notesSlides/",
+		rootDir(root, "slides"),
+		rootDir(root, "charts"),
+		rootDir(root, "diagrams"),
+		rootDir(root, "notesSlides"),
 	}
 
 	masterPatterns := []string{
-		"ppt/slideMasters/",
-		"ppt/slideLayouts/",
-		"ppt/notesMasters/",
-		"ppt/handoutMasters/",
+		rootDir(root, "slideMasters"),
+		rootDir(root, "slideLayouts"),
+		rootDir(root, "notesMasters"),
+		rootDir(root, "handoutMasters"),
+	}
+
+	// Theme parts are only processed on request; they are not part of "all"
+	themePatterns := []string{
+		rootDir(root, "theme"),
 	}
 
 	switch scope {
@@ -366,6 +503,8 @@ func getXMLPatterns(scope Scope) []string {
 		return contentPatterns
 	case ScopeMaster:
 		return masterPatterns
+	case ScopeTheme:
+		return themePatterns
 	default: // ScopeAll
 		all := make([]string, 0, len(contentPatterns)+len(masterPatterns))
 		all = append(all, contentPatterns...)
@@ -374,40 +513,30 @@ func getXMLPatterns(scope Scope) []string {
 	}
 }
 
-// ProcessPPTX processes a PowerPoint file, replacing scheme color references
-// Returns: filesProcessed, matchedSlides (nil if not applicable), error
-func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int) (int, *int, error) {
-	// Validate input
-	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-		return 0, nil, fmt.Errorf("input file not found: %s", inputPath)
-	}
-
-	// Validate scope
-	if err := validateScope(scope); err != nil {
-		return 0, nil, err
+// presentationPatterns returns the presentation-wide parts of an extracted package
+// that sit outside slides and masters but also carry color references (default
+// table styles, default text style)
+func presentationPatterns(tempDir string) []string {
+	return []string{
+		rootPath(documentRoot(tempDir), "tableStyles.xml"),
+		presentationPart(tempDir),
 	}
+}
 
-	// Get XML file patterns based on scope
-	xmlPatterns := getXMLPatterns(Scope(scope))
-
-	filesProcessed := 0
-
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "pptx-toolkit-*")
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Extract PPTX
+// extractPPTX unpacks every entry of the PPTX archive into destDir
+func extractPPTX(inputPath, destDir string) error {
 	zipReader, err := zip.OpenReader(inputPath)
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to open PPTX: %w", err)
+		return fmt.Errorf("failed to open PPTX: %w", err)
 	}
 	defer zipReader.Close()
 
 	for _, file := range zipReader.File {
-		filePath := filepath.Join(tempDir, file.Name)
+		// Refuse entries that would land outside destDir ("zip slip")
+		if !filepath.IsLocal(filepath.FromSlash(file.Name)) {
+			return fmt.Errorf("failed to extract PPTX: unsafe entry name %q", file.Name)
+		}
+		filePath := filepath.Join(destDir, filepath.FromSlash(file.Name))
 
 		if file.FileInfo().IsDir() {
 			os.MkdirAll(filePath, os.ModePerm)
@@ -415,18 +544,18 @@ func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, t
 		}
 
 		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			return 0, nil, err
+			return err
 		}
 
 		outFile, err := os.Create(filePath)
 		if err != nil {
-			return 0, nil, err
+			return err
 		}
 
 		rc, err := file.Open()
 		if err != nil {
 			outFile.Close()
-			return 0, nil, err
+			return err
 		}
 
 		_, err = io.Copy(outFile, rc)
@@ -434,26 +563,553 @@ func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, t
 		rc.Close()
 
 		if err != nil {
-			return 0, nil, err
+			return err
+		}
+	}
+
+	return nil
+}
+
+// defaultTempDir is where presentations are extracted when Options.TempDir is not
+// given; empty for the system temp directory. The CLI sets it from --temp-dir.
+var defaultTempDir string
+
+// ValidateTempDir checks that dir is an existing directory that files can be
+// created in, so a bad temp directory is reported before any work is done
+func ValidateTempDir(dir string) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return &ErrInvalidTempDir{Dir: dir, Err: fmt.Errorf("does not exist")}
+	}
+	if err != nil {
+		return &ErrInvalidTempDir{Dir: dir, Err: err}
+	}
+	if !info.IsDir() {
+		return &ErrInvalidTempDir{Dir: dir, Err: fmt.Errorf("not a directory")}
+	}
+
+	probe, err := os.CreateTemp(dir, ".pptx-toolkit-probe-*")
+	if err != nil {
+		return &ErrInvalidTempDir{Dir: dir, Err: fmt.Errorf("not writable")}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// newTempDir creates a directory for an extraction under dir, or under
+// defaultTempDir if dir is empty. Remove it with removeTemp.
+func newTempDir(dir string) (string, error) {
+	if dir == "" {
+		dir = defaultTempDir
+	}
+	tempDir, err := os.MkdirTemp(dir, "pptx-toolkit-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	trackTemp(tempDir)
+	return tempDir, nil
+}
+
+// withExtractedPPTX extracts a PPTX to a temporary directory, calls fn with it,
+// and removes the directory afterwards. Intended for read-only inspection.
+func withExtractedPPTX(pptxPath string, fn func(tempDir string) error) error {
+	if _, err := os.Stat(pptxPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", pptxPath)
+	}
+
+	tempDir, err := newTempDir("")
+	if err != nil {
+		return err
+	}
+	defer removeTemp(tempDir)
+
+	if err := extractPPTX(pptxPath, tempDir); err != nil {
+		return err
+	}
+
+	return fn(tempDir)
+}
+
+// editPackage extracts a PPTX to a temporary directory, calls edit with it, and
+// writes the output with writePPTX, removing the directory afterwards. edit
+// changes parts in place and returns the archive paths of the parts it changed
+// (e.g., "ppt/slides/slide1.xml"); those are recompressed, all other entries are
+// copied verbatim. Nothing is written if edit fails.
+func editPackage(inputPath, outputPath string, edit func(tempDir string) (map[string]bool, error)) error {
+	return editPackageIn("", nil, inputPath, outputPath, edit)
+}
+
+// editPackageIn is editPackage extracting under tempRoot (see newTempDir). If
+// timings is not nil, it receives the time taken by each phase.
+func editPackageIn(tempRoot string, timings *PhaseTimings, inputPath, outputPath string, edit func(tempDir string) (map[string]bool, error)) error {
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", inputPath)
+	}
+	if timings == nil {
+		timings = &PhaseTimings{}
+	}
+
+	tempDir, err := newTempDir(tempRoot)
+	if err != nil {
+		return err
+	}
+	defer removeTemp(tempDir)
+
+	start := time.Now()
+	if err := extractPPTX(inputPath, tempDir); err != nil {
+		return err
+	}
+	timings.Extract = time.Since(start)
+
+	start = time.Now()
+	changed, err := edit(tempDir)
+	if err != nil {
+		return err
+	}
+	timings.Process = time.Since(start)
+
+	start = time.Now()
+	err = writePPTX(inputPath, outputPath, tempDir, changed)
+	timings.Write = time.Since(start)
+	return err
+}
+
+// writePPTX writes the output archive, following the entry order of the input.
+// Entries listed in changed (archive-relative paths) are re-read from tempDir and
+// recompressed under their original name, method, and timestamp; all other entries
+// are copied verbatim, keeping their original compressed bytes. Both paths stream,
+// so memory use does not grow with the size of media entries.
+func writePPTX(inputPath, outputPath, tempDir string, changed map[string]bool) error {
+	return writeRenamedPPTX(inputPath, outputPath, tempDir, changed, nil)
+}
+
+// writeRenamedPPTX is writePPTX for packages whose parts were moved. Entries in
+// renamed (old archive path → new archive path) are written under their new name,
+// with content re-read from the new path in tempDir.
+//
+// The archive is written to a temporary file next to outputPath and renamed into
+// place only once it is complete, so a failure never leaves a truncated output or
+// destroys an existing file at outputPath.
+func writeRenamedPPTX(inputPath, outputPath, tempDir string, changed map[string]bool, renamed map[string]string) error {
+	zipReader, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open PPTX: %w", err)
+	}
+	defer zipReader.Close()
+
+	outFile, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	trackTemp(outFile.Name())
+	committed := false
+	defer func() {
+		if !committed {
+			outFile.Close()
+			removeTemp(outFile.Name())
+		}
+	}()
+
+	// Keep an existing output's permissions; new outputs get the usual 0644
+	// rather than CreateTemp's private 0600
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(outputPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := outFile.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	zipWriter := zip.NewWriter(outFile)
+
+	for _, file := range zipReader.File {
+		name, isRenamed := renamed[file.Name]
+		if !isRenamed {
+			name = file.Name
+		}
+
+		if !changed[file.Name] && !isRenamed {
+			if err := zipWriter.Copy(file); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   file.Method,
+			Modified: file.Modified,
+		}
+		w, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		// Stream from disk so large parts are never held in memory
+		src, err := os.Open(filepath.Join(tempDir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, src)
+		src.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
 		}
 	}
 
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize output file: %w", err)
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("failed to finalize output file: %w", err)
+	}
+
+	if err := os.Rename(outFile.Name(), outputPath); err != nil {
+		return fmt.Errorf("failed to move output into place: %w", err)
+	}
+	forgetTemp(outFile.Name())
+	committed = true
+	return nil
+}
+
+// Options holds optional processing settings for ProcessPPTXWithOptions
+type Options struct {
+	IncludeTableStyles bool // Also process ppt/tableStyles.xml and ppt/presentation.xml
+
+	// IncludeTheme also applies scheme→hex and hex→hex mappings to the clrScheme
+	// definitions in theme parts, so every reference to those slots follows.
+	// It cannot be combined with a slide filter.
+	IncludeTheme bool
+
+	// ThemeMappings holds additional mappings keyed by theme file name (e.g., "theme1.xml").
+	// A part governed by that theme uses the general mapping overlaid with its theme's
+	// mapping; parts with no single governing theme use the general mapping only.
+	ThemeMappings map[string]map[string]string
+
+	// Record, if set, receives every edit made so the swap can be undone exactly
+	Record *ChangeLog
+
+	// Preflight, if set, is called once before any part is edited with the scheme
+	// colors whose references the swap both removes and adds (see MappingEffect)
+	Preflight func(effects []MappingEffect)
+
+	// Replacements, if set, receives the number of color references replaced plus
+	// the number of theme slots recolored. It can be zero even when files were processed.
+	Replacements *int
+
+	// ThemeImpacts, if set, receives the parts changed and replacements made under
+	// each theme, sorted by theme file (see ThemeImpact)
+	ThemeImpacts *[]ThemeImpact
+
+	// Include and Exclude narrow the parts in scope by archive path, matched with
+	// path.Match (e.g., "ppt/charts/*"). A part is processed if it is in scope,
+	// matches an Include pattern (any part, if there are none), and matches no
+	// Exclude pattern. Theme definitions recolored by IncludeTheme are not affected.
+	Include []string
+	Exclude []string
+
+	// MatchSysClr also lets hex sources match system colors (sysClr) by their cached
+	// lastClr value. Matches are replaced with fixed srgbClr or schemeClr elements,
+	// so they stop following the operating system's colors.
+	MatchSysClr bool
+
+	// RecacheSysClr rewrites only the lastClr value of system colors that a hex→hex
+	// mapping matches, keeping the sysClr itself. Cannot be combined with MatchSysClr.
+	RecacheSysClr bool
+
+	// MapUnmatchedTo, if set, is a scheme or hex color that every color reference
+	// not covered by the mapping is replaced with, in the same atomic pass. Explicit
+	// mappings win (see withUnmatchedFallback).
+	MapUnmatchedTo string
+
+	// Protect lists scheme and hex colors that MapUnmatchedTo never replaces (see
+	// ParseProtectedColors). Explicit mappings are not checked against it; use
+	// ValidateProtectedColors for that.
+	Protect []string
+
+	// NoNotes leaves speaker notes (notesSlides) untouched whatever the scope,
+	// including the notes a slide filter would otherwise pull in with their slides.
+	// NoCharts and NoDiagrams do the same for charts and SmartArt diagrams.
+	NoNotes    bool
+	NoCharts   bool
+	NoDiagrams bool
+
+	// AllowEmpty lets a slide filter and a theme filter with no slide in common
+	// process nothing instead of failing with ErrNoSlidesMatched
+	AllowEmpty bool
+
+	// Sections adds the slides of these named sections (see ResolveSections) to the
+	// slide filter. It is an error if they hold no slides.
+	Sections []string
+
+	// VisibleIndex treats slide filter numbers as positions among visible slides,
+	// skipping hidden ones, rather than positions in the slide list
+	VisibleIndex bool
+
+	// Progress, if set, is called after each candidate part is processed with the
+	// number of parts done so far and the total. Calls are serialized.
+	Progress func(done, total int)
+
+	// NormalizeEmpty rewrites empty schemeClr and srgbClr containers in processed
+	// parts (e.g. <a:schemeClr val="accent1"></a:schemeClr>) in the self-closing form
+	NormalizeEmpty bool
+
+	// PreserveCase writes hex targets in references exactly as given in the mapping
+	// instead of uppercased (see ReplaceOptions). Theme color scheme slots recolored
+	// by IncludeTheme are still written uppercase.
+	PreserveCase bool
+
+	// TempDir is the directory the presentation is extracted under while it is
+	// edited; empty for the default (see defaultTempDir)
+	TempDir string
+
+	// Timings, if set, receives the time spent extracting the input, editing its
+	// parts, and writing the output
+	Timings *PhaseTimings
+}
+
+// ThemeImpact totals what a swap changed in the parts governed by one theme: its
+// masters, layouts, slides, and their charts, diagrams, and notes, plus the theme
+// part itself
+type ThemeImpact struct {
+	Theme        string `json:"theme"`        // Theme file (e.g., "theme1.xml"), or empty for parts no single theme governs
+	Files        int    `json:"files"`        // Parts whose content changed
+	Replacements int    `json:"replacements"` // Color references replaced plus theme slots recolored
+}
+
+// themeImpacts accumulates ThemeImpact totals by theme file
+type themeImpacts map[string]*ThemeImpact
+
+// add counts a part's changes toward theme
+func (impacts themeImpacts) add(theme string, changed bool, replacements int) {
+	impact, ok := impacts[theme]
+	if !ok {
+		impact = &ThemeImpact{Theme: theme}
+		impacts[theme] = impact
+	}
+	if changed {
+		impact.Files++
+	}
+	impact.Replacements += replacements
+}
+
+// sorted returns the themes that changed, in natural file order, with parts no
+// single theme governs last
+func (impacts themeImpacts) sorted() []ThemeImpact {
+	themes := make([]string, 0, len(impacts))
+	for theme, impact := range impacts {
+		if theme != "" && (impact.Files > 0 || impact.Replacements > 0) {
+			themes = append(themes, theme)
+		}
+	}
+	sortNatural(themes)
+
+	result := make([]ThemeImpact, 0, len(impacts))
+	for _, theme := range themes {
+		result = append(result, *impacts[theme])
+	}
+	if impact, ok := impacts[""]; ok && (impact.Files > 0 || impact.Replacements > 0) {
+		result = append(result, *impact)
+	}
+	return result
+}
+
+// ProcessPPTX processes a PowerPoint file, replacing scheme color references
+// Returns: filesProcessed, matchedSlides (nil if not applicable), error
+func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int) (int, *int, error) {
+	return ProcessPPTXWithOptions(inputPath, outputPath, colorMapping, themeFilter, scope, slideFilter, Options{})
+}
+
+// ProcessPPTXWithOptions is ProcessPPTX with additional options
+func ProcessPPTXWithOptions(inputPath, outputPath string, colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int, opts Options) (int, *int, error) {
+	// Validate input
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return 0, nil, fmt.Errorf("input file not found: %s", inputPath)
+	}
+
+	opts, err := checkSwapOptions(scope, slideFilter, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	themeMappingNames := make([]string, 0, len(opts.ThemeMappings))
+	for theme := range opts.ThemeMappings {
+		themeMappingNames = append(themeMappingNames, theme)
+	}
+	if err := checkThemeFilter(inputPath, themeFilter, themeMappingNames); err != nil {
+		return 0, nil, err
+	}
+
+	var filesProcessed int
+	var matchedSlides *int
+	err = editPackageIn(opts.TempDir, opts.Timings, inputPath, outputPath, func(tempDir string) (map[string]bool, error) {
+		var changedParts map[string]bool
+		var err error
+		filesProcessed, matchedSlides, changedParts, err = swapExtracted(tempDir, colorMapping, themeFilter, scope, slideFilter, opts)
+		return changedParts, err
+	})
+	return filesProcessed, matchedSlides, err
+}
+
+// ProcessDir is ProcessPPTXWithOptions for a presentation already extracted to dir
+// (e.g., by unzip), editing its parts in place instead of writing an output file.
+// Nothing is zipped or unzipped, so opts.TempDir is not used. If processing fails,
+// parts already edited stay edited.
+func ProcessDir(dir string, colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int, opts Options) (int, *int, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return 0, nil, fmt.Errorf("input directory not found: %s", dir)
+	}
+
+	opts, err := checkSwapOptions(scope, slideFilter, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	filesProcessed, matchedSlides, _, err := swapExtracted(dir, colorMapping, themeFilter, scope, slideFilter, opts)
+	return filesProcessed, matchedSlides, err
+}
+
+// checkSwapOptions validates the swap arguments that do not depend on the package,
+// and returns opts with IncludeTheme set when the scope takes in theme parts
+func checkSwapOptions(scope string, slideFilter []int, opts Options) (Options, error) {
+	// Validate scope
+	if err := validateScope(scope); err != nil {
+		return opts, err
+	}
+
+	// Validate include/exclude globs
+	if err := validatePartGlobs(opts.Include, opts.Exclude); err != nil {
+		return opts, err
+	}
+
+	if opts.MapUnmatchedTo != "" && !isValidColor(opts.MapUnmatchedTo) {
+		return opts, &ErrInvalidColor{Color: opts.MapUnmatchedTo, Side: "fallback"}
+	}
+
+	if _, err := ParseProtectedColors(opts.Protect); err != nil {
+		return opts, err
+	}
+
+	if opts.MatchSysClr && opts.RecacheSysClr {
+		return opts, fmt.Errorf("MatchSysClr and RecacheSysClr cannot be combined")
+	}
+
+	if opts.TempDir != "" {
+		if err := ValidateTempDir(opts.TempDir); err != nil {
+			return opts, err
+		}
+	}
+
+	// Processing theme parts means recoloring their color schemes too
+	themeScope := scopeIncludes(scope, ScopeTheme)
+	if themeScope {
+		opts.IncludeTheme = true
+	}
+
+	if opts.IncludeTheme && (len(slideFilter) > 0 || len(opts.Sections) > 0) {
+		return opts, fmt.Errorf("theme colors apply to every slide and cannot be combined with a slide filter")
+	}
+
+	return opts, nil
+}
+
+// swapExtracted replaces color references in an extracted presentation, editing
+// parts in place. opts must have passed checkSwapOptions. It returns the number of
+// parts processed, the number of filtered slides on the selected themes (nil without
+// both filters), and the archive paths of the parts changed.
+func swapExtracted(tempDir string, colorMapping map[string]string, themeFilter []string, scope string, slideFilter []int, opts Options) (filesProcessed int, matchedSlides *int, changedParts map[string]bool, err error) {
+	themeScope := scopeIncludes(scope, ScopeTheme)
+	protected, _ := ParseProtectedColors(opts.Protect) // Checked by checkSwapOptions
+
+	// Get XML file patterns based on scope
+	xmlPatterns := getXMLPatternsUnder(documentRoot(tempDir), Scope(scope))
+	if opts.IncludeTableStyles {
+		xmlPatterns = append(xmlPatterns, presentationPatterns(tempDir)...)
+	}
+	contentTypes := opts.slideContentTypes()
+	skippedDirs := make(map[string]bool)
+	for contentType, dir := range slideContentDirs {
+		if !contentTypes[contentType] {
+			skippedDirs[rootDir(documentRoot(tempDir), dir)] = true
+		}
+	}
+	if len(skippedDirs) > 0 {
+		var kept []string
+		for _, pattern := range xmlPatterns {
+			if !skippedDirs[pattern] {
+				kept = append(kept, pattern)
+			}
+		}
+		xmlPatterns = kept
+	}
+
 	// Build theme relationship mappings
 	masterToTheme, _ := buildThemeRelationships(tempDir)
 	layoutToMaster, _ := buildLayoutToMasterMapping(tempDir)
 
 	// Validate theme filter
 	if err := validateThemeFilter(themeFilter, masterToTheme); err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
+	}
+
+	// Resolve which theme governs each part for theme-specific mappings and impacts
+	var partThemes map[string]string
+	if len(opts.ThemeMappings) > 0 {
+		themeNames := make([]string, 0, len(opts.ThemeMappings))
+		for theme := range opts.ThemeMappings {
+			themeNames = append(themeNames, theme)
+		}
+		if err := validateThemeFilter(themeNames, masterToTheme); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	if len(opts.ThemeMappings) > 0 || opts.ThemeImpacts != nil {
+		partThemes, err = buildPartThemes(tempDir, layoutToMaster, masterToTheme)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	mappingFor := func(theme string) map[string]string {
+		return mergeMappings(colorMapping, opts.ThemeMappings[theme])
+	}
+
+	// Alias sources (bg1, tx1, ...) resolve differently on slides that override
+	// their master's color map
+	var partColorMaps map[string]partColorMap
+	if hasColorMapAliases(colorMapping) || hasColorMapAliases(mapValues(opts.ThemeMappings)...) {
+		partColorMaps, err = buildPartColorMaps(tempDir, layoutToMaster)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	// Map visible positions to slide numbers before anything else uses them
+	if len(slideFilter) > 0 && opts.VisibleIndex {
+		slideFilter, err = ResolveVisibleSlides(tempDir, slideFilter)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	// Sections name slides by slide number, never by visible position
+	if len(opts.Sections) > 0 {
+		sectionSlides, err := ResolveSections(tempDir, opts.Sections)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		if len(sectionSlides) == 0 {
+			return 0, nil, nil, fmt.Errorf("section(s) %s contain no slides", strings.Join(opts.Sections, ", "))
+		}
+		slideFilter = mergeSlides(slideFilter, sectionSlides)
 	}
 
 	// Build slide filter mapping if slides specified
 	var allowedFiles map[string]bool
-	var matchedSlides *int
 	if len(slideFilter) > 0 {
 		// Validate slides exist
 		if err := ValidateSlideNumbers(tempDir, slideFilter); err != nil {
-			return 0, nil, err
+			return 0, nil, nil, err
 		}
 
 		// If theme filter is also specified, filter slides to only those using the specified themes
@@ -463,16 +1119,20 @@ func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, t
 			// Track matched count for output feedback
 			count := len(filteredSlides)
 			matchedSlides = &count
+			if count == 0 && !opts.AllowEmpty {
+				return 0, matchedSlides, nil, &ErrNoSlidesMatched{Slides: slideFilter, Themes: themeFilter}
+			}
 		}
 
 		// Build dependency graph (slides + embedded content)
-		allowedFiles, err = GetSlideContent(tempDir, filteredSlides)
+		allowedFiles, err = GetSlideContentWithTypes(tempDir, filteredSlides, contentTypes)
 		if err != nil {
-			return 0, nil, fmt.Errorf("failed to build slide content mapping: %w", err)
+			return 0, nil, nil, fmt.Errorf("failed to build slide content mapping: %w", err)
 		}
 	}
 
-	// Process XML files
+	// Collect the parts to process up front so progress can report a total
+	var candidates []string
 	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -498,6 +1158,11 @@ func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, t
 			return nil
 		}
 
+		// Check include/exclude globs
+		if !matchesPartGlobs(relPath, opts.Include, opts.Exclude) {
+			return nil
+		}
+
 		// Check theme filter
 		if !shouldProcessFile(path, tempDir, themeFilter, layoutToMaster, masterToTheme) {
 			return nil
@@ -508,74 +1173,549 @@ func ProcessPPTX(inputPath, outputPath string, colorMapping map[string]string, t
 			return nil
 		}
 
-		// Read, replace, write
-		content, err := os.ReadFile(path)
+		candidates = append(candidates, path)
+		return nil
+	})
+
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var themeParts []string
+	if opts.IncludeTheme {
+		themeParts, err = selectThemeParts(tempDir, themeFilter)
 		if err != nil {
-			return nil
+			return 0, nil, nil, err
+		}
+	}
+
+	// Explain mixed effects before anything is edited
+	if opts.Preflight != nil {
+		effects := make(mappingEffects)
+		for _, path := range candidates {
+			relPath, _ := filepath.Rel(tempDir, path)
+			relPath = filepath.ToSlash(relPath)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			colorMaps := partColorMaps[relPath]
+			effects.add(content, resolveColorMapAliases(mappingFor(partThemes[relPath]), colorMaps.Master, colorMaps.Effective))
 		}
+		opts.Preflight(effects.mixed())
+	}
+
+	// With --scope theme the theme parts are already candidates; count them once
+	themeProgress := len(themeParts)
+	if themeScope {
+		themeProgress = 0
+	}
+	progress := newProgressReporter(opts.Progress, len(candidates)+themeProgress)
 
-		// Apply scheme → scheme/hex replacements
-		modified, err := ReplaceSchemeColorsWithSrgb(content, colorMapping)
+	// Process XML files
+	partOpts := partOptions{
+		fallback:       opts.MapUnmatchedTo,
+		protected:      protected,
+		sysClr:         opts.sysClrMode(),
+		selfCloseEmpty: opts.NormalizeEmpty,
+		replace:        ReplaceOptions{PreserveCase: opts.PreserveCase},
+		record:         opts.Record,
+	}
+	changedFiles := make(map[string]bool)
+	replacements := 0
+	impacts := make(themeImpacts)
+	for _, path := range candidates {
+		relPath, _ := filepath.Rel(tempDir, path)
+		relPath = filepath.ToSlash(relPath)
+		colorMaps := partColorMaps[relPath]
+		mapping := resolveColorMapAliases(mappingFor(partThemes[relPath]), colorMaps.Master, colorMaps.Effective)
+		edits, rewritten, err := processXMLPart(path, relPath, mapping, partOpts)
 		if err != nil {
-			return nil
+			return 0, nil, nil, err
+		}
+		if rewritten {
+			changedFiles[relPath] = true
+		}
+		replacements += edits
+		theme := partThemes[relPath]
+		if isThemePart(relPath) {
+			// Only references inside the theme part, with --scope theme
+			theme = filepath.Base(path)
 		}
+		impacts.add(theme, rewritten, edits)
+		filesProcessed++
+		progress.step()
+	}
 
-		// Apply hex → scheme/hex replacements
-		modified, err = ReplaceSrgbColors(modified, colorMapping)
+	// Recolor theme definitions
+	if opts.IncludeTheme {
+		themeProgressReporter := progress
+		if themeScope {
+			themeProgressReporter = nil
+		}
+		themeDir := rootPath(documentRoot(tempDir), "theme")
+		rewrittenBefore := make(map[string]bool)
+		for relPath := range changedFiles {
+			rewrittenBefore[relPath] = true
+		}
+		themesProcessed, slotsRecolored, err := updateThemeColors(themeDir, themeParts, mappingFor, changedFiles, themeProgressReporter, opts.Record)
+		if !themeScope {
+			filesProcessed += themesProcessed
+		}
+		for theme, recolored := range slotsRecolored {
+			replacements += recolored
+			// A part already rewritten for its references counts once
+			impacts.add(theme, !rewrittenBefore[path.Join(themeDir, theme)], recolored)
+		}
 		if err != nil {
-			return nil
+			return 0, nil, nil, err
 		}
+	}
 
-		if err := os.WriteFile(path, modified, info.Mode()); err != nil {
-			return nil
+	if opts.Replacements != nil {
+		*opts.Replacements = replacements
+	}
+	if opts.ThemeImpacts != nil {
+		*opts.ThemeImpacts = impacts.sorted()
+	}
+
+	return filesProcessed, matchedSlides, changedFiles, nil
+}
+
+// mergeSlides returns the sorted union of two lists of slide numbers
+func mergeSlides(a, b []int) []int {
+	seen := make(map[int]bool, len(a)+len(b))
+	var merged []int
+	for _, slideNum := range append(append([]int{}, a...), b...) {
+		if !seen[slideNum] {
+			seen[slideNum] = true
+			merged = append(merged, slideNum)
 		}
+	}
+	sort.Ints(merged)
+	return merged
+}
 
-		filesProcessed++
-		return nil
-	})
+// sysClrMode is how hex sources treat system colors (sysClr)
+type sysClrMode int
+
+const (
+	sysClrIgnore  sysClrMode = iota // Leave system colors alone (default)
+	sysClrReplace                   // Replace matching system colors (ReplaceSysColors)
+	sysClrRecache                   // Rewrite only their lastClr (ReplaceSysColorCache)
+)
 
+// slideContentTypes returns the slide content types opts leaves enabled
+func (opts Options) slideContentTypes() map[SlideContentType]bool {
+	return map[SlideContentType]bool{
+		SlideCharts:   !opts.NoCharts,
+		SlideDiagrams: !opts.NoDiagrams,
+		SlideNotes:    !opts.NoNotes,
+	}
+}
+
+// sysClrMode returns the system color handling selected by opts
+func (opts Options) sysClrMode() sysClrMode {
+	switch {
+	case opts.MatchSysClr:
+		return sysClrReplace
+	case opts.RecacheSysClr:
+		return sysClrRecache
+	}
+	return sysClrIgnore
+}
+
+// partOptions are the settings processXMLPart applies to every part of a swap
+type partOptions struct {
+	fallback       string            // Color for references the mapping does not cover, empty for none (see withUnmatchedFallback)
+	protected      map[string]string // Colors the fallback never replaces
+	sysClr         sysClrMode        // How hex sources treat system colors
+	selfCloseEmpty bool              // Also tidy empty color containers (see SelfCloseEmptyColors)
+	replace        ReplaceOptions    // How targets are written
+	record         *ChangeLog        // Receives the edits made, if non-nil
+}
+
+// processXMLPart applies colorMapping to a single extracted XML part, rewriting it
+// only if its content changed, with the settings in opts.
+// Returns the number of color references replaced and whether the part was
+// rewritten. A part that cannot be read or rewritten is an error.
+func processXMLPart(path, relPath string, colorMapping map[string]string, opts partOptions) (int, bool, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return filesProcessed, matchedSlides, err
+		return 0, false, fmt.Errorf("failed to read %s: %w", relPath, err)
 	}
 
-	// Create output ZIP
-	outFile, err := os.Create(outputPath)
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return filesProcessed, matchedSlides, fmt.Errorf("failed to create output file: %w", err)
+		return 0, false, fmt.Errorf("failed to read %s: %w", relPath, err)
 	}
-	defer outFile.Close()
 
-	zipWriter := zip.NewWriter(outFile)
-	defer zipWriter.Close()
+	// System colors are only matched by explicit mappings
+	explicitMapping := colorMapping
+	colorMapping = withUnmatchedFallback(content, colorMapping, opts.fallback, opts.protected)
 
-	// Add all files to ZIP
-	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	// Apply scheme → scheme/hex replacements
+	schemeEdits := schemeColorWithSrgbEdits(content, colorMapping, opts.replace)
+	if isThemePart(relPath) {
+		schemeEdits = outsideColorScheme(content, schemeEdits)
+	}
+	intermediate := applyEdits(content, schemeEdits)
+
+	// Apply hex → scheme/hex replacements. They are matched against the original
+	// content, so hex colors the scheme pass wrote are not mapped again.
+	srgbEdits := srgbColorEdits(content, colorMapping, opts.replace)
+	switch opts.sysClr {
+	case sysClrReplace:
+		srgbEdits = append(srgbEdits, sysColorEdits(content, explicitMapping, opts.replace)...)
+	case sysClrRecache:
+		srgbEdits = append(srgbEdits, sysColorCacheEdits(content, explicitMapping, opts.replace)...)
+	}
+	if isThemePart(relPath) {
+		srgbEdits = outsideColorScheme(content, srgbEdits)
+	}
+	srgbEdits = shiftEdits(srgbEdits, schemeEdits)
+	modified := applyEdits(intermediate, srgbEdits)
+
+	// Tidy empty containers; this changes no color, so it is not counted
+	var emptyEdits []byteEdit
+	if opts.selfCloseEmpty {
+		emptyEdits = emptyColorEdits(modified)
+		if isThemePart(relPath) {
+			emptyEdits = outsideColorScheme(modified, emptyEdits)
 		}
+	}
+	swapped := modified
+	modified = applyEdits(swapped, emptyEdits)
 
-		if info.IsDir() {
-			return nil
+	// Only rewrite parts that actually changed, so untouched parts keep their original bytes
+	if bytes.Equal(modified, content) {
+		return 0, false, nil
+	}
+	if err := os.WriteFile(path, modified, info.Mode()); err != nil {
+		return 0, false, fmt.Errorf("failed to write %s: %w", relPath, err)
+	}
+
+	if opts.record != nil {
+		opts.record.add(relPath, passSchemeColors, content, schemeEdits)
+		opts.record.add(relPath, passSrgbColors, intermediate, srgbEdits)
+		opts.record.add(relPath, passEmptyColors, swapped, emptyEdits)
+	}
+	return countChangingEdits(content, schemeEdits) + countChangingEdits(intermediate, srgbEdits), true, nil
+}
+
+// validatePartGlobs checks that include/exclude patterns are valid path.Match patterns
+func validatePartGlobs(patterns ...[]string) error {
+	for _, list := range patterns {
+		for _, pattern := range list {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid part pattern '%s': %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesPartGlobs reports whether an archive path matches one of include (or
+// include is empty) and none of exclude
+func matchesPartGlobs(relPath string, include, exclude []string) bool {
+	if len(include) > 0 {
+		included := false
+		for _, pattern := range include {
+			if matched, _ := path.Match(pattern, relPath); matched {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
 		}
+	}
+
+	for _, pattern := range exclude {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// isThemePart reports whether an archive path is a theme part, one in a "theme"
+// folder under the document root
+func isThemePart(relPath string) bool {
+	return path.Base(path.Dir(relPath)) == "theme"
+}
+
+// outsideColorScheme drops edits that fall inside a theme's clrScheme elements, its
+// own and any in extraClrSchemeLst. The slot definitions are recolored by
+// updateThemeColors with theme semantics, not as references; a reference swap there
+// could write a schemeClr into a slot, which a color scheme cannot hold.
+func outsideColorScheme(themeXML []byte, edits []byteEdit) []byteEdit {
+	schemes := clrSchemePattern.FindAllIndex(themeXML, -1)
+	if schemes == nil {
+		return edits
+	}
 
-		relPath, err := filepath.Rel(tempDir, path)
+	kept := edits[:0]
+	for _, edit := range edits {
+		inside := false
+		for _, loc := range schemes {
+			if edit.start >= loc[0] && edit.start < loc[1] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			kept = append(kept, edit)
+		}
+	}
+	return kept
+}
+
+// selectThemeParts returns the extracted theme parts in tempDir, optionally limited by themeFilter
+func selectThemeParts(tempDir string, themeFilter []string) ([]string, error) {
+	themePaths, err := filepath.Glob(filepath.Join(docPath(tempDir, "theme"), "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(themeFilter) == 0 {
+		return themePaths, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, theme := range themeFilter {
+		if !strings.HasSuffix(theme, ".xml") {
+			theme += ".xml"
+		}
+		wanted[theme] = true
+	}
+
+	var selected []string
+	for _, path := range themePaths {
+		if wanted[filepath.Base(path)] {
+			selected = append(selected, path)
+		}
+	}
+	return selected, nil
+}
+
+// forEachTheme calls fn with the content of every theme part in tempDir, or of those
+// named in themeFilter after checking that they are themes in use. A part for which
+// fn returns different content is written back and added to the returned set of
+// changed parts (e.g., "ppt/theme/theme1.xml"). progress, if set, is called once per
+// theme part with the number done and the total.
+func forEachTheme(tempDir string, themeFilter []string, progress func(done, total int), fn func(themeFile string, content []byte) ([]byte, error)) (map[string]bool, error) {
+	masterToTheme, _ := buildThemeRelationships(tempDir)
+	if err := validateThemeFilter(themeFilter, masterToTheme); err != nil {
+		return nil, err
+	}
+
+	themePaths, err := selectThemeParts(tempDir, themeFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	themeDir := rootPath(documentRoot(tempDir), "theme")
+	reporter := newProgressReporter(progress, len(themePaths))
+	changed := make(map[string]bool)
+	for _, themePath := range themePaths {
+		fileName := filepath.Base(themePath)
+
+		content, err := os.ReadFile(themePath)
 		if err != nil {
-			return err
+			return changed, err
 		}
 
-		zipFile, err := zipWriter.Create(filepath.ToSlash(relPath))
+		modified, err := fn(fileName, content)
 		if err != nil {
-			return err
+			return changed, err
+		}
+		if !bytes.Equal(modified, content) {
+			if err := os.WriteFile(themePath, modified, 0644); err != nil {
+				return changed, err
+			}
+			changed[path.Join(themeDir, fileName)] = true
+		}
+
+		reporter.step()
+	}
+
+	return changed, nil
+}
+
+// updateThemeColors applies the theme slot changes implied by each theme's color
+// mapping (from mappingFor) to the given theme parts, found in the themeDir archive
+// folder, recording rewritten parts in changed (and their edits in record, if
+// non-nil). Returns the number of theme parts processed and the number of slots
+// recolored in each, keyed by theme file name.
+func updateThemeColors(themeDir string, themePaths []string, mappingFor func(theme string) map[string]string, changed map[string]bool, progress *progressReporter, record *ChangeLog) (int, map[string]int, error) {
+	processed, recolored := 0, make(map[string]int)
+	for _, themePath := range themePaths {
+		fileName := filepath.Base(themePath)
+
+		content, err := os.ReadFile(themePath)
+		if err != nil {
+			return processed, recolored, err
 		}
 
-		content, err := os.ReadFile(path)
+		theme, err := parseThemeXML(content, fileName)
 		if err != nil {
-			return err
+			// Not a theme with a color scheme; nothing to recolor
+			progress.step()
+			continue
 		}
 
-		_, err = io.Copy(zipFile, bytes.NewReader(content))
-		return err
+		changes := themeColorChanges(theme.Colors, mappingFor(fileName))
+		if len(changes) > 0 {
+			edits, err := schemeSlotEdits(content, changes)
+			if err != nil {
+				return processed, recolored, fmt.Errorf("failed to update %s: %w", fileName, err)
+			}
+			modified := applyEdits(content, edits)
+			if !bytes.Equal(modified, content) {
+				if err := os.WriteFile(themePath, modified, 0644); err != nil {
+					return processed, recolored, err
+				}
+				changed[path.Join(themeDir, fileName)] = true
+				for name, target := range changes {
+					if !strings.EqualFold(theme.Colors.Get(name), target) {
+						recolored[fileName]++
+					}
+				}
+				if record != nil {
+					// One change per slot, so each can be attributed to its color
+					var changing []byteEdit
+					for _, edit := range edits {
+						if !bytes.Equal(content[edit.start:edit.end], edit.replacement) {
+							changing = append(changing, edit)
+						}
+					}
+					record.add(path.Join(themeDir, fileName), passThemeColors, content, changing)
+				}
+			}
+		}
+
+		processed++
+		progress.step()
+	}
+
+	return processed, recolored, nil
+}
+
+// rewriteParts applies rewrite to every XML part in scope and writes the output,
+// re-compressing only the parts whose content changed.
+// Returns the number of parts changed.
+func rewriteParts(inputPath, outputPath string, scope Scope, rewrite func([]byte) []byte) (int, error) {
+	changed := make(map[string]bool)
+	err := editPackage(inputPath, outputPath, func(tempDir string) (map[string]bool, error) {
+		xmlPatterns := getXMLPatternsUnder(documentRoot(tempDir), scope)
+		err := filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() || !strings.HasSuffix(path, ".xml") {
+				return nil
+			}
+
+			relPath, _ := filepath.Rel(tempDir, path)
+			relPath = filepath.ToSlash(relPath)
+
+			inScope := false
+			for _, pattern := range xmlPatterns {
+				if strings.HasPrefix(relPath, pattern) {
+					inScope = true
+					break
+				}
+			}
+			if !inScope {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			modified := rewrite(content)
+			if bytes.Equal(modified, content) {
+				return nil
+			}
+
+			if err := os.WriteFile(path, modified, info.Mode()); err != nil {
+				return err
+			}
+			changed[relPath] = true
+			return nil
+		})
+		return changed, err
 	})
+	if err != nil {
+		return 0, err
+	}
+	return len(changed), nil
+}
 
-	return filesProcessed, matchedSlides, err
+// mergeMappings returns base overlaid with override; base is returned as is when
+// there is nothing to overlay
+func mergeMappings(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+
+	merged := make(map[string]string, len(base)+len(override))
+	for source, target := range base {
+		merged[source] = target
+	}
+	for source, target := range override {
+		merged[source] = target
+	}
+	return merged
+}
+
+// buildPartThemes resolves the theme file governing each slide, slide-owned part
+// (charts, diagrams, notes), slide layout, and slide master, keyed by archive path.
+// Parts shared by slides with different themes are left out, since no single theme
+// governs them.
+func buildPartThemes(tempDir string, layoutToMaster, masterToTheme map[string]string) (map[string]string, error) {
+	root := documentRoot(tempDir)
+	partThemes := make(map[string]string)
+
+	for master, theme := range masterToTheme {
+		partThemes[rootPath(root, "slideMasters", master)] = theme
+	}
+	for layout, master := range layoutToMaster {
+		if theme, ok := masterToTheme[master]; ok {
+			partThemes[rootPath(root, "slideLayouts", layout)] = theme
+		}
+	}
+
+	slideThemes, err := BuildSlideThemeMapping(tempDir)
+	if err != nil {
+		// Without a slide mapping only masters and layouts can be resolved
+		return partThemes, nil
+	}
+
+	shared := make(map[string]bool)
+	for _, st := range slideThemes {
+		if st.Theme == "" {
+			continue
+		}
+
+		parts, err := GetSlideContent(tempDir, []int{st.Slide})
+		if err != nil {
+			return nil, err
+		}
+		for part := range parts {
+			if existing, ok := partThemes[part]; ok && existing != st.Theme {
+				shared[part] = true
+			}
+			partThemes[part] = st.Theme
+		}
+	}
+	for part := range shared {
+		delete(partThemes, part)
+	}
+
+	return partThemes, nil
 }
@@ -2,10 +2,19 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestProcessPPTX(t *testing.T) {
@@ -232,7 +241,6 @@ func TestProcessPPTX(t *testing.T) {
 
 }
 
-
 func TestProcessPPTX_Errors(t *testing.T) {
 	t.Run("nonexistent input file", func(t *testing.T) {
 		_, _, err := ProcessPPTX("/nonexistent/file.pptx", "/tmp/output.pptx", map[string]string{"accent1": "accent2"}, nil, "all", nil)
@@ -470,8 +478,14 @@ func TestProcessPPTX_SlideFiltering(t *testing.T) {
 
 		// Process slide 2 (theme1) with theme2 filter - should match nothing
 		mapping := map[string]string{"accent1": "accent6"}
-		filesProcessed, matchedSlides, err := ProcessPPTX(testPPTX, outputPath, mapping, []string{"theme2"}, "content", []int{2})
+		_, _, err = ProcessPPTX(testPPTX, outputPath, mapping, []string{"theme2"}, "content", []int{2})
+		var noMatch *ErrNoSlidesMatched
+		if !errors.As(err, &noMatch) {
+			t.Fatalf("ProcessPPTX() error = %v, want ErrNoSlidesMatched", err)
+		}
 
+		// AllowEmpty turns the empty intersection into a no-op
+		filesProcessed, matchedSlides, err := ProcessPPTXWithOptions(testPPTX, outputPath, mapping, []string{"theme2"}, "content", []int{2}, Options{AllowEmpty: true})
 		if err != nil {
 			t.Fatalf("ProcessPPTX failed: %v", err)
 		}
@@ -501,6 +515,11 @@ func TestValidateScope(t *testing.T) {
 		{"valid all", "all", false},
 		{"valid content", "content", false},
 		{"valid master", "master", false},
+		{"valid theme", "theme", false},
+		{"combined scopes", "content,master", false},
+		{"combined with spaces", "content, theme", false},
+		{"combined with invalid", "content,invalid", true},
+		{"trailing comma", "content,", true},
 		{"invalid scope", "invalid", true},
 		{"empty scope", "", true},
 	}
@@ -539,6 +558,29 @@ func TestGetXMLPatterns(t *testing.T) {
 			wantContains: []string{"ppt/slideMasters/", "ppt/slideLayouts/", "ppt/notesMasters/", "ppt/handoutMasters/"},
 			wantExcludes: []string{"ppt/slides/", "ppt/charts/"},
 		},
+		{
+			name:         "theme scope",
+			scope:        ScopeTheme,
+			wantContains: []string{"ppt/theme/"},
+			wantExcludes: []string{"ppt/slides/", "ppt/slideMasters/"},
+		},
+		{
+			name:         "content and master",
+			scope:        "content,master",
+			wantContains: []string{"ppt/slides/", "ppt/notesSlides/", "ppt/slideMasters/", "ppt/notesMasters/"},
+			wantExcludes: []string{"ppt/theme/"},
+		},
+		{
+			name:         "overlapping scopes",
+			scope:        "all,content",
+			wantContains: []string{"ppt/slides/", "ppt/slideMasters/"},
+		},
+		{
+			name:         "content and theme",
+			scope:        "content,theme",
+			wantContains: []string{"ppt/slides/", "ppt/charts/", "ppt/theme/"},
+			wantExcludes: []string{"ppt/slideMasters/", "ppt/slideLayouts/", "ppt/notesMasters/"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -556,6 +598,14 @@ func TestGetXMLPatterns(t *testing.T) {
 					t.Errorf("getXMLPatterns(%s) should not contain %s", tt.scope, exclude)
 				}
 			}
+
+			seen := make(map[string]bool)
+			for _, pattern := range patterns {
+				if seen[pattern] {
+					t.Errorf("getXMLPatterns(%s) lists %s twice", tt.scope, pattern)
+				}
+				seen[pattern] = true
+			}
 		})
 	}
 }
@@ -569,3 +619,1381 @@ func containsString(slice []string, item string) bool {
 	}
 	return false
 }
+
+func BenchmarkProcessPPTX(b *testing.B) {
+	sizes := []struct {
+		slides, colors int
+	}{
+		{10, 10},
+		{100, 10},
+		{100, 100},
+		{500, 50},
+	}
+
+	mapping := map[string]string{
+		"accent1": "accent3",
+		"accent2": "FF0000",
+		"AABBCC":  "accent4",
+		"123456":  "654321",
+	}
+
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("slides=%d/colors=%d", size.slides, size.colors), func(b *testing.B) {
+			inputPath := writeSyntheticPPTX(b, syntheticDeck{Slides: size.slides, ColorsPerSlide: size.colors})
+			outputPath := filepath.Join(b.TempDir(), "output.pptx")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := ProcessPPTX(inputPath, outputPath, mapping, nil, "all", nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// readRawEntries returns the compressed bytes and method of every entry in a ZIP archive
+func readRawEntries(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+
+	entries := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		r, err := file.OpenRaw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = append([]byte{byte(file.Method)}, raw...)
+	}
+	return entries
+}
+
+func TestProcessPPTX_UnchangedPartsKeepOriginalBytes(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	// Only slide 1 is processed, and it has no accent1 references, so nothing changes
+	filesProcessed, _, err := ProcessPPTX(testPPTX, outputPath, map[string]string{"accent1": "accent6"}, nil, "content", []int{1})
+	if err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+	if filesProcessed != 1 {
+		t.Errorf("expected 1 file processed, got %d", filesProcessed)
+	}
+
+	input := readRawEntries(t, testPPTX)
+	output := readRawEntries(t, outputPath)
+
+	if len(input) != len(output) {
+		t.Fatalf("expected %d entries, got %d", len(input), len(output))
+	}
+	for name, raw := range input {
+		if !bytes.Equal(raw, output[name]) {
+			t.Errorf("%s: raw bytes differ from input", name)
+		}
+	}
+}
+
+func TestProcessPPTX_ChangedPartsRewritten(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	// Slide 2 uses accent1, so it must be rewritten; slide 1 does not
+	if _, _, err := ProcessPPTX(testPPTX, outputPath, map[string]string{"accent1": "accent6"}, nil, "content", []int{1, 2}); err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+
+	input := readRawEntries(t, testPPTX)
+	output := readRawEntries(t, outputPath)
+
+	if bytes.Equal(input["ppt/slides/slide2.xml"], output["ppt/slides/slide2.xml"]) {
+		t.Error("expected slide2.xml to be rewritten")
+	}
+	if !bytes.Equal(input["ppt/slides/slide1.xml"], output["ppt/slides/slide1.xml"]) {
+		t.Error("expected slide1.xml to keep its original bytes")
+	}
+	if !bytes.Equal(input["docProps/thumbnail.jpeg"], output["docProps/thumbnail.jpeg"]) {
+		t.Error("expected binary parts to keep their original bytes")
+	}
+}
+
+func TestProcessPPTX_PreservesPackageEntries(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         2,
+		ColorsPerSlide: 4,
+		Parts: map[string]string{
+			"docProps/":           "",
+			"docProps/core.xml":   `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"/>`,
+			"docProps/app.xml":    `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"/>`,
+			"customXml/item1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><root/>`,
+			"customXml/_rels/":    "",
+			"ppt/media/":          "",
+			"ppt/slides/":         "",
+		},
+	})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	if _, _, err := ProcessPPTX(inputPath, outputPath, map[string]string{"accent1": "FF0000"}, nil, "all", nil); err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+
+	input := readRawEntries(t, inputPath)
+	output := readRawEntries(t, outputPath)
+
+	// Output entry set must be a superset of the input's
+	for name := range input {
+		if _, ok := output[name]; !ok {
+			t.Errorf("output is missing %s", name)
+		}
+	}
+
+	// Parts outside ppt/ must be copied through unchanged
+	for _, name := range []string{"_rels/.rels", "[Content_Types].xml", "docProps/core.xml", "docProps/app.xml", "customXml/item1.xml"} {
+		if !bytes.Equal(input[name], output[name]) {
+			t.Errorf("%s: expected original bytes", name)
+		}
+	}
+
+	if err := VerifyOutput(inputPath, outputPath); err != nil {
+		t.Errorf("VerifyOutput() error = %v", err)
+	}
+}
+
+// readZipEntry returns the uncompressed content of a single ZIP entry
+func readZipEntry(t *testing.T, path, name string) []byte {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+
+	rc, err := zipReader.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestProcessPPTX_SmartArtColorsAndDrawingCacheStayConsistent(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	// Slide 3 holds a SmartArt diagram: colors1.xml defines its color transform and
+	// drawing1.xml caches the rendered shapes. Both reference accent1 and must be
+	// swapped together, whether selected by slide or by scope.
+	tests := []struct {
+		name   string
+		scope  string
+		slides []int
+	}{
+		{"slide filter", "content", []int{3}},
+		{"content scope", "content", nil},
+		{"all scope", "all", nil},
+	}
+
+	diagramParts := []string{"ppt/diagrams/colors1.xml", "ppt/diagrams/drawing1.xml"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+			if _, _, err := ProcessPPTX(testPPTX, outputPath, map[string]string{"accent1": "accent5"}, nil, tt.scope, tt.slides); err != nil {
+				t.Fatalf("ProcessPPTX failed: %v", err)
+			}
+
+			for _, part := range diagramParts {
+				before, err := extractSchemeColors(readZipEntry(t, testPPTX, part))
+				if err != nil {
+					t.Fatal(err)
+				}
+				after, err := extractSchemeColors(readZipEntry(t, outputPath, part))
+				if err != nil {
+					t.Fatalf("%s: output is not valid XML: %v", part, err)
+				}
+
+				if !containsString(before, "accent1") {
+					t.Fatalf("%s: fixture expected to reference accent1", part)
+				}
+				if containsString(after, "accent1") {
+					t.Errorf("%s: accent1 should have been swapped", part)
+				}
+				if len(before) != len(after) {
+					t.Errorf("%s: scheme color count changed from %d to %d", part, len(before), len(after))
+				}
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_IncludeTableStyles(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		wantChanged bool
+	}{
+		{name: "default skips table styles", opts: Options{}, wantChanged: false},
+		{name: "include table styles", opts: Options{IncludeTableStyles: true}, wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+			if _, _, err := ProcessPPTXWithOptions(testPPTX, outputPath, map[string]string{"accent1": "accent6"}, nil, "all", nil, tt.opts); err != nil {
+				t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+			}
+
+			tableStyles := string(readZipEntry(t, outputPath, "ppt/tableStyles.xml"))
+			changed := !strings.Contains(tableStyles, `val="accent1"`)
+			if changed != tt.wantChanged {
+				t.Errorf("tableStyles.xml changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if tt.wantChanged && !strings.Contains(tableStyles, `val="accent6"`) {
+				t.Error("expected accent1 in tableStyles.xml to be swapped to accent6")
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_TableStyleFills(t *testing.T) {
+	// A custom table style with a table background, banded rows, and a header row
+	tableStyles := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<a:tblStyleLst xmlns:a="` + drawingmlNS + `" def="{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}">` +
+		`<a:tblStyle styleId="{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}" styleName="Custom">` +
+		`<a:tblBg><a:fillRef idx="2"><a:schemeClr val="accent1"/></a:fillRef></a:tblBg>` +
+		`<a:wholeTbl><a:tcTxStyle><a:schemeClr val="dk1"/></a:tcTxStyle><a:tcStyle><a:fill><a:solidFill><a:schemeClr val="accent1"><a:tint val="20000"/></a:schemeClr></a:solidFill></a:fill></a:tcStyle></a:wholeTbl>` +
+		`<a:band1H><a:tcStyle><a:fill><a:solidFill><a:schemeClr val="accent1"><a:tint val="40000"/></a:schemeClr></a:solidFill></a:fill></a:tcStyle></a:band1H>` +
+		`<a:firstRow><a:tcTxStyle b="on"><a:schemeClr val="lt1"/></a:tcTxStyle><a:tcStyle><a:fill><a:solidFill><a:srgbClr val="AABBCC"/></a:solidFill></a:fill></a:tcStyle></a:firstRow>` +
+		`</a:tblStyle></a:tblStyleLst>`
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/tableStyles.xml": tableStyles},
+	})
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	mapping := map[string]string{"accent1": "accent4", "AABBCC": "accent2"}
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "all", nil, Options{IncludeTableStyles: true}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+
+	result := string(readZipEntry(t, outputPath, "ppt/tableStyles.xml"))
+	for _, want := range []string{
+		`<a:tblBg><a:fillRef idx="2"><a:schemeClr val="accent4"/></a:fillRef></a:tblBg>`,
+		`<a:schemeClr val="accent4"><a:tint val="20000"/></a:schemeClr>`,
+		`<a:band1H><a:tcStyle><a:fill><a:solidFill><a:schemeClr val="accent4"><a:tint val="40000"/></a:schemeClr>`,
+		`<a:fill><a:solidFill><a:schemeClr val="accent2"/></a:solidFill></a:fill></a:tcStyle></a:firstRow>`,
+		`<a:tcTxStyle><a:schemeClr val="dk1"/></a:tcTxStyle>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("tableStyles.xml missing %s:\n%s", want, result)
+		}
+	}
+}
+
+func TestProcessPPTX_IncludeTheme(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 4})
+	mapping := map[string]string{"accent1": "FF0000"}
+
+	// Default: theme definition untouched
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if _, _, err := ProcessPPTX(inputPath, outputPath, mapping, nil, "all", nil); err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+	themes, err := ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if themes[0].Colors.Accent1 != "4F81BD" {
+		t.Errorf("accent1 = %s, expected theme to be untouched by default", themes[0].Colors.Accent1)
+	}
+
+	// IncludeTheme: accent1 definition repainted as well
+	outputPath = filepath.Join(t.TempDir(), "output.pptx")
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "all", nil, Options{IncludeTheme: true}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+	themes, err = ReadThemes(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if themes[0].Colors.Accent1 != "FF0000" {
+		t.Errorf("accent1 = %s, want FF0000", themes[0].Colors.Accent1)
+	}
+	if err := VerifyOutput(inputPath, outputPath); err != nil {
+		t.Errorf("VerifyOutput() error = %v", err)
+	}
+
+	// Unknown theme filter is rejected
+	outputPath = filepath.Join(t.TempDir(), "output.pptx")
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, []string{"theme2"}, "all", nil, Options{IncludeTheme: true}); err == nil {
+		t.Error("expected error for unknown theme filter")
+	}
+
+	// Slide filter is rejected
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "content", []int{1}, Options{IncludeTheme: true}); err == nil {
+		t.Error("expected error when combining IncludeTheme with a slide filter")
+	}
+}
+
+func TestProcessPPTX_ThemeScope(t *testing.T) {
+	// A theme whose format scheme references accent1
+	themeXML := strings.Replace(syntheticThemeXML("Synthetic Theme", "Synthetic"),
+		`</a:clrScheme>`,
+		`</a:clrScheme><a:fmtScheme name="Synthetic"><a:fillStyleLst><a:solidFill><a:schemeClr val="accent1"/></a:solidFill></a:fillStyleLst></a:fmtScheme>`, 1)
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         2,
+		ColorsPerSlide: 4,
+		Parts:          map[string]string{"ppt/theme/theme1.xml": themeXML},
+	})
+
+	tests := []struct {
+		name        string
+		mapping     map[string]string
+		wantAccent1 string
+		wantAccent2 string
+		wantFmt     string
+	}{
+		{
+			name:        "scheme to hex",
+			mapping:     map[string]string{"accent1": "FF0000"},
+			wantAccent1: "FF0000",
+			wantAccent2: "C0504D",
+			wantFmt:     `<a:solidFill><a:srgbClr val="FF0000"/></a:solidFill></a:fillStyleLst>`,
+		},
+		{
+			name:        "hex swap applied once",
+			mapping:     map[string]string{"4F81BD": "C0504D", "C0504D": "4F81BD"},
+			wantAccent1: "C0504D",
+			wantAccent2: "4F81BD",
+			wantFmt:     `<a:schemeClr val="accent1"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if _, _, err := ProcessPPTX(inputPath, outputPath, tt.mapping, nil, "theme", nil); err != nil {
+				t.Fatalf("ProcessPPTX failed: %v", err)
+			}
+
+			themes, err := ReadThemes(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if themes[0].Colors.Accent1 != tt.wantAccent1 || themes[0].Colors.Accent2 != tt.wantAccent2 {
+				t.Errorf("accent1, accent2 = %s, %s; want %s, %s",
+					themes[0].Colors.Accent1, themes[0].Colors.Accent2, tt.wantAccent1, tt.wantAccent2)
+			}
+			if theme := string(readZipEntry(t, outputPath, "ppt/theme/theme1.xml")); !strings.Contains(theme, tt.wantFmt) {
+				t.Errorf("format scheme missing %q:\n%s", tt.wantFmt, theme)
+			}
+
+			// Slides are out of scope
+			if slide, orig := readZipEntry(t, outputPath, "ppt/slides/slide1.xml"), readZipEntry(t, inputPath, "ppt/slides/slide1.xml"); string(slide) != string(orig) {
+				t.Error("slide1.xml changed with --scope theme")
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_LargeMediaRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large media test in short mode")
+	}
+
+	// Incompressible payload, large enough that buffering it would be noticeable
+	const mediaSize = 64 << 20
+	media := make([]byte, mediaSize)
+	rand.New(rand.NewSource(1)).Read(media)
+
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         1,
+		ColorsPerSlide: 4,
+		Parts:          map[string]string{"ppt/media/media1.mp4": string(media)},
+	})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	if _, _, err := ProcessPPTX(inputPath, outputPath, map[string]string{"accent1": "FF0000"}, nil, "all", nil); err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+
+	headers := func(path string) *zip.FileHeader {
+		zipReader, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zipReader.Close()
+		for _, file := range zipReader.File {
+			if file.Name == "ppt/media/media1.mp4" {
+				header := file.FileHeader
+				return &header
+			}
+		}
+		t.Fatalf("%s: media entry not found", path)
+		return nil
+	}
+
+	in, out := headers(inputPath), headers(outputPath)
+	if out.UncompressedSize64 != mediaSize {
+		t.Errorf("media size = %d, want %d", out.UncompressedSize64, mediaSize)
+	}
+	if in.CRC32 != out.CRC32 || in.CompressedSize64 != out.CompressedSize64 {
+		t.Error("expected media entry to be copied verbatim")
+	}
+	if !bytes.Equal(readZipEntry(t, outputPath, "ppt/media/media1.mp4"), media) {
+		t.Error("media content changed")
+	}
+}
+
+func TestProcessPPTX_ThemeMappings(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	opts := Options{
+		ThemeMappings: map[string]map[string]string{
+			"theme1.xml": {"accent1": "FF0000"},
+			"theme2.xml": {"accent1": "0000FF"},
+		},
+	}
+
+	// General mapping applies everywhere, theme mappings on top
+	if _, _, err := ProcessPPTXWithOptions(testPPTX, outputPath, map[string]string{"accent2": "accent5"}, nil, "all", nil, opts); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+
+	tests := []struct {
+		part string
+		want string
+		not  string
+	}{
+		{part: "ppt/slides/slide2.xml", want: `val="FF0000"`, not: `val="0000FF"`},        // theme1 slide
+		{part: "ppt/diagrams/colors1.xml", want: `val="FF0000"`, not: `val="0000FF"`},     // owned by a theme1 slide
+		{part: "ppt/slides/slide8.xml", want: `val="0000FF"`, not: `val="FF0000"`},        // theme2 slide
+		{part: "ppt/slides/slide11.xml", want: `schemeClr val="accent1"`, not: `FF0000"`}, // theme3 slide: untouched
+	}
+	for _, tt := range tests {
+		content := string(readZipEntry(t, outputPath, tt.part))
+		if !strings.Contains(content, tt.want) {
+			t.Errorf("%s: expected %s", tt.part, tt.want)
+		}
+		if strings.Contains(content, tt.not) {
+			t.Errorf("%s: did not expect %s", tt.part, tt.not)
+		}
+	}
+
+	// Unknown themes are rejected
+	opts.ThemeMappings = map[string]map[string]string{"theme9.xml": {"accent1": "FF0000"}}
+	if _, _, err := ProcessPPTXWithOptions(testPPTX, outputPath, nil, nil, "all", nil, opts); err == nil {
+		t.Error("expected error for unknown theme in theme mappings")
+	}
+}
+
+func TestProcessPPTX_Replacements(t *testing.T) {
+	// Two slides with accent1, AABBCC, accent2 (lumMod), FF0000 each; the layout uses accent1
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 4})
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		opts    Options
+		want    int
+	}{
+		{name: "scheme and hex sources", mapping: map[string]string{"accent1": "accent3", "AABBCC": "123456"}, want: 5},
+		{name: "source never used", mapping: map[string]string{"accent6": "accent3"}, want: 0},
+		{name: "identity mapping", mapping: map[string]string{"accent1": "accent1"}, want: 0},
+		{name: "theme slot recolored too", mapping: map[string]string{"accent1": "FF00FF"}, opts: Options{IncludeTheme: true}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replacements int
+			tt.opts.Replacements = &replacements
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			filesProcessed, _, err := ProcessPPTXWithOptions(inputPath, outputPath, tt.mapping, nil, "all", nil, tt.opts)
+			if err != nil {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+			}
+			if filesProcessed == 0 {
+				t.Error("expected files to be processed")
+			}
+			if replacements != tt.want {
+				t.Errorf("Replacements = %d, want %d", replacements, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_NoCascadeAcrossPasses(t *testing.T) {
+	// Slides with accent1, AABBCC, accent2 (lumMod), FF0000 each
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 4})
+
+	// A scheme→hex target that is also a hex source must not be mapped a second time
+	mapping := map[string]string{
+		"accent1": "AABBCC", "AABBCC": "accent1", // Cycle
+		"accent2": "FF0000", "FF0000": "00FF00", // Chain
+	}
+	record := NewChangeLog(mapping)
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "content", nil, Options{Record: record}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+	}
+
+	slide := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml"))
+	want := `<a:srgbClr val="AABBCC"/>.*<a:schemeClr val="accent1"/>.*<a:srgbClr val="FF0000"/>.*<a:srgbClr val="00FF00"/>`
+	if !regexp.MustCompile(want).MatchString(slide) {
+		t.Errorf("slide = %s, want each reference changed once (%s)", slide, want)
+	}
+
+	// Recorded offsets still undo the swap exactly
+	restoredPath := filepath.Join(t.TempDir(), "restored.pptx")
+	if _, err := UndoChanges(outputPath, restoredPath, record); err != nil {
+		t.Fatalf("UndoChanges() error = %v", err)
+	}
+	if got, want := readZipEntry(t, restoredPath, "ppt/slides/slide1.xml"), readZipEntry(t, inputPath, "ppt/slides/slide1.xml"); !bytes.Equal(got, want) {
+		t.Errorf("undo restored %s, want %s", got, want)
+	}
+}
+
+func TestProcessPPTX_ThemeImpacts(t *testing.T) {
+	synthetic := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 4})
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		opts    Options
+		want    []ThemeImpact
+	}{
+		{
+			name:    "slides, layout, and theme slot",
+			mapping: map[string]string{"accent1": "FF00FF"},
+			opts:    Options{IncludeTheme: true},
+			want:    []ThemeImpact{{Theme: "theme1.xml", Files: 4, Replacements: 4}},
+		},
+		{
+			name:    "nothing replaced",
+			mapping: map[string]string{"accent6": "accent3"},
+			want:    []ThemeImpact{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replacements int
+			var impacts []ThemeImpact
+			tt.opts.Replacements = &replacements
+			tt.opts.ThemeImpacts = &impacts
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if _, _, err := ProcessPPTXWithOptions(synthetic, outputPath, tt.mapping, nil, "all", nil, tt.opts); err != nil {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+			}
+			if !reflect.DeepEqual(impacts, tt.want) {
+				t.Errorf("ThemeImpacts = %+v, want %+v", impacts, tt.want)
+			}
+
+			total := 0
+			for _, impact := range impacts {
+				total += impact.Replacements
+			}
+			if total != replacements {
+				t.Errorf("ThemeImpacts replacements sum to %d, want %d", total, replacements)
+			}
+		})
+	}
+
+	// Every theme in use is reported, each once, in file order
+	var impacts []ThemeImpact
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if _, _, err := ProcessPPTXWithOptions("testdata/test.pptx", outputPath, map[string]string{"accent1": "FF0000"}, nil, "all", nil, Options{ThemeImpacts: &impacts}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+	}
+	var themes []string
+	for _, impact := range impacts {
+		themes = append(themes, impact.Theme)
+	}
+	if want := []string{"theme1.xml", "theme2.xml", "theme3.xml"}; !reflect.DeepEqual(themes, want) {
+		t.Errorf("ThemeImpacts themes = %v, want %v", themes, want)
+	}
+}
+
+func TestWritePPTX_FailureKeepsExistingOutput(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outDir := t.TempDir()
+	outputPath := filepath.Join(outDir, "output.pptx")
+	if err := os.WriteFile(outputPath, []byte("existing deck"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// A changed part missing from the extraction directory fails mid-write
+	err := writePPTX(inputPath, outputPath, t.TempDir(), map[string]bool{"ppt/slides/slide1.xml": true})
+	if err == nil {
+		t.Fatal("expected writePPTX to fail")
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil || string(content) != "existing deck" {
+		t.Errorf("existing output = %q, %v; want it untouched", content, err)
+	}
+	entries, _ := os.ReadDir(outDir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, found %d entries", len(entries))
+	}
+
+	// A successful write replaces the output and keeps its permissions
+	if err := writePPTX(inputPath, outputPath, t.TempDir(), nil); err != nil {
+		t.Fatalf("writePPTX() error = %v", err)
+	}
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("output mode = %v, want 0640", info.Mode().Perm())
+	}
+	if _, err := ReadThemes(outputPath); err != nil {
+		t.Errorf("output is not a valid package: %v", err)
+	}
+}
+
+func TestEditPackage(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 2})
+	input := readRawEntries(t, inputPath)
+
+	// Only the part the edit rewrote is recompressed
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	err := editPackage(inputPath, outputPath, func(tempDir string) (map[string]bool, error) {
+		return map[string]bool{"ppt/slides/slide1.xml": true}, os.WriteFile(filepath.Join(tempDir, "ppt", "slides", "slide1.xml"), []byte("<edited/>"), 0644)
+	})
+	if err != nil {
+		t.Fatalf("editPackage() error = %v", err)
+	}
+	if got := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml")); got != "<edited/>" {
+		t.Errorf("slide1.xml = %q, want the edited content", got)
+	}
+	output := readRawEntries(t, outputPath)
+	if len(output) != len(input) {
+		t.Fatalf("expected %d entries, got %d", len(input), len(output))
+	}
+	for name, raw := range input {
+		if name != "ppt/slides/slide1.xml" && !bytes.Equal(raw, output[name]) {
+			t.Errorf("%s: raw bytes differ from input", name)
+		}
+	}
+
+	// Parts are found by the set the edit returns, not by file times
+	touchedPath := filepath.Join(t.TempDir(), "touched.pptx")
+	err = editPackage(inputPath, touchedPath, func(tempDir string) (map[string]bool, error) {
+		slide := filepath.Join(tempDir, "ppt", "slides", "slide2.xml")
+		if err := os.WriteFile(slide, []byte("<edited/>"), 0644); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"ppt/slides/slide2.xml": true}, os.Chtimes(slide, time.Unix(0, 0), time.Unix(0, 0))
+	})
+	if err != nil {
+		t.Fatalf("editPackage() error = %v", err)
+	}
+	if got := string(readZipEntry(t, touchedPath, "ppt/slides/slide2.xml")); got != "<edited/>" {
+		t.Errorf("slide2.xml = %q, want the edited content whatever its file time", got)
+	}
+
+	// A failed edit writes nothing
+	failedPath := filepath.Join(t.TempDir(), "failed.pptx")
+	err = editPackage(inputPath, failedPath, func(tempDir string) (map[string]bool, error) {
+		return nil, fmt.Errorf("edit failed")
+	})
+	if err == nil || err.Error() != "edit failed" {
+		t.Errorf("editPackage() error = %v, want the edit's error", err)
+	}
+	if _, err := os.Stat(failedPath); !os.IsNotExist(err) {
+		t.Error("expected no output after a failed edit")
+	}
+
+	// A missing input is reported before extraction
+	err = editPackage(filepath.Join(t.TempDir(), "missing.pptx"), outputPath, func(tempDir string) (map[string]bool, error) {
+		t.Error("edit called for a missing input")
+		return nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "input file not found") {
+		t.Errorf("editPackage() error = %v, want input file not found", err)
+	}
+}
+
+func TestExtractPPTX_RejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"../evil.xml", "ppt/../../evil.xml", "/abs/evil.xml"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			inputPath := filepath.Join(dir, "input.pptx")
+			f, err := os.Create(inputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := zip.NewWriter(f)
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("<evil/>"))
+			zw.Close()
+			f.Close()
+
+			destDir := filepath.Join(dir, "extract")
+			if err := extractPPTX(inputPath, destDir); err == nil {
+				t.Fatal("expected extractPPTX to reject the entry")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil.xml")); !os.IsNotExist(err) {
+				t.Error("entry was written outside the destination")
+			}
+		})
+	}
+}
+
+func TestProcessDir(t *testing.T) {
+	inputPath := filepath.Join("testdata", "test.pptx")
+	dir := t.TempDir()
+	if err := extractPPTX(inputPath, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	themes, err := ReadThemesDir(dir)
+	if err != nil {
+		t.Fatalf("ReadThemesDir() error = %v", err)
+	}
+	want, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatalf("ReadThemes() error = %v", err)
+	}
+	if !reflect.DeepEqual(themes, want) {
+		t.Errorf("ReadThemesDir() differs from ReadThemes()")
+	}
+
+	// Swapping the directory leaves the parts a zip-based swap writes
+	mapping := map[string]string{"accent1": "accent3", "FF0000": "accent2"}
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	wantFiles, _, err := ProcessPPTX(inputPath, outputPath, mapping, []string{"theme1"}, "all", nil)
+	if err != nil {
+		t.Fatalf("ProcessPPTX() error = %v", err)
+	}
+	files, _, err := ProcessDir(dir, mapping, []string{"theme1"}, "all", nil, Options{})
+	if err != nil {
+		t.Fatalf("ProcessDir() error = %v", err)
+	}
+	if files != wantFiles {
+		t.Errorf("ProcessDir() processed %d files, want %d", files, wantFiles)
+	}
+
+	zipReader, err := zip.OpenReader(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, readZipEntry(t, outputPath, file.Name)) {
+			t.Errorf("%s differs from the zip-based swap", file.Name)
+		}
+	}
+
+	if _, _, err := ProcessDir(filepath.Join(dir, "missing"), mapping, nil, "all", nil, Options{}); err == nil {
+		t.Error("ProcessDir() on a missing directory should fail")
+	}
+}
+
+func TestCheckThemeFilter(t *testing.T) {
+	fixture := filepath.Join("testdata", "test.pptx")
+
+	// The archive and an extraction agree on which theme each master uses
+	zipReader, err := zip.OpenReader(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+	fromZip, err := readMasterThemes(&zipReader.Reader, zipDocumentRoot(zipReader.File))
+	if err != nil {
+		t.Fatalf("readMasterThemes() error = %v", err)
+	}
+	var fromDir map[string]string
+	withExtractedPPTX(fixture, func(tempDir string) error {
+		fromDir, err = buildThemeRelationships(tempDir)
+		return err
+	})
+	if len(fromZip) == 0 || !reflect.DeepEqual(fromZip, fromDir) {
+		t.Errorf("readMasterThemes() = %v, want %v", fromZip, fromDir)
+	}
+
+	// A copy of the fixture that extraction refuses, so only a check made before
+	// extracting can report the theme
+	inputPath := filepath.Join(t.TempDir(), "unsafe.pptx")
+	f, err := os.Create(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range zipReader.File {
+		if err := zw.Copy(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, _ := zw.Create("../evil.xml")
+	w.Write([]byte("<evil/>"))
+	zw.Close()
+	f.Close()
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	mapping := map[string]string{"accent1": "accent2"}
+	var notFound *ErrThemeNotFound
+
+	_, _, err = ProcessPPTX(inputPath, outputPath, mapping, []string{"theme9"}, "all", nil)
+	if !errors.As(err, &notFound) {
+		t.Errorf("ProcessPPTX() with an unknown theme error = %v, want ErrThemeNotFound", err)
+	}
+	_, _, err = ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "all", nil, Options{ThemeMappings: map[string]map[string]string{"theme9.xml": mapping}})
+	if !errors.As(err, &notFound) {
+		t.Errorf("ProcessPPTXWithOptions() with an unknown theme mapping error = %v, want ErrThemeNotFound", err)
+	}
+	if _, err := RenameColorScheme(inputPath, outputPath, "Renamed", []string{"theme9"}); !errors.As(err, &notFound) {
+		t.Errorf("RenameColorScheme() with an unknown theme error = %v, want ErrThemeNotFound", err)
+	}
+
+	// A valid filter gets as far as extraction
+	_, _, err = ProcessPPTX(inputPath, outputPath, mapping, []string{"theme1"}, "all", nil)
+	if err == nil || !strings.Contains(err.Error(), "unsafe entry") {
+		t.Errorf("ProcessPPTX() with a valid theme error = %v, want the extraction error", err)
+	}
+}
+
+func TestValidateTempDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{name: "writable directory", dir: dir},
+		{name: "missing directory", dir: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "file", dir: file, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTempDir(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTempDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var invalid *ErrInvalidTempDir
+			if tt.wantErr && !errors.As(err, &invalid) {
+				t.Errorf("ValidateTempDir() error = %v, want ErrInvalidTempDir", err)
+			}
+		})
+	}
+
+	// The probe file must not be left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("ValidateTempDir() left %d entries in the directory, want 1", len(entries))
+	}
+}
+
+func TestProcessPPTX_TempDir(t *testing.T) {
+	tempRoot := t.TempDir()
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	opts := Options{TempDir: tempRoot}
+	if _, _, err := ProcessPPTXWithOptions(filepath.Join("testdata", "test.pptx"), outputPath, map[string]string{"accent1": "accent2"}, nil, "all", nil, opts); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(tempRoot); len(entries) != 0 {
+		t.Errorf("extraction left %d entries in TempDir", len(entries))
+	}
+
+	opts.TempDir = filepath.Join(tempRoot, "missing")
+	_, _, err := ProcessPPTXWithOptions(filepath.Join("testdata", "test.pptx"), outputPath, map[string]string{"accent1": "accent2"}, nil, "all", nil, opts)
+	var invalid *ErrInvalidTempDir
+	if !errors.As(err, &invalid) {
+		t.Errorf("ProcessPPTXWithOptions() with a missing TempDir error = %v, want ErrInvalidTempDir", err)
+	}
+}
+
+func TestProcessPPTX_PartGlobs(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("Test file not found")
+	}
+
+	tests := []struct {
+		name      string
+		scope     string
+		include   []string
+		exclude   []string
+		wantParts func(part string) bool // Parts that may change
+		wantSome  string                 // Prefix of a part that must change
+		wantErr   bool
+	}{
+		{
+			name:      "only charts",
+			scope:     "all",
+			include:   []string{"ppt/charts/*"},
+			wantParts: func(part string) bool { return strings.HasPrefix(part, "ppt/charts/") },
+			wantSome:  "ppt/charts/",
+		},
+		{
+			name:      "exclude notes",
+			scope:     "content",
+			exclude:   []string{"ppt/notesSlides/*"},
+			wantParts: func(part string) bool { return !strings.HasPrefix(part, "ppt/notesSlides/") },
+			wantSome:  "ppt/slides/",
+		},
+		{
+			name:      "include outside scope matches nothing",
+			scope:     "master",
+			include:   []string{"ppt/charts/*"},
+			wantParts: func(part string) bool { return false },
+		},
+		{
+			name:    "invalid pattern",
+			scope:   "all",
+			include: []string{"ppt/[charts"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			_, _, err := ProcessPPTXWithOptions(testPPTX, outputPath, map[string]string{"accent1": "FF00FF", "accent2": "FF00FF"},
+				nil, tt.scope, nil, Options{Include: tt.include, Exclude: tt.exclude})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			changed := changedParts(t, testPPTX, outputPath)
+			sawExpected := tt.wantSome == ""
+			for _, part := range changed {
+				if !tt.wantParts(part) {
+					t.Errorf("%s changed but should have been filtered out", part)
+				}
+				if tt.wantSome != "" && strings.HasPrefix(part, tt.wantSome) {
+					sawExpected = true
+				}
+			}
+			if !sawExpected {
+				t.Errorf("expected some %s part to change, changed: %v", tt.wantSome, changed)
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_Sections(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 3, ColorsPerSlide: 2, Parts: map[string]string{
+		"ppt/presentation.xml": sectionedPresentationXML(3, map[string][]int{
+			"Intro": {256, 257},
+			"Close": {258},
+			"Empty": {},
+		}, "Intro", "Close", "Empty"),
+	}})
+
+	tests := []struct {
+		name       string
+		slides     []int
+		opts       Options
+		wantSlides []string // Slide parts that change
+		wantErr    bool
+	}{
+		{name: "section", opts: Options{Sections: []string{"Close"}}, wantSlides: []string{"ppt/slides/slide3.xml"}},
+		{name: "added to slides", slides: []int{1}, opts: Options{Sections: []string{"Close"}}, wantSlides: []string{"ppt/slides/slide1.xml", "ppt/slides/slide3.xml"}},
+		{name: "overlapping slides", slides: []int{2}, opts: Options{Sections: []string{"Intro"}}, wantSlides: []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml"}},
+		{name: "empty section", opts: Options{Sections: []string{"Empty"}}, wantErr: true},
+		{name: "unknown section", opts: Options{Sections: []string{"Outro"}}, wantErr: true},
+		{name: "with theme colors", opts: Options{Sections: []string{"Close"}, IncludeTheme: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "accent3"}, nil, "content", tt.slides, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var slides []string
+			for _, part := range changedParts(t, inputPath, outputPath) {
+				if strings.HasPrefix(part, "ppt/slides/") {
+					slides = append(slides, part)
+				}
+			}
+			sort.Strings(slides)
+			if !reflect.DeepEqual(slides, tt.wantSlides) {
+				t.Errorf("changed slides = %v, want %v", slides, tt.wantSlides)
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_SkipSlideContent(t *testing.T) {
+	// Slide 1 has speaker notes and a chart, each with an accent1 reference
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 2, Parts: map[string]string{
+		"ppt/slides/_rels/slide1.xml.rels": syntheticRelsXML(
+			[3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"},
+			[3]string{"rId2", "notesSlide", "../notesSlides/notesSlide1.xml"},
+			[3]string{"rId3", "chart", "../charts/chart1.xml"},
+		),
+		"ppt/notesSlides/notesSlide1.xml": `<p:notes xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><a:solidFill><a:schemeClr val="accent1"/></a:solidFill></p:notes>`,
+		"ppt/charts/chart1.xml":           `<c:chartSpace xmlns:a="` + drawingmlNS + `" xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"><a:solidFill><a:schemeClr val="accent1"/></a:solidFill></c:chartSpace>`,
+	}})
+
+	tests := []struct {
+		name      string
+		scope     string
+		slides    []int
+		opts      Options
+		wantNotes bool // Whether the notes slide changes
+		wantChart bool // Whether the chart changes
+	}{
+		{name: "no notes, content scope", scope: "content", opts: Options{NoNotes: true}, wantChart: true},
+		{name: "no notes, all scope", scope: "all", opts: Options{NoNotes: true}, wantChart: true},
+		{name: "no notes pulled in by slide filter", scope: "content", slides: []int{1}, opts: Options{NoNotes: true}, wantChart: true},
+		{name: "no charts pulled in by slide filter", scope: "content", slides: []int{1}, opts: Options{NoCharts: true}, wantNotes: true},
+		{name: "no charts, all scope", scope: "all", opts: Options{NoCharts: true}, wantNotes: true},
+		{name: "everything by default", scope: "content", slides: []int{1}, wantNotes: true, wantChart: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "accent3"},
+				nil, tt.scope, tt.slides, tt.opts)
+			if err != nil {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+			}
+
+			changed := make(map[string]bool)
+			for _, part := range changedParts(t, inputPath, outputPath) {
+				changed[part] = true
+			}
+			if !changed["ppt/slides/slide1.xml"] {
+				t.Errorf("slide 1 should still be processed, changed: %v", changed)
+			}
+			if changed["ppt/notesSlides/notesSlide1.xml"] != tt.wantNotes {
+				t.Errorf("notes slide changed = %v, want %v", changed["ppt/notesSlides/notesSlide1.xml"], tt.wantNotes)
+			}
+			if changed["ppt/charts/chart1.xml"] != tt.wantChart {
+				t.Errorf("chart changed = %v, want %v", changed["ppt/charts/chart1.xml"], tt.wantChart)
+			}
+		})
+	}
+}
+
+// changedParts returns the archive paths whose content differs between two packages
+func changedParts(t *testing.T, inputPath, outputPath string) []string {
+	t.Helper()
+
+	input, err := zip.OpenReader(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+
+	var changed []string
+	for _, file := range input.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		if !bytes.Equal(readZipEntry(t, inputPath, file.Name), readZipEntry(t, outputPath, file.Name)) {
+			changed = append(changed, file.Name)
+		}
+	}
+	return changed
+}
+
+func TestProcessPPTX_MatchSysClr(t *testing.T) {
+	sysClrSlide := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>` +
+		`<p:sp><p:spPr><a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill></p:spPr></p:sp>` +
+		`<p:sp><p:spPr><a:solidFill><a:sysClr val="window" lastClr="FFFFFF"><a:alpha val="50000"/></a:sysClr></a:solidFill></p:spPr></p:sp>` +
+		`</p:spTree></p:cSld></p:sld>`
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/slides/slide1.xml": sysClrSlide},
+	})
+	mapping := map[string]string{"000000": "1F2937", "FFFFFF": "accent2"}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    []string
+		wantNot []string
+	}{
+		{
+			name:    "default leaves sysClr untouched",
+			want:    []string{`<a:sysClr val="windowText" lastClr="000000"/>`, `<a:sysClr val="window" lastClr="FFFFFF">`},
+			wantNot: []string{`srgbClr`, `schemeClr`},
+		},
+		{
+			name:    "MatchSysClr replaces by lastClr",
+			opts:    Options{MatchSysClr: true},
+			want:    []string{`<a:srgbClr val="1F2937"/>`, `<a:schemeClr val="accent2"><a:alpha val="50000"/></a:schemeClr>`},
+			wantNot: []string{`sysClr`},
+		},
+		{
+			name:    "RecacheSysClr rewrites lastClr only",
+			opts:    Options{RecacheSysClr: true},
+			want:    []string{`<a:sysClr val="windowText" lastClr="1F2937"/>`, `<a:sysClr val="window" lastClr="FFFFFF">`},
+			wantNot: []string{`srgbClr`, `schemeClr`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "all", nil, tt.opts); err != nil {
+				t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+			}
+
+			slide := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml"))
+			for _, want := range tt.want {
+				if !strings.Contains(slide, want) {
+					t.Errorf("slide1.xml missing %s:\n%s", want, slide)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(slide, unwanted) {
+					t.Errorf("slide1.xml should not contain %s:\n%s", unwanted, slide)
+				}
+			}
+
+			// The theme's system colors are definitions, not references
+			theme := string(readZipEntry(t, outputPath, "ppt/theme/theme1.xml"))
+			if !strings.Contains(theme, `<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1>`) {
+				t.Errorf("theme dk1 should be untouched:\n%s", theme)
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_GradientStops(t *testing.T) {
+	gradientSlide := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>` +
+		`<p:sp><p:spPr><a:gradFill><a:gsLst>` +
+		`<a:gs pos="0"><a:schemeClr val="accent1"><a:lumMod val="60000"/><a:lumOff val="40000"/></a:schemeClr></a:gs>` +
+		`<a:gs pos="100000"><a:srgbClr val="AABBCC"/></a:gs>` +
+		`</a:gsLst><a:path path="circle"/></a:gradFill></p:spPr></p:sp>` +
+		`</p:spTree></p:cSld></p:sld>`
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/slides/slide1.xml": gradientSlide},
+	})
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	mapping := map[string]string{"accent1": "accent5", "AABBCC": "112233"}
+	if _, _, err := ProcessPPTX(inputPath, outputPath, mapping, nil, "content", nil); err != nil {
+		t.Fatalf("ProcessPPTX failed: %v", err)
+	}
+
+	slide := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml"))
+	for _, want := range []string{
+		`<a:gs pos="0"><a:schemeClr val="accent5"><a:lumMod val="60000"/><a:lumOff val="40000"/></a:schemeClr></a:gs>`,
+		`<a:gs pos="100000"><a:srgbClr val="112233"/></a:gs>`,
+	} {
+		if !strings.Contains(slide, want) {
+			t.Errorf("slide1.xml missing %s:\n%s", want, slide)
+		}
+	}
+	if err := VerifyOutput(inputPath, outputPath); err != nil {
+		t.Errorf("VerifyOutput() error = %v", err)
+	}
+}
+
+func TestProcessPPTX_NormalizeEmpty(t *testing.T) {
+	slide := func(body string) string {
+		return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>` +
+			`<p:sp><p:spPr>` + body + `</p:spPr></p:sp>` +
+			`</p:spTree></p:cSld></p:sld>`
+	}
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 2,
+		Parts: map[string]string{
+			"ppt/slides/slide1.xml": slide(`<a:solidFill><a:schemeClr val="accent1"></a:schemeClr></a:solidFill><a:ln><a:solidFill><a:srgbClr val="AABBCC"> </a:srgbClr></a:solidFill></a:ln>`),
+			// No color the mapping covers, only an empty container
+			"ppt/slides/slide2.xml": slide(`<a:solidFill><a:schemeClr val="accent4"></a:schemeClr></a:solidFill>`),
+		},
+	})
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	var replacements int
+	opts := Options{NormalizeEmpty: true, Replacements: &replacements, Record: NewChangeLog(nil)}
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "accent2"}, nil, "content", nil, opts); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+	}
+
+	if replacements != 1 {
+		t.Errorf("replacements = %d, want 1 (tidying containers is not a replacement)", replacements)
+	}
+	for part, want := range map[string]string{
+		"ppt/slides/slide1.xml": `<a:solidFill><a:schemeClr val="accent2"/></a:solidFill><a:ln><a:solidFill><a:srgbClr val="AABBCC"/></a:solidFill></a:ln>`,
+		"ppt/slides/slide2.xml": `<a:solidFill><a:schemeClr val="accent4"/></a:solidFill>`,
+	} {
+		if got := string(readZipEntry(t, outputPath, part)); !strings.Contains(got, want) {
+			t.Errorf("%s missing %s:\n%s", part, want, got)
+		}
+	}
+
+	// The tidying is recorded, so undo restores the original bytes
+	restoredPath := filepath.Join(t.TempDir(), "restored.pptx")
+	if _, err := UndoChanges(outputPath, restoredPath, opts.Record); err != nil {
+		t.Fatalf("UndoChanges() error = %v", err)
+	}
+	for _, part := range []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml"} {
+		if !bytes.Equal(readZipEntry(t, restoredPath, part), readZipEntry(t, inputPath, part)) {
+			t.Errorf("%s was not restored by undo", part)
+		}
+	}
+}
+
+func TestProcessPPTX_ChartDataPoints(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("Test file not found")
+	}
+
+	// chart1.xml (slide 4) overrides the color of each pie slice with <c:dPt>
+	tests := []struct {
+		name   string
+		slides []int
+	}{
+		{name: "content scope"},
+		{name: "slide filter", slides: []int{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			mapping := map[string]string{"tx1": "FF0000", "bg2": "accent6"}
+			if _, _, err := ProcessPPTX(testPPTX, outputPath, mapping, nil, "content", tt.slides); err != nil {
+				t.Fatalf("ProcessPPTX failed: %v", err)
+			}
+
+			chart := string(readZipEntry(t, outputPath, "ppt/charts/chart1.xml"))
+			for _, want := range []string{
+				`<c:dPt><c:idx val="1"/><c:bubble3D val="0"/><c:spPr><a:solidFill><a:srgbClr val="FF0000"/></a:solidFill>`,
+				`<c:dPt><c:idx val="2"/><c:bubble3D val="0"/><c:spPr><a:solidFill><a:schemeClr val="accent6"/></a:solidFill>`,
+			} {
+				if !strings.Contains(chart, want) {
+					t.Errorf("chart1.xml missing %s", want)
+				}
+			}
+			// Non-color val attributes in the chart namespace are left alone
+			if !strings.Contains(chart, `<c:dPt><c:idx val="0"/><c:bubble3D val="0"/><c:spPr><a:solidFill><a:schemeClr val="bg1"/>`) {
+				t.Error("chart1.xml: unmapped data point should be untouched")
+			}
+		})
+	}
+}
+
+func TestProcessPPTX_MapUnmatchedTo(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 4})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	mapping := map[string]string{"accent1": "FF0000"}
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "content", nil, Options{MapUnmatchedTo: "808080"}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+
+	// Slide colors: accent1, AABBCC, accent2 (lumMod), FF0000
+	slide := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml"))
+	if got := strings.Count(slide, `<a:srgbClr val="FF0000"/>`); got != 2 {
+		t.Errorf("FF0000 count = %d, want 2 (mapped accent1 and the explicit target left as is):\n%s", got, slide)
+	}
+	if got := strings.Count(slide, `<a:srgbClr val="808080"/>`); got != 2 {
+		t.Errorf("808080 count = %d, want 2 (AABBCC and accent2):\n%s", got, slide)
+	}
+	if strings.Contains(slide, "schemeClr") {
+		t.Errorf("no scheme references should remain:\n%s", slide)
+	}
+
+	// Invalid fallback is rejected
+	if _, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "content", nil, Options{MapUnmatchedTo: "gray"}); err == nil {
+		t.Error("expected error for invalid fallback color")
+	}
+}
+
+func TestProcessXMLPart_Errors(t *testing.T) {
+	dir := t.TempDir()
+	mapping := map[string]string{"accent1": "FF0000"}
+
+	// A part that cannot be read
+	if _, _, err := processXMLPart(filepath.Join(dir, "missing.xml"), "ppt/slides/missing.xml", mapping, partOptions{}); err == nil {
+		t.Error("processXMLPart() on a missing part succeeded, want an error")
+	}
+
+	// A part that cannot be rewritten
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions do not stop root from writing")
+	}
+	readOnly := filepath.Join(dir, "slide1.xml")
+	if err := os.WriteFile(readOnly, []byte(syntheticSlideXML(2)), 0444); err != nil {
+		t.Fatal(err)
+	}
+	_, rewritten, err := processXMLPart(readOnly, "ppt/slides/slide1.xml", mapping, partOptions{})
+	if err == nil || !strings.Contains(err.Error(), "ppt/slides/slide1.xml") {
+		t.Errorf("processXMLPart() on a read-only part error = %v, want a write error naming the part", err)
+	}
+	if rewritten {
+		t.Error("processXMLPart() reported a part it could not write as rewritten")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MappingEffect summarizes how a swap changes the number of references to one
+// scheme color. Counts are taken before any edit is made.
+type MappingEffect struct {
+	Color      string `json:"color"`      // Scheme color (e.g., "accent1")
+	ToHex      int    `json:"toHex"`      // References to Color converted to hex
+	ToScheme   int    `json:"toScheme"`   // References to Color moved to another scheme color
+	FromHex    int    `json:"fromHex"`    // Hex references converted to Color
+	FromScheme int    `json:"fromScheme"` // References to other scheme colors moved to Color
+}
+
+// Removed returns the number of references to the color the swap takes away
+func (e MappingEffect) Removed() int {
+	return e.ToHex + e.ToScheme
+}
+
+// Added returns the number of references to the color the swap adds
+func (e MappingEffect) Added() int {
+	return e.FromHex + e.FromScheme
+}
+
+// String describes the effect, e.g. "accent1: 12 references converted to hex;
+// 3 hex references converted to accent1"
+func (e MappingEffect) String() string {
+	var parts []string
+	if e.ToHex > 0 {
+		parts = append(parts, fmt.Sprintf("%s converted to hex", pluralReferences(e.ToHex)))
+	}
+	if e.ToScheme > 0 {
+		parts = append(parts, fmt.Sprintf("%s moved to other scheme colors", pluralReferences(e.ToScheme)))
+	}
+	if e.FromHex > 0 {
+		parts = append(parts, fmt.Sprintf("%d hex %s converted to %s", e.FromHex, pluralNoun(e.FromHex), e.Color))
+	}
+	if e.FromScheme > 0 {
+		parts = append(parts, fmt.Sprintf("%s to other scheme colors moved to %s", pluralReferences(e.FromScheme), e.Color))
+	}
+	return e.Color + ": " + strings.Join(parts, "; ")
+}
+
+// pluralReferences formats a reference count ("1 reference", "3 references")
+func pluralReferences(n int) string {
+	return fmt.Sprintf("%d %s", n, pluralNoun(n))
+}
+
+// pluralNoun returns "reference" or "references" for a count
+func pluralNoun(n int) string {
+	if n == 1 {
+		return "reference"
+	}
+	return "references"
+}
+
+// mappingEffects accumulates MappingEffect counts across the parts of a swap
+type mappingEffects map[string]*MappingEffect
+
+// add counts the references in one part that a mapping will change
+func (m mappingEffects) add(xmlContent []byte, colorMapping map[string]string) {
+	effect := func(color string) *MappingEffect {
+		if m[color] == nil {
+			m[color] = &MappingEffect{Color: color}
+		}
+		return m[color]
+	}
+
+	for source, target := range colorMapping {
+		count := countColor(xmlContent, source)
+		if count == 0 || strings.EqualFold(source, target) {
+			continue
+		}
+
+		sourceIsHex := isValidHexColor(source)
+		targetIsHex := isValidHexColor(target)
+		switch {
+		case !sourceIsHex && targetIsHex:
+			effect(source).ToHex += count
+		case !sourceIsHex && !targetIsHex:
+			effect(source).ToScheme += count
+			effect(target).FromScheme += count
+		case sourceIsHex && !targetIsHex:
+			effect(target).FromHex += count
+		}
+	}
+}
+
+// mixed returns the effects of scheme colors the swap both removes and adds
+// references to, sorted naturally by color
+func (m mappingEffects) mixed() []MappingEffect {
+	var result []MappingEffect
+	for _, effect := range m {
+		if effect.Removed() > 0 && effect.Added() > 0 {
+			result = append(result, *effect)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return naturalLess(result[i].Color, result[j].Color)
+	})
+	return result
+}
+
+// RedundantConversion is an advisory: a hex→scheme mapping whose hex source already
+// equals the target slot's color in some themes, so in those themes the swap links
+// references to the theme without changing how they look
+type RedundantConversion struct {
+	Source string   `json:"source"` // Hex source (e.g., "4F81BD")
+	Target string   `json:"target"` // Scheme target (e.g., "accent1")
+	Themes []string `json:"themes"` // Themes whose target slot equals the source, without extension
+}
+
+// String describes the advisory
+func (r RedundantConversion) String() string {
+	return fmt.Sprintf("%s:%s is redundant in %s, where %s is already %s (advisory: the swap only links these references to the theme)",
+		r.Source, r.Target, strings.Join(r.Themes, ", "), r.Target, strings.ToUpper(r.Source))
+}
+
+// FindRedundantConversions checks hex→scheme mappings against the theme colors they
+// resolve to. The general mapping is checked against every theme in themeFilter (all
+// themes if empty); each theme mapping only against its own theme. Results are sorted
+// by source and target.
+func FindRedundantConversions(themes []*Theme, colorMapping map[string]string, themeMappings map[string]map[string]string, themeFilter []string) []RedundantConversion {
+	selected := themeFilterSet(themeFilter)
+
+	found := make(map[[2]string]map[string]bool)
+	check := func(theme *Theme, mapping map[string]string) {
+		for source, target := range mapping {
+			if !isValidHexColor(source) || isValidHexColor(target) {
+				continue
+			}
+			if strings.EqualFold(theme.Colors.Get(defaultColorMap.Resolve(target)), source) {
+				key := [2]string{source, target}
+				if found[key] == nil {
+					found[key] = make(map[string]bool)
+				}
+				found[key][strings.TrimSuffix(theme.FileName, ".xml")] = true
+			}
+		}
+	}
+
+	for _, theme := range themes {
+		if len(selected) == 0 || selected[theme.FileName] {
+			check(theme, colorMapping)
+		}
+		check(theme, themeMappings[theme.FileName])
+	}
+
+	result := make([]RedundantConversion, 0, len(found))
+	for key, themeSet := range found {
+		conversion := RedundantConversion{Source: key[0], Target: key[1]}
+		for theme := range themeSet {
+			conversion.Themes = append(conversion.Themes, theme)
+		}
+		sortNatural(conversion.Themes)
+		result = append(result, conversion)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return naturalLess(result[i].Target, result[j].Target)
+	})
+	return result
+}
+
+// UndefinedTarget is an advisory: a mapping whose scheme target is a slot that some
+// of the themes it applies to do not define (see Theme.UndefinedColors), so in those
+// themes the swapped references fall back to black
+type UndefinedTarget struct {
+	Source string   `json:"source"` // Scheme or hex source (e.g., "accent1")
+	Target string   `json:"target"` // Scheme target as given (e.g., "accent6" or "tx2")
+	Slot   string   `json:"slot"`   // Theme slot the target resolves to (e.g., "dk2" for tx2)
+	Themes []string `json:"themes"` // Themes that do not define the slot, without extension
+}
+
+// String describes the advisory
+func (u UndefinedTarget) String() string {
+	target := u.Target
+	if u.Slot != u.Target {
+		target = fmt.Sprintf("%s (%s)", u.Target, u.Slot)
+	}
+	return fmt.Sprintf("%s:%s: %s is not defined in %s; references swapped to it there will render black",
+		u.Source, u.Target, target, strings.Join(u.Themes, ", "))
+}
+
+// FindUndefinedTargets checks the scheme targets of a swap against the themes they
+// resolve in, through the default color map (tx2 → dk2, ...). As with
+// FindRedundantConversions, the general mapping is checked against every theme in
+// themeFilter (all themes if empty) and each theme mapping only against its own
+// theme. Results are sorted by source and target.
+func FindUndefinedTargets(themes []*Theme, colorMapping map[string]string, themeMappings map[string]map[string]string, themeFilter []string) []UndefinedTarget {
+	selected := themeFilterSet(themeFilter)
+
+	found := make(map[[2]string]map[string]bool)
+	check := func(theme *Theme, mapping map[string]string) {
+		for source, target := range mapping {
+			if isValidHexColor(target) {
+				continue
+			}
+			if slices.Contains(theme.UndefinedColors, defaultColorMap.Resolve(target)) {
+				key := [2]string{source, target}
+				if found[key] == nil {
+					found[key] = make(map[string]bool)
+				}
+				found[key][strings.TrimSuffix(theme.FileName, ".xml")] = true
+			}
+		}
+	}
+
+	for _, theme := range themes {
+		if len(selected) == 0 || selected[theme.FileName] {
+			check(theme, colorMapping)
+		}
+		check(theme, themeMappings[theme.FileName])
+	}
+
+	result := make([]UndefinedTarget, 0, len(found))
+	for key, themeSet := range found {
+		undefined := UndefinedTarget{Source: key[0], Target: key[1], Slot: defaultColorMap.Resolve(key[1])}
+		for theme := range themeSet {
+			undefined.Themes = append(undefined.Themes, theme)
+		}
+		sortNatural(undefined.Themes)
+		result = append(result, undefined)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return naturalLess(result[i].Source, result[j].Source)
+		}
+		return naturalLess(result[i].Target, result[j].Target)
+	})
+	return result
+}
+
+// themeFilterSet returns the theme files a theme filter selects, with the .xml
+// extension added where it was left off
+func themeFilterSet(themeFilter []string) map[string]bool {
+	selected := make(map[string]bool)
+	for _, theme := range themeFilter {
+		if strings.HasSuffix(theme, ".xml") {
+			selected[theme] = true
+		} else {
+			selected[theme+".xml"] = true
+		}
+	}
+	return selected
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMappingEffects(t *testing.T) {
+	// Two slides with accent1, AABBCC, accent2, FF0000 each
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 4})
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		want    []MappingEffect
+	}{
+		{
+			name:    "scheme to hex and hex to same scheme",
+			mapping: map[string]string{"accent1": "FF00FF", "AABBCC": "accent1"},
+			want:    []MappingEffect{{Color: "accent1", ToHex: 2, FromHex: 2}},
+		},
+		{
+			name:    "scheme swap cycle",
+			mapping: map[string]string{"accent1": "accent2", "accent2": "accent1"},
+			want: []MappingEffect{
+				{Color: "accent1", ToScheme: 2, FromScheme: 2},
+				{Color: "accent2", ToScheme: 2, FromScheme: 2},
+			},
+		},
+		{
+			name:    "only removes",
+			mapping: map[string]string{"accent1": "FF00FF"},
+			want:    nil,
+		},
+		{
+			name:    "source not used",
+			mapping: map[string]string{"accent6": "FF00FF", "FF0000": "accent6"},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []MappingEffect
+			called := 0
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, tt.mapping, nil, "content", nil, Options{
+				Preflight: func(effects []MappingEffect) {
+					called++
+					got = effects
+				},
+			})
+			if err != nil {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+			}
+			if called != 1 {
+				t.Fatalf("Preflight called %d times, want 1", called)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("effects = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMappingEffectString(t *testing.T) {
+	tests := []struct {
+		effect MappingEffect
+		want   string
+	}{
+		{
+			effect: MappingEffect{Color: "accent1", ToHex: 12, FromHex: 3},
+			want:   "accent1: 12 references converted to hex; 3 hex references converted to accent1",
+		},
+		{
+			effect: MappingEffect{Color: "accent2", ToScheme: 1, FromScheme: 1, FromHex: 1},
+			want:   "accent2: 1 reference moved to other scheme colors; 1 hex reference converted to accent2; 1 reference to other scheme colors moved to accent2",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.effect.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFindRedundantConversions(t *testing.T) {
+	themes := []*Theme{
+		{FileName: "theme1.xml", Colors: ColorScheme{Accent1: "4F81BD", Lt1: "FFFFFF"}},
+		{FileName: "theme2.xml", Colors: ColorScheme{Accent1: "1CADE4", Lt1: "FFFFFF"}},
+	}
+
+	tests := []struct {
+		name          string
+		colorMapping  map[string]string
+		themeMappings map[string]map[string]string
+		themeFilter   []string
+		want          []RedundantConversion
+	}{
+		{
+			name:         "matches one theme",
+			colorMapping: map[string]string{"4f81bd": "accent1"},
+			want:         []RedundantConversion{{Source: "4f81bd", Target: "accent1", Themes: []string{"theme1"}}},
+		},
+		{
+			name:         "alias target resolves through the color map",
+			colorMapping: map[string]string{"FFFFFF": "bg1"},
+			want:         []RedundantConversion{{Source: "FFFFFF", Target: "bg1", Themes: []string{"theme1", "theme2"}}},
+		},
+		{
+			name:         "theme filter excludes the matching theme",
+			colorMapping: map[string]string{"4F81BD": "accent1"},
+			themeFilter:  []string{"theme2"},
+			want:         []RedundantConversion{},
+		},
+		{
+			name:          "theme mapping checked against its own theme only",
+			themeMappings: map[string]map[string]string{"theme2.xml": {"4F81BD": "accent1", "1CADE4": "accent1"}},
+			want:          []RedundantConversion{{Source: "1CADE4", Target: "accent1", Themes: []string{"theme2"}}},
+		},
+		{
+			name:         "scheme and hex targets are not conversions",
+			colorMapping: map[string]string{"accent1": "4F81BD", "4F81BD": "4F81BD"},
+			want:         []RedundantConversion{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindRedundantConversions(themes, tt.colorMapping, tt.themeMappings, tt.themeFilter)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindRedundantConversions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindUndefinedTargets(t *testing.T) {
+	themes := []*Theme{
+		{FileName: "theme1.xml"},
+		{FileName: "theme2.xml", UndefinedColors: []string{"dk2", "accent5", "accent6"}},
+		{FileName: "theme3.xml", UndefinedColors: []string{"accent6"}},
+	}
+
+	tests := []struct {
+		name          string
+		colorMapping  map[string]string
+		themeMappings map[string]map[string]string
+		themeFilter   []string
+		want          []UndefinedTarget
+	}{
+		{
+			name:         "undefined in some themes",
+			colorMapping: map[string]string{"accent1": "accent6", "accent2": "accent4"},
+			want:         []UndefinedTarget{{Source: "accent1", Target: "accent6", Slot: "accent6", Themes: []string{"theme2", "theme3"}}},
+		},
+		{
+			name:         "alias target resolves through the color map",
+			colorMapping: map[string]string{"FF0000": "tx2"},
+			want:         []UndefinedTarget{{Source: "FF0000", Target: "tx2", Slot: "dk2", Themes: []string{"theme2"}}},
+		},
+		{
+			name:         "theme filter excludes the themes missing the slot",
+			colorMapping: map[string]string{"accent1": "accent6"},
+			themeFilter:  []string{"theme1"},
+			want:         []UndefinedTarget{},
+		},
+		{
+			name:          "theme mapping checked against its own theme only",
+			themeMappings: map[string]map[string]string{"theme3.xml": {"accent1": "accent5", "accent2": "accent6"}},
+			want:          []UndefinedTarget{{Source: "accent2", Target: "accent6", Slot: "accent6", Themes: []string{"theme3"}}},
+		},
+		{
+			name:         "hex targets are not checked",
+			colorMapping: map[string]string{"accent1": "FF0000"},
+			want:         []UndefinedTarget{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindUndefinedTargets(themes, tt.colorMapping, tt.themeMappings, tt.themeFilter)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindUndefinedTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
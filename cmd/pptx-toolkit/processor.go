@@ -2,10 +2,200 @@ package main
 
 import (
 	"bytes"
+	"encoding/xml"
 	"regexp"
+	"sort"
 	"strings"
 )
 
+// tagAttrPattern matches a single well-formed attribute (name="value" or name='value'),
+// so that '>' or '/>' inside a quoted value never ends a tag early
+const tagAttrPattern = `\s+[^\s=/>]+\s*=\s*(?:"[^"]*"|'[^']*')`
+
+// colorStartTagPattern builds a regex matching the start tag of a color element with
+// any namespace prefix. The val attribute may appear anywhere among the attributes.
+//
+// Capture groups:
+//  1. "<a:"               (tag opening with prefix)
+//  2. element name        (e.g. "schemeClr")
+//  3. attrs up to 'val="' (e.g. ' val="')
+//  4. val value           (e.g. "accent1")
+//  5. '"' + other attrs   (e.g. '"')
+//  6. tag end             ("/>" for self-closing, ">" for container)
+func colorStartTagPattern(element, value string) *regexp.Regexp {
+	return regexp.MustCompile(`(<(?:[A-Za-z_][\w.\-]*:)?)(` + element + `)((?:` + tagAttrPattern + `)*?\s+val\s*=\s*")(` + value + `)("(?:` + tagAttrPattern + `)*\s*)(/?>)`)
+}
+
+var (
+	// schemeClrStartTag matches <prefix:schemeClr ... val="..." ...> and the self-closing form
+	schemeClrStartTag = colorStartTagPattern("schemeClr", `[^"]+`)
+
+	// srgbClrStartTag matches <prefix:srgbClr ... val="AABBCC" ...> and the self-closing form
+	srgbClrStartTag = colorStartTagPattern("srgbClr", `[0-9A-Fa-f]{6}`)
+
+	// schemeClrTag and srgbClrTag match any start, end, or self-closing tag of the element,
+	// used to find the end tag that balances a container start tag
+	schemeClrTag = regexp.MustCompile(`</?(?:[A-Za-z_][\w.\-]*:)?schemeClr(?:` + tagAttrPattern + `)*\s*/?>`)
+	srgbClrTag   = regexp.MustCompile(`</?(?:[A-Za-z_][\w.\-]*:)?srgbClr(?:` + tagAttrPattern + `)*\s*/?>`)
+
+	// sysClrStartTag matches <prefix:sysClr ...> and the self-closing form
+	sysClrStartTag = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?sysClr(?:` + tagAttrPattern + `)*\s*/?>`)
+
+	// sysClrTag matches any start, end, or self-closing sysClr tag, used for balancing
+	sysClrTag = regexp.MustCompile(`</?(?:[A-Za-z_][\w.\-]*:)?sysClr(?:` + tagAttrPattern + `)*\s*/?>`)
+
+	// tagPrefixPattern captures the namespace prefix (with colon) at the start of a tag
+	tagPrefixPattern = regexp.MustCompile(`^<([A-Za-z_][\w.\-]*:)?`)
+
+	// lastClrAttr matches the lastClr attribute of a sysClr tag, capturing its hex value
+	lastClrAttr = regexp.MustCompile(`\slastClr\s*=\s*"([0-9A-Fa-f]{6})"`)
+
+	// nonMarkupPattern matches regions whose content is not markup (comments, CDATA, processing instructions)
+	nonMarkupPattern = regexp.MustCompile(`<!--[\s\S]*?-->|<!\[CDATA\[[\s\S]*?\]\]>|<\?[\s\S]*?\?>`)
+)
+
+// findNonMarkup returns the [start, end) offsets of comments, CDATA sections, and
+// processing instructions, so that tag-like text inside them is never rewritten
+func findNonMarkup(xmlContent []byte) [][]int {
+	return nonMarkupPattern.FindAllIndex(xmlContent, -1)
+}
+
+// inNonMarkup reports whether offset falls inside one of the given non-markup regions
+func inNonMarkup(offset int, regions [][]int) bool {
+	for _, r := range regions {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// findClosingTag returns the [start, end) offsets of the end tag balancing a container
+// start tag that ends at offset, honoring nested elements of the same name.
+// Returns -1, -1 if no balancing end tag exists.
+func findClosingTag(xmlContent []byte, offset int, tagPattern *regexp.Regexp, regions [][]int) (int, int) {
+	depth := 0
+	for _, loc := range tagPattern.FindAllIndex(xmlContent[offset:], -1) {
+		start, end := offset+loc[0], offset+loc[1]
+		if inNonMarkup(start, regions) {
+			continue
+		}
+
+		tag := xmlContent[start:end]
+		switch {
+		case bytes.HasPrefix(tag, []byte("</")):
+			if depth == 0 {
+				return start, end
+			}
+			depth--
+		case bytes.HasSuffix(tag, []byte("/>")):
+			// Self-closing, no effect on depth
+		default:
+			depth++
+		}
+	}
+	return -1, -1
+}
+
+// byteEdit replaces xmlContent[start:end] with replacement
+type byteEdit struct {
+	start, end  int
+	replacement []byte
+}
+
+// countChangingEdits returns how many edits actually change xmlContent, ignoring
+// edits that write back the bytes they replace
+func countChangingEdits(xmlContent []byte, edits []byteEdit) int {
+	count := 0
+	for _, edit := range edits {
+		if !bytes.Equal(xmlContent[edit.start:edit.end], edit.replacement) {
+			count++
+		}
+	}
+	return count
+}
+
+// ReplaceOptions adjusts how the Replace functions write replacements. Each takes
+// an optional ReplaceOptions; only the first is used.
+type ReplaceOptions struct {
+	// PreserveCase writes hex targets exactly as given in the mapping (e.g.,
+	// "aabbcc") instead of uppercased. Matching is case-insensitive either way.
+	PreserveCase bool
+}
+
+// replaceOptions returns the first of opts, or the zero ReplaceOptions
+func replaceOptions(opts []ReplaceOptions) ReplaceOptions {
+	if len(opts) == 0 {
+		return ReplaceOptions{}
+	}
+	return opts[0]
+}
+
+// hexTarget returns a hex target as written into a part: uppercased, as PowerPoint
+// writes hex values, unless PreserveCase is set
+func (o ReplaceOptions) hexTarget(hex string) string {
+	if o.PreserveCase {
+		return hex
+	}
+	return strings.ToUpper(hex)
+}
+
+// applyEdits builds new content by copying unchanged parts and substituting edits.
+// Edits must not overlap; they are applied in offset order.
+func applyEdits(xmlContent []byte, edits []byteEdit) []byte {
+	if len(edits) == 0 {
+		return xmlContent
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var result bytes.Buffer
+	lastEnd := 0
+	for _, edit := range edits {
+		result.Write(xmlContent[lastEnd:edit.start])
+		result.Write(edit.replacement)
+		lastEnd = edit.end
+	}
+	result.Write(xmlContent[lastEnd:])
+
+	return result.Bytes()
+}
+
+// shiftEdits moves edits computed on some content to where they fall once applied,
+// a sorted set of earlier edits that none of them overlap, has been applied to it
+func shiftEdits(edits, applied []byteEdit) []byteEdit {
+	shifted := make([]byteEdit, len(edits))
+	for i, edit := range edits {
+		delta := 0
+		for _, earlier := range applied {
+			if earlier.end > edit.start {
+				break
+			}
+			delta += len(earlier.replacement) - (earlier.end - earlier.start)
+		}
+		shifted[i] = byteEdit{edit.start + delta, edit.end + delta, edit.replacement}
+	}
+	return shifted
+}
+
+// setNameAttr rewrites the value captured by the second group of the first match of
+// pattern (a name="..." attribute) to newName, escaping it for XML. Values are stored
+// escaped, so a name such as "A & B" is written as "A &amp; B". Reports false if the
+// pattern does not match.
+func setNameAttr(xmlContent []byte, pattern *regexp.Regexp, newName string) ([]byte, bool, error) {
+	loc := pattern.FindSubmatchIndex(xmlContent)
+	if loc == nil {
+		return xmlContent, false, nil
+	}
+
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(newName)); err != nil {
+		return xmlContent, false, err
+	}
+
+	return applyEdits(xmlContent, []byteEdit{{loc[4], loc[5], escaped.Bytes()}}), true, nil
+}
+
 // ReplaceSchemeColors replaces scheme color references in PowerPoint XML content.
 //
 // It finds all <schemeClr val="accent1"/> elements (namespace-agnostic) and replaces
@@ -13,56 +203,38 @@ import (
 //
 // Returns the modified XML bytes, or the original if no replacements are needed.
 func ReplaceSchemeColors(xmlContent []byte, colorMapping map[string]string) ([]byte, error) {
+	return applyEdits(xmlContent, schemeColorEdits(xmlContent, colorMapping)), nil
+}
+
+// schemeColorEdits computes the edits made by ReplaceSchemeColors
+func schemeColorEdits(xmlContent []byte, colorMapping map[string]string) []byteEdit {
 	if len(colorMapping) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
-	// Use regex to find and replace schemeClr val attributes
-	// Pattern matches: <prefix:schemeClr val="colorname" with any namespace prefix
-	// This is namespace-agnostic and preserves XML structure
-	pattern := regexp.MustCompile(`(<[^:>]*:?schemeClr[^>]*\sval=")([^"]+)(")`)
-
 	// Atomic replacement: capture all matches first, then replace
 	// This prevents cascading replacements
-	matches := pattern.FindAllSubmatchIndex(xmlContent, -1)
+	matches := schemeClrStartTag.FindAllSubmatchIndex(xmlContent, -1)
 	if len(matches) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
-	// Build new content by copying unchanged parts and replacing matches
-	var result bytes.Buffer
-	lastEnd := 0
+	regions := findNonMarkup(xmlContent)
 
+	var edits []byteEdit
 	for _, match := range matches {
-		// match[0], match[1] = full match start, end
-		// match[4], match[5] = color value start, end (capture group 2)
-
-		// Write everything before this match
-		result.Write(xmlContent[lastEnd:match[0]])
-
-		// Get current color value
-		currentColor := string(xmlContent[match[4]:match[5]])
-
-		// Write opening (prefix + 'val="')
-		result.Write(xmlContent[match[2]:match[3]])
+		// match[8], match[9] = color value start, end (capture group 4)
+		if inNonMarkup(match[0], regions) {
+			continue
+		}
 
-		// Write replacement color or original
+		currentColor := string(xmlContent[match[8]:match[9]])
 		if newColor, exists := colorMapping[currentColor]; exists {
-			result.WriteString(newColor)
-		} else {
-			result.WriteString(currentColor)
+			edits = append(edits, byteEdit{match[8], match[9], []byte(newColor)})
 		}
-
-		// Write closing ('"')
-		result.Write(xmlContent[match[6]:match[7]])
-
-		lastEnd = match[1]
 	}
 
-	// Write remaining content
-	result.Write(xmlContent[lastEnd:])
-
-	return result.Bytes(), nil
+	return edits
 }
 
 // ReplaceSrgbColors replaces RGB color values in PowerPoint XML content.
@@ -71,12 +243,21 @@ func ReplaceSchemeColors(xmlContent []byte, colorMapping map[string]string) ([]b
 //   - Replaces the hex value with another hex value (HEX → HEX)
 //   - Replaces the entire element with <schemeClr> (HEX → Scheme)
 //
+// For HEX → Scheme on a container element, child modifiers (alpha, lumMod, etc.)
+// are kept and the end tag is renamed to match.
+//
 // Replacement is atomic (no cascading), matching the behavior of ReplaceSchemeColors.
+// Hex targets are written uppercase unless opts sets PreserveCase (see ReplaceOptions).
 //
 // Returns the modified XML bytes, or the original if no replacements are needed.
-func ReplaceSrgbColors(xmlContent []byte, colorMapping map[string]string) ([]byte, error) {
+func ReplaceSrgbColors(xmlContent []byte, colorMapping map[string]string, opts ...ReplaceOptions) ([]byte, error) {
+	return applyEdits(xmlContent, srgbColorEdits(xmlContent, colorMapping, replaceOptions(opts))), nil
+}
+
+// srgbColorEdits computes the edits made by ReplaceSrgbColors
+func srgbColorEdits(xmlContent []byte, colorMapping map[string]string, opts ReplaceOptions) []byteEdit {
 	if len(colorMapping) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
 	// Build a case-insensitive mapping for hex values
@@ -90,71 +271,236 @@ func ReplaceSrgbColors(xmlContent []byte, colorMapping map[string]string) ([]byt
 	}
 
 	if len(hexMapping) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
-	// Pattern matches: <prefix:srgbClr val="AABBCC" with any namespace prefix
-	pattern := regexp.MustCompile(`(<[^:>]*:?srgbClr[^>]*\sval=")([0-9A-Fa-f]{6})(")`)
-
 	// Atomic replacement: capture all matches first, then replace
-	matches := pattern.FindAllSubmatchIndex(xmlContent, -1)
+	matches := srgbClrStartTag.FindAllSubmatchIndex(xmlContent, -1)
 	if len(matches) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
-	// Build new content by copying unchanged parts and replacing matches
-	var result bytes.Buffer
-	lastEnd := 0
+	regions := findNonMarkup(xmlContent)
 
+	var edits []byteEdit
 	for _, match := range matches {
-		// match[0], match[1] = full match start, end
-		// match[4], match[5] = hex value start, end (capture group 2)
-
-		// Write everything before this match
-		result.Write(xmlContent[lastEnd:match[0]])
+		if inNonMarkup(match[0], regions) {
+			continue
+		}
 
 		// Get current hex value (normalize to uppercase)
-		currentHex := strings.ToUpper(string(xmlContent[match[4]:match[5]]))
+		currentHex := strings.ToUpper(string(xmlContent[match[8]:match[9]]))
 
 		// Check if we have a mapping for this hex value
-		if newColor, exists := hexMapping[currentHex]; exists {
-			// Determine if target is hex or scheme
-			if isValidHexColor(newColor) {
-				// HEX → HEX: just replace the value
-				result.Write(xmlContent[match[2]:match[3]]) // opening (prefix + 'val="')
-				result.WriteString(strings.ToUpper(newColor))
-				result.Write(xmlContent[match[6]:match[7]]) // closing ('"')
-			} else {
-				// HEX → Scheme: replace entire element
-				// Extract namespace prefix from opening tag
-				opening := string(xmlContent[match[2]:match[3]])
-				// opening looks like: <a:srgbClr val="
-				// We need to extract the prefix (e.g., "a:")
-				prefixEnd := strings.Index(opening, "srgbClr")
-				prefix := ""
-				if prefixEnd > 0 {
-					prefix = opening[1:prefixEnd] // Extract prefix including ':'
-				}
+		newColor, exists := hexMapping[currentHex]
+		if !exists {
+			continue
+		}
+
+		if isValidHexColor(newColor) {
+			// HEX → HEX: just replace the value
+			edits = append(edits, byteEdit{match[8], match[9], []byte(opts.hexTarget(newColor))})
+			continue
+		}
+
+		// HEX → Scheme: rename the element, keeping its attributes and children
+		prefix := xmlContent[match[2]+1 : match[3]] // e.g. "a:"
+		isSelfClosing := string(xmlContent[match[12]:match[13]]) == "/>"
 
-				// Write replacement as schemeClr
-				result.WriteString("<")
-				result.WriteString(prefix)
-				result.WriteString("schemeClr val=\"")
-				result.WriteString(newColor)
-				result.WriteString("\"")
+		if !isSelfClosing {
+			closeStart, closeEnd := findClosingTag(xmlContent, match[1], srgbClrTag, regions)
+			if closeStart == -1 {
+				// Unbalanced element, leave it alone rather than corrupt it
+				continue
 			}
+			edits = append(edits, byteEdit{closeStart, closeEnd, []byte("</" + string(prefix) + "schemeClr>")})
+		}
+
+		var opening bytes.Buffer
+		opening.Write(xmlContent[match[2]:match[3]]) // "<a:"
+		opening.WriteString("schemeClr")
+		opening.Write(xmlContent[match[6]:match[7]]) // ' val="'
+		opening.WriteString(newColor)
+		opening.Write(xmlContent[match[10]:match[13]]) // '"' + other attrs + tag end
+		edits = append(edits, byteEdit{match[0], match[1], opening.Bytes()})
+	}
+
+	return edits
+}
+
+// withUnmatchedFallback extends colorMapping so that every scheme and hex color
+// referenced in xmlContent that the mapping does not cover maps to fallback.
+// Explicit mappings win: a hex color that is the target of an explicit mapping is not
+// added, since after the scheme pass it can no longer be told apart from references
+// the swap itself produced. Placeholder colors (phClr), system colors, and protected
+// colors (see ParseProtectedColors) are never matched. Returns colorMapping unchanged
+// if fallback is empty.
+func withUnmatchedFallback(xmlContent []byte, colorMapping map[string]string, fallback string, protected map[string]string) map[string]string {
+	if fallback == "" {
+		return colorMapping
+	}
+
+	extended := make(map[string]string, len(colorMapping))
+	explicitHex := make(map[string]bool)
+	for source, target := range colorMapping {
+		extended[source] = target
+		if isValidHexColor(source) {
+			explicitHex[strings.ToUpper(source)] = true
+		}
+		if isValidHexColor(target) {
+			explicitHex[strings.ToUpper(target)] = true
+		}
+	}
+
+	for _, ref := range ExtractColors(xmlContent) {
+		if ref.Kind == ColorRefScheme {
+			color := ref.Value
+			if _, skip := protected[color]; skip {
+				continue
+			}
+			if _, mapped := extended[color]; !mapped && (ValidSchemeColors[color] || colorMapAliases[color]) {
+				extended[color] = fallback
+			}
+			continue
+		}
+
+		hex := strings.ToUpper(ref.Value)
+		if _, skip := protected[hex]; skip {
+			continue
+		}
+		if !explicitHex[hex] && !strings.EqualFold(hex, fallback) {
+			extended[hex] = fallback
+			explicitHex[hex] = true
+		}
+	}
+
+	return extended
+}
+
+// ReplaceSysColors replaces system colors whose cached value matches a hex source.
+//
+// A <sysClr val="windowText" lastClr="000000"/> is matched by a hex source equal to its
+// lastClr (case-insensitive) and replaced by an srgbClr (hex target) or schemeClr
+// (scheme target) element. Child modifiers (alpha, lumMod, etc.) are kept and the end
+// tag is renamed to match. The replacement no longer follows the operating system's
+// colors: lastClr is only the value last seen, and the new element is fixed.
+//
+// Replacement is atomic (no cascading), matching the behavior of ReplaceSrgbColors.
+//
+// Returns the modified XML bytes, or the original if no replacements are needed.
+func ReplaceSysColors(xmlContent []byte, colorMapping map[string]string, opts ...ReplaceOptions) ([]byte, error) {
+	return applyEdits(xmlContent, sysColorEdits(xmlContent, colorMapping, replaceOptions(opts))), nil
+}
+
+// sysColorEdits computes the edits made by ReplaceSysColors
+func sysColorEdits(xmlContent []byte, colorMapping map[string]string, opts ReplaceOptions) []byteEdit {
+	// Build a case-insensitive mapping for hex sources
+	hexMapping := make(map[string]string)
+	for source, target := range colorMapping {
+		if isValidHexColor(source) {
+			hexMapping[strings.ToUpper(source)] = target
+		}
+	}
+	if len(hexMapping) == 0 {
+		return nil
+	}
+
+	matches := sysClrStartTag.FindAllIndex(xmlContent, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	for _, loc := range matches {
+		if inNonMarkup(loc[0], regions) {
+			continue
+		}
+
+		tag := xmlContent[loc[0]:loc[1]]
+		attr := lastClrAttr.FindSubmatch(tag)
+		if attr == nil {
+			continue
+		}
+		newColor, exists := hexMapping[strings.ToUpper(string(attr[1]))]
+		if !exists {
+			continue
+		}
+
+		element := "schemeClr"
+		if isValidHexColor(newColor) {
+			element = "srgbClr"
+			newColor = opts.hexTarget(newColor)
+		}
+		prefix := string(tagPrefixPattern.FindSubmatch(tag)[1]) // e.g. "a:"
+		isSelfClosing := bytes.HasSuffix(tag, []byte("/>"))
+
+		if !isSelfClosing {
+			closeStart, closeEnd := findClosingTag(xmlContent, loc[1], sysClrTag, regions)
+			if closeStart == -1 {
+				// Unbalanced element, leave it alone rather than corrupt it
+				continue
+			}
+			edits = append(edits, byteEdit{closeStart, closeEnd, []byte("</" + prefix + element + ">")})
+		}
+
+		// The system color name and cached value don't apply to the new element
+		opening := "<" + prefix + element + ` val="` + newColor + `"`
+		if isSelfClosing {
+			opening += "/>"
 		} else {
-			// No mapping, write original
-			result.Write(xmlContent[match[0]:match[1]])
+			opening += ">"
 		}
+		edits = append(edits, byteEdit{loc[0], loc[1], []byte(opening)})
+	}
+
+	return edits
+}
+
+// ReplaceSysColorCache rewrites the cached lastClr value of system colors, keeping
+// the sysClr element and its val. A sysClr whose lastClr matches a hex source
+// (case-insensitive) gets the hex target as its new lastClr. Scheme sources and
+// scheme targets are ignored: lastClr can only hold a hex value.
+//
+// Returns the modified XML bytes, or the original if no replacements are needed.
+func ReplaceSysColorCache(xmlContent []byte, colorMapping map[string]string, opts ...ReplaceOptions) ([]byte, error) {
+	return applyEdits(xmlContent, sysColorCacheEdits(xmlContent, colorMapping, replaceOptions(opts))), nil
+}
 
-		lastEnd = match[1]
+// sysColorCacheEdits computes the edits made by ReplaceSysColorCache
+func sysColorCacheEdits(xmlContent []byte, colorMapping map[string]string, opts ReplaceOptions) []byteEdit {
+	hexMapping := make(map[string]string)
+	for source, target := range colorMapping {
+		if isValidHexColor(source) && isValidHexColor(target) {
+			hexMapping[strings.ToUpper(source)] = opts.hexTarget(target)
+		}
+	}
+	if len(hexMapping) == 0 {
+		return nil
 	}
 
-	// Write remaining content
-	result.Write(xmlContent[lastEnd:])
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	for _, loc := range sysClrStartTag.FindAllIndex(xmlContent, -1) {
+		if inNonMarkup(loc[0], regions) {
+			continue
+		}
 
-	return result.Bytes(), nil
+		attr := lastClrAttr.FindSubmatchIndex(xmlContent[loc[0]:loc[1]])
+		if attr == nil {
+			continue
+		}
+		valueStart, valueEnd := loc[0]+attr[2], loc[0]+attr[3]
+		newColor, exists := hexMapping[strings.ToUpper(string(xmlContent[valueStart:valueEnd]))]
+		if !exists {
+			continue
+		}
+		edits = append(edits, byteEdit{valueStart, valueEnd, []byte(newColor)})
+	}
+
+	return edits
 }
 
 // ReplaceSchemeColorsWithSrgb replaces scheme color references with RGB values.
@@ -167,12 +513,18 @@ func ReplaceSrgbColors(xmlContent []byte, colorMapping map[string]string) ([]byt
 //
 // For scheme→scheme conversions, it preserves tint/shade modifiers.
 //
-// Replacement is atomic (no cascading).
+// Replacement is atomic (no cascading). Hex targets are written uppercase unless
+// opts sets PreserveCase.
 //
 // Returns the modified XML bytes, or the original if no replacements are needed.
-func ReplaceSchemeColorsWithSrgb(xmlContent []byte, colorMapping map[string]string) ([]byte, error) {
+func ReplaceSchemeColorsWithSrgb(xmlContent []byte, colorMapping map[string]string, opts ...ReplaceOptions) ([]byte, error) {
+	return applyEdits(xmlContent, schemeColorWithSrgbEdits(xmlContent, colorMapping, replaceOptions(opts))), nil
+}
+
+// schemeColorWithSrgbEdits computes the edits made by ReplaceSchemeColorsWithSrgb
+func schemeColorWithSrgbEdits(xmlContent []byte, colorMapping map[string]string, opts ReplaceOptions) []byteEdit {
 	if len(colorMapping) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
 	// Build mapping for scheme → hex conversions only
@@ -180,97 +532,84 @@ func ReplaceSchemeColorsWithSrgb(xmlContent []byte, colorMapping map[string]stri
 	schemeToSchemeMapping := make(map[string]string)
 
 	for source, target := range colorMapping {
-		if ValidSchemeColors[source] {
+		if ValidSchemeColors[source] || colorMapAliases[source] {
 			if isValidHexColor(target) {
-				schemeToHexMapping[source] = strings.ToUpper(target)
+				schemeToHexMapping[source] = opts.hexTarget(target)
 			} else {
 				schemeToSchemeMapping[source] = target
 			}
 		}
 	}
 
-	// If no scheme→hex conversions, use fast regex path for scheme→scheme
+	// If no scheme→hex conversions, use fast path for scheme→scheme
 	if len(schemeToHexMapping) == 0 {
-		return ReplaceSchemeColors(xmlContent, schemeToSchemeMapping)
+		return schemeColorEdits(xmlContent, schemeToSchemeMapping)
 	}
 
-	// Pattern matches entire schemeClr element including children and closing tag
-	// Matches both self-closing and container variants:
+	// Matches the start tag of both self-closing and container variants:
 	//   <a:schemeClr val="accent1"/>  (self-closing)
-	//   <a:schemeClr val="accent1">...</a:schemeClr>  (container)
-	// Two alternatives: self-closing OR container with closing tag
-	pattern := regexp.MustCompile(`(<[^:>]*:?)(schemeClr)(\s+val=")([^"]+)("(?:[^>]*?))(/>)|(<[^:>]*:?)(schemeClr)(\s+val=")([^"]+)("(?:[^>]*?))(>)([\s\S]*?</[^:>]*:?schemeClr>)`)
-
+	//   <a:schemeClr val="accent1">...</a:schemeClr>  (container, end tag found by balancing)
 	// Atomic replacement: capture all matches first
-	matches := pattern.FindAllSubmatchIndex(xmlContent, -1)
+	matches := schemeClrStartTag.FindAllSubmatchIndex(xmlContent, -1)
 	if len(matches) == 0 {
-		return xmlContent, nil
+		return nil
 	}
 
-	var result bytes.Buffer
-	lastEnd := 0
+	regions := findNonMarkup(xmlContent)
+
+	var edits []byteEdit
+	consumedUntil := 0 // end of the last container replaced as a whole
 
 	for _, match := range matches {
-		// Pattern has two alternatives:
-		// Alternative 1 (self-closing): groups [2-13]
-		// Alternative 2 (container): groups [14-27]
-
-		// Write everything before this match
-		result.Write(xmlContent[lastEnd:match[0]])
-
-		// Determine which alternative matched
-		var prefix, valOpening, colorValue, closing, restOfElement []byte
-		var currentColor string
-		var isSelfClosing bool
-
-		if match[2] != -1 {
-			// Self-closing variant matched
-			isSelfClosing = true
-			prefix = xmlContent[match[2]:match[3]]           // "<a:"
-			valOpening = xmlContent[match[6]:match[7]]       // ' val="'
-			colorValue = xmlContent[match[8]:match[9]]       // "accent1"
-			currentColor = string(colorValue)
-			closing = xmlContent[match[10]:match[13]]        // '"/>'
-			restOfElement = nil
-		} else {
-			// Container variant matched
-			isSelfClosing = false
-			prefix = xmlContent[match[14]:match[15]]          // "<a:"
-			valOpening = xmlContent[match[18]:match[19]]      // ' val="'
-			colorValue = xmlContent[match[20]:match[21]]      // "accent1"
-			currentColor = string(colorValue)
-			closing = xmlContent[match[22]:match[25]]         // '">...'
-			restOfElement = xmlContent[match[26]:match[27]]   // children + closing tag
+		// match[0], match[1] = start tag start, end
+		// match[8], match[9] = color value start, end (capture group 4)
+		if match[0] < consumedUntil || inNonMarkup(match[0], regions) {
+			continue
 		}
 
+		currentColor := string(xmlContent[match[8]:match[9]])
+		isSelfClosing := string(xmlContent[match[12]:match[13]]) == "/>"
+
 		// Check for scheme → hex conversion
 		if hexColor, exists := schemeToHexMapping[currentColor]; exists {
 			// Scheme → HEX: replace entire element with self-closing srgbClr
-			result.Write(prefix)                  // "<a:"
-			result.WriteString("srgbClr")         // new element name
-			result.WriteString(" val=\"")         // ' val="'
-			result.WriteString(hexColor)          // hex value
-			result.WriteString("\"/>")            // close self-closing tag
-		} else if newScheme, exists := schemeToSchemeMapping[currentColor]; exists {
-			// Scheme → Scheme: preserve structure, just change val
-			result.Write(prefix)                  // "<a:"
-			result.WriteString("schemeClr")       // keep element name
-			result.Write(valOpening)              // ' val="'
-			result.WriteString(newScheme)         // new scheme color
-			result.Write(closing)                 // '"/>' or '">'
+			end := match[1]
 			if !isSelfClosing {
-				result.Write(restOfElement)       // children + closing tag
+				_, closeEnd := findClosingTag(xmlContent, match[1], schemeClrTag, regions)
+				if closeEnd == -1 {
+					// Unbalanced element, leave it alone rather than corrupt it
+					continue
+				}
+				end = closeEnd
+				consumedUntil = closeEnd
 			}
-		} else {
-			// No mapping, write original
-			result.Write(xmlContent[match[0]:match[1]])
-		}
 
-		lastEnd = match[1]
+			var replacement bytes.Buffer
+			replacement.Write(xmlContent[match[2]:match[3]]) // "<a:"
+			replacement.WriteString("srgbClr")               // new element name
+			replacement.WriteString(" val=\"")               // ' val="'
+			replacement.WriteString(hexColor)                // hex value
+			replacement.WriteString("\"/>")                  // close self-closing tag
+			edits = append(edits, byteEdit{match[0], end, replacement.Bytes()})
+		} else if newScheme, exists := schemeToSchemeMapping[currentColor]; exists {
+			// Scheme → Scheme: preserve structure, just change val
+			edits = append(edits, byteEdit{match[8], match[9], []byte(newScheme)})
+		}
 	}
 
-	// Write remaining content
-	result.Write(xmlContent[lastEnd:])
+	return edits
+}
 
-	return result.Bytes(), nil
+// ReplaceAll applies a whole color mapping to XML content in one pass: scheme
+// sources are handled as by ReplaceSchemeColorsWithSrgb and hex sources as by
+// ReplaceSrgbColors. Both are matched against the original content, so the pass is
+// atomic across the two kinds as well: with accent1→FF0000 and FF0000→00FF00,
+// accent1 becomes FF0000 and only the original FF0000 becomes 00FF00. Hex targets
+// are written as set by opts (see ReplaceOptions).
+func ReplaceAll(xmlContent []byte, colorMapping map[string]string, opts ...ReplaceOptions) ([]byte, error) {
+	options := replaceOptions(opts)
+
+	// schemeClr and srgbClr elements never overlap, so the two edit sets combine
+	edits := append(schemeColorWithSrgbEdits(xmlContent, colorMapping, options), srgbColorEdits(xmlContent, colorMapping, options)...)
+	return applyEdits(xmlContent, edits), nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/antchfx/xmlquery"
@@ -28,23 +29,15 @@ func createSampleXML(schemeColors []string) []byte {
 
 // extractSchemeColors extracts all schemeClr val attributes from XML
 func extractSchemeColors(xmlContent []byte) ([]string, error) {
-	doc, err := xmlquery.Parse(bytes.NewReader(xmlContent))
-	if err != nil {
+	// Parse first so that malformed output fails the test
+	if _, err := xmlquery.Parse(bytes.NewReader(xmlContent)); err != nil {
 		return nil, err
 	}
 
-	nodes, err := xmlquery.QueryAll(doc, "//*[local-name()='schemeClr']")
-	if err != nil {
-		return nil, err
-	}
-
-	colors := make([]string, 0, len(nodes))
-	for _, node := range nodes {
-		for _, attr := range node.Attr {
-			if attr.Name.Local == "val" {
-				colors = append(colors, attr.Value)
-				break
-			}
+	colors := []string{}
+	for _, ref := range ExtractColors(xmlContent) {
+		if ref.Kind == ColorRefScheme {
+			colors = append(colors, ref.Value)
 		}
 	}
 
@@ -330,23 +323,15 @@ func createSampleXMLWithRgb(rgbColors []string) []byte {
 
 // extractSrgbColors extracts all srgbClr val attributes from XML
 func extractSrgbColors(xmlContent []byte) ([]string, error) {
-	doc, err := xmlquery.Parse(bytes.NewReader(xmlContent))
-	if err != nil {
+	// Parse first so that malformed output fails the test
+	if _, err := xmlquery.Parse(bytes.NewReader(xmlContent)); err != nil {
 		return nil, err
 	}
 
-	nodes, err := xmlquery.QueryAll(doc, "//*[local-name()='srgbClr']")
-	if err != nil {
-		return nil, err
-	}
-
-	colors := make([]string, 0, len(nodes))
-	for _, node := range nodes {
-		for _, attr := range node.Attr {
-			if attr.Name.Local == "val" {
-				colors = append(colors, attr.Value)
-				break
-			}
+	colors := []string{}
+	for _, ref := range ExtractColors(xmlContent) {
+		if ref.Kind == ColorRefSrgb {
+			colors = append(colors, ref.Value)
 		}
 	}
 
@@ -765,3 +750,530 @@ func TestReplaceSchemeColorsWithSrgb_WithTintModifiers(t *testing.T) {
 		}
 	})
 }
+
+// fuzzMapping exercises every conversion kind: scheme→scheme, scheme→hex, hex→scheme, hex→hex
+var fuzzMapping = map[string]string{
+	"accent1": "accent2",
+	"accent2": "FF0000",
+	"AABBCC":  "accent1",
+	"112233":  "445566",
+}
+
+// fuzzSeeds returns representative DrawingML snippets for the fuzz corpus
+func fuzzSeeds() [][]byte {
+	return [][]byte{
+		createSampleXML([]string{"accent1", "accent2", "dk1"}),
+		createSampleXMLWithRgb([]string{"AABBCC", "112233", "aabbcc"}),
+		[]byte(`<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` +
+			`<a:solidFill><a:schemeClr val="accent2"><a:lumMod val="75000"/></a:schemeClr></a:solidFill>` +
+			`<a:solidFill><a:srgbClr val="AABBCC"><a:alpha val="50000"/></a:srgbClr></a:solidFill>` +
+			`</p:sld>`),
+		[]byte(`<sld><schemeClr val="accent2"/><schemeClr val="accent1"></schemeClr><srgbClr val="112233"/></sld>`),
+		[]byte(`<sld><!-- <schemeClr val="accent2"> --><schemeClr val="accent2" x="/>"></schemeClr></sld>`),
+	}
+}
+
+// countColorElements parses XML and counts schemeClr and srgbClr elements that are not
+// nested inside another color element (DrawingML never nests them, and scheme→hex
+// intentionally strips children). Returns ok=false if the content is not well-formed XML.
+func countColorElements(xmlContent []byte) (int, bool) {
+	if checkWellFormedXML(bytes.NewReader(xmlContent)) != nil {
+		return 0, false
+	}
+	doc, err := xmlquery.Parse(bytes.NewReader(xmlContent))
+	if err != nil {
+		return 0, false
+	}
+	nodes, err := xmlquery.QueryAll(doc, "//*[(local-name()='schemeClr' or local-name()='srgbClr') and not(ancestor::*[local-name()='schemeClr' or local-name()='srgbClr'])]")
+	if err != nil {
+		return 0, false
+	}
+	return len(nodes), true
+}
+
+// checkFuzzInvariants asserts a replacement kept the XML well-formed and preserved the color element count
+func checkFuzzInvariants(t *testing.T, input, output []byte) {
+	t.Helper()
+
+	before, ok := countColorElements(input)
+	if !ok {
+		return
+	}
+
+	after, ok := countColorElements(output)
+	if !ok {
+		t.Fatalf("output is not well-formed XML\ninput:  %q\noutput: %q", input, output)
+	}
+
+	if before != after {
+		t.Fatalf("color element count changed from %d to %d\ninput:  %q\noutput: %q", before, after, input, output)
+	}
+}
+
+func FuzzReplaceSchemeColors(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		output, err := ReplaceSchemeColors(input, fuzzMapping)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkFuzzInvariants(t, input, output)
+	})
+}
+
+func FuzzReplaceSrgbColors(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		output, err := ReplaceSrgbColors(input, fuzzMapping)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkFuzzInvariants(t, input, output)
+	})
+}
+
+func FuzzReplaceSchemeColorsWithSrgb(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		output, err := ReplaceSchemeColorsWithSrgb(input, fuzzMapping)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkFuzzInvariants(t, input, output)
+	})
+}
+
+func TestReplaceSrgbColors_ContainerHexToScheme(t *testing.T) {
+	// Regression: hex→scheme on a container srgbClr used to leave </a:srgbClr> behind
+	xml := []byte(`<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` +
+		`<a:solidFill><a:srgbClr val="AABBCC"><a:alpha val="50000"/></a:srgbClr></a:solidFill>` +
+		`</p:sld>`)
+
+	result, err := ReplaceSrgbColors(xml, map[string]string{"AABBCC": "accent1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := xmlquery.Parse(bytes.NewReader(result)); err != nil {
+		t.Fatalf("result should be valid XML: %v\n%s", err, result)
+	}
+
+	expected := `<a:schemeClr val="accent1"><a:alpha val="50000"/></a:schemeClr>`
+	if !bytes.Contains(result, []byte(expected)) {
+		t.Errorf("expected %s in result, got %s", expected, result)
+	}
+}
+
+func TestReplaceSchemeColorsWithSrgb_TagEdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mapping  map[string]string
+		expected string
+	}{
+		{
+			name:     "val not first attribute",
+			input:    `<sld><a:schemeClr x="1" val="accent1"/></sld>`,
+			mapping:  map[string]string{"accent1": "FF0000"},
+			expected: `<sld><a:srgbClr val="FF0000"/></sld>`,
+		},
+		{
+			name:     "quoted '/>' inside attribute",
+			input:    `<sld><schemeClr val="accent1" x="/>"><lumMod val="1"/></schemeClr></sld>`,
+			mapping:  map[string]string{"accent1": "FF0000"},
+			expected: `<sld><srgbClr val="FF0000"/></sld>`,
+		},
+		{
+			name:     "comment is left untouched",
+			input:    `<sld><!--<schemeClr val="accent1">--><schemeClr val="dk1"/></sld>`,
+			mapping:  map[string]string{"accent1": "FF0000"},
+			expected: `<sld><!--<schemeClr val="accent1">--><schemeClr val="dk1"/></sld>`,
+		},
+		{
+			name:     "attribute named schemeClr is not an element",
+			input:    `<sld><x schemeClr="1" val="accent1"/></sld>`,
+			mapping:  map[string]string{"accent1": "FF0000"},
+			expected: `<sld><x schemeClr="1" val="accent1"/></sld>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceSchemeColorsWithSrgb([]byte(tt.input), tt.mapping)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplaceSysColors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mapping  map[string]string
+		expected string
+	}{
+		{
+			name:     "hex target",
+			input:    `<a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill>`,
+			mapping:  map[string]string{"000000": "1F2937"},
+			expected: `<a:solidFill><a:srgbClr val="1F2937"/></a:solidFill>`,
+		},
+		{
+			name:     "scheme target, case-insensitive source",
+			input:    `<a:solidFill><a:sysClr val="window" lastClr="ffffff"/></a:solidFill>`,
+			mapping:  map[string]string{"FFFFFF": "bg1"},
+			expected: `<a:solidFill><a:schemeClr val="bg1"/></a:solidFill>`,
+		},
+		{
+			name:     "container keeps modifiers",
+			input:    `<a:solidFill><a:sysClr val="windowText" lastClr="000000"><a:lumMod val="75000"/></a:sysClr></a:solidFill>`,
+			mapping:  map[string]string{"000000": "accent1"},
+			expected: `<a:solidFill><a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr></a:solidFill>`,
+		},
+		{
+			name:     "no match",
+			input:    `<a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill>`,
+			mapping:  map[string]string{"111111": "accent1"},
+			expected: `<a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill>`,
+		},
+		{
+			name:     "scheme sources ignored",
+			input:    `<a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill>`,
+			mapping:  map[string]string{"tx1": "accent1"},
+			expected: `<a:solidFill><a:sysClr val="windowText" lastClr="000000"/></a:solidFill>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xml := `<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` + tt.input + `</p:sld>`
+			result, err := ReplaceSysColors([]byte(xml), tt.mapping)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := xmlquery.Parse(bytes.NewReader(result)); err != nil {
+				t.Fatalf("result should be valid XML: %v\n%s", err, result)
+			}
+			if !bytes.Contains(result, []byte(tt.expected)) {
+				t.Errorf("expected %s in result, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplaceSysColorCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mapping  map[string]string
+		expected string
+	}{
+		{
+			name:     "hex target rewrites lastClr",
+			input:    `<a:sysClr val="windowText" lastClr="000000"/>`,
+			mapping:  map[string]string{"000000": "1f2937"},
+			expected: `<a:sysClr val="windowText" lastClr="1F2937"/>`,
+		},
+		{
+			name:     "container and attribute order",
+			input:    `<a:sysClr lastClr="ffffff" val="window"><a:alpha val="50000"/></a:sysClr>`,
+			mapping:  map[string]string{"FFFFFF": "F0F0F0"},
+			expected: `<a:sysClr lastClr="F0F0F0" val="window"><a:alpha val="50000"/></a:sysClr>`,
+		},
+		{
+			name:     "scheme target ignored",
+			input:    `<a:sysClr val="windowText" lastClr="000000"/>`,
+			mapping:  map[string]string{"000000": "accent1"},
+			expected: `<a:sysClr val="windowText" lastClr="000000"/>`,
+		},
+		{
+			name:     "srgbClr untouched",
+			input:    `<a:srgbClr val="000000"/>`,
+			mapping:  map[string]string{"000000": "1F2937"},
+			expected: `<a:srgbClr val="000000"/>`,
+		},
+		{
+			name:     "comment untouched",
+			input:    `<!-- <a:sysClr val="windowText" lastClr="000000"/> -->`,
+			mapping:  map[string]string{"000000": "1F2937"},
+			expected: `<!-- <a:sysClr val="windowText" lastClr="000000"/> -->`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xml := `<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` + tt.input + `</p:sld>`
+			result, err := ReplaceSysColorCache([]byte(xml), tt.mapping)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Contains(result, []byte(tt.expected)) {
+				t.Errorf("expected %s in result, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplaceColors_GradientStops(t *testing.T) {
+	// Gradient stops nest color elements, often with modifiers, side by side in one gsLst
+	xml := []byte(`<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` +
+		`<a:gradFill rotWithShape="1"><a:gsLst>` +
+		`<a:gs pos="0"><a:schemeClr val="accent1"><a:satMod val="103000"/><a:lumMod val="102000"/><a:tint val="94000"/></a:schemeClr></a:gs>` +
+		`<a:gs pos="50000"><a:srgbClr val="AABBCC"><a:alpha val="60000"/></a:srgbClr></a:gs>` +
+		`<a:gs pos="100000"><a:schemeClr val="accent2"><a:shade val="78000"/></a:schemeClr></a:gs>` +
+		`</a:gsLst><a:lin ang="5400000" scaled="0"/></a:gradFill>` +
+		`</p:sld>`)
+
+	mapping := map[string]string{"accent1": "FF0000", "AABBCC": "accent3", "accent2": "accent4"}
+
+	intermediate, err := ReplaceSchemeColorsWithSrgb(xml, mapping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := ReplaceSrgbColors(intermediate, mapping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doc, err := xmlquery.Parse(bytes.NewReader(result))
+	if err != nil {
+		t.Fatalf("result should be valid XML: %v\n%s", err, result)
+	}
+
+	stops := xmlquery.Find(doc, "//*[local-name()='gs']")
+	if len(stops) != 3 {
+		t.Fatalf("expected 3 gradient stops, got %d:\n%s", len(stops), result)
+	}
+
+	expected := []string{
+		`<a:gs pos="0"><a:srgbClr val="FF0000"/></a:gs>`,
+		`<a:gs pos="50000"><a:schemeClr val="accent3"><a:alpha val="60000"/></a:schemeClr></a:gs>`,
+		`<a:gs pos="100000"><a:schemeClr val="accent4"><a:shade val="78000"/></a:schemeClr></a:gs>`,
+		`<a:lin ang="5400000" scaled="0"/>`,
+	}
+	for _, want := range expected {
+		if !bytes.Contains(result, []byte(want)) {
+			t.Errorf("expected %s in result, got %s", want, result)
+		}
+	}
+}
+
+func TestReplaceAll_Fragments(t *testing.T) {
+	// Fragments carry no document root and no namespace declarations
+	tests := []struct {
+		name    string
+		xml     string
+		mapping map[string]string
+		want    string
+	}{
+		{
+			name:    "bare scheme color",
+			xml:     `<a:schemeClr val="accent1"/>`,
+			mapping: map[string]string{"accent1": "accent2"},
+			want:    `<a:schemeClr val="accent2"/>`,
+		},
+		{
+			name:    "bare hex color",
+			xml:     `<a:srgbClr val="aabbcc"/>`,
+			mapping: map[string]string{"AABBCC": "112233"},
+			want:    `<a:srgbClr val="112233"/>`,
+		},
+		{
+			name:    "fill with modifiers, scheme to hex",
+			xml:     `<a:solidFill><a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr></a:solidFill>`,
+			mapping: map[string]string{"accent1": "FF0000"},
+			want:    `<a:solidFill><a:srgbClr val="FF0000"/></a:solidFill>`,
+		},
+		{
+			name:    "fill with modifiers, hex to scheme",
+			xml:     `<a:solidFill><a:srgbClr val="AABBCC"><a:alpha val="60000"/></a:srgbClr></a:solidFill>`,
+			mapping: map[string]string{"AABBCC": "accent3"},
+			want:    `<a:solidFill><a:schemeClr val="accent3"><a:alpha val="60000"/></a:schemeClr></a:solidFill>`,
+		},
+		{
+			name:    "no cascading between scheme and hex mappings",
+			xml:     `<a:schemeClr val="accent1"/><a:srgbClr val="FF0000"/>`,
+			mapping: map[string]string{"accent1": "FF0000", "FF0000": "00FF00"},
+			want:    `<a:srgbClr val="FF0000"/><a:srgbClr val="00FF00"/>`,
+		},
+		{
+			name:    "no cascading from hex into scheme mappings",
+			xml:     `<a:srgbClr val="AABBCC"/><a:schemeClr val="accent2"/>`,
+			mapping: map[string]string{"AABBCC": "accent2", "accent2": "accent4"},
+			want:    `<a:schemeClr val="accent2"/><a:schemeClr val="accent4"/>`,
+		},
+		{
+			name:    "empty mapping",
+			xml:     `<a:schemeClr val="accent1"/>`,
+			mapping: map[string]string{},
+			want:    `<a:schemeClr val="accent1"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceAll([]byte(tt.xml), tt.mapping)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("ReplaceAll() = %s, want %s", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceOptions_PreserveCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace func([]byte, map[string]string, ...ReplaceOptions) ([]byte, error)
+		xml     string
+		mapping map[string]string
+		opts    []ReplaceOptions
+		want    string
+	}{
+		{
+			name:    "hex to hex uppercased by default",
+			replace: ReplaceSrgbColors,
+			xml:     `<a:srgbClr val="AABBCC"/>`,
+			mapping: map[string]string{"AABBCC": "c0ffee"},
+			want:    `<a:srgbClr val="C0FFEE"/>`,
+		},
+		{
+			name:    "hex to hex keeps lowercase target",
+			replace: ReplaceSrgbColors,
+			xml:     `<a:srgbClr val="AABBCC"/>`,
+			mapping: map[string]string{"AABBCC": "c0ffee"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:srgbClr val="c0ffee"/>`,
+		},
+		{
+			name:    "matching stays case-insensitive",
+			replace: ReplaceSrgbColors,
+			xml:     `<a:srgbClr val="AaBbCc"/>`,
+			mapping: map[string]string{"aabbcc": "C0ffEE"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:srgbClr val="C0ffEE"/>`,
+		},
+		{
+			name:    "scheme to hex uppercased by default",
+			replace: ReplaceSchemeColorsWithSrgb,
+			xml:     `<a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`,
+			mapping: map[string]string{"accent1": "ff0000"},
+			want:    `<a:srgbClr val="FF0000"/>`,
+		},
+		{
+			name:    "scheme to hex keeps lowercase target",
+			replace: ReplaceSchemeColorsWithSrgb,
+			xml:     `<a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`,
+			mapping: map[string]string{"accent1": "ff0000"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:srgbClr val="ff0000"/>`,
+		},
+		{
+			name:    "all passes",
+			replace: ReplaceAll,
+			xml:     `<a:schemeClr val="accent1"/><a:srgbClr val="112233"/>`,
+			mapping: map[string]string{"accent1": "abcdef", "112233": "fedcba"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:srgbClr val="abcdef"/><a:srgbClr val="fedcba"/>`,
+		},
+		{
+			name:    "system color replaced",
+			replace: ReplaceSysColors,
+			xml:     `<a:sysClr val="windowText" lastClr="000000"/>`,
+			mapping: map[string]string{"000000": "1f4e79"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:srgbClr val="1f4e79"/>`,
+		},
+		{
+			name:    "system color cache",
+			replace: ReplaceSysColorCache,
+			xml:     `<a:sysClr val="windowText" lastClr="000000"/>`,
+			mapping: map[string]string{"000000": "1f4e79"},
+			opts:    []ReplaceOptions{{PreserveCase: true}},
+			want:    `<a:sysClr val="windowText" lastClr="1f4e79"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.replace([]byte(tt.xml), tt.mapping, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.want {
+				t.Errorf("got %s, want %s", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithUnmatchedFallback(t *testing.T) {
+	xml := []byte(`<p:sld xmlns:p="` + presentationmlNS + `" xmlns:a="` + drawingmlNS + `">` +
+		`<a:schemeClr val="accent1"/><a:schemeClr val="tx1"/><a:schemeClr val="phClr"/>` +
+		`<a:srgbClr val="aabbcc"/><a:srgbClr val="FF0000"/><a:srgbClr val="808080"/>` +
+		`<a:sysClr val="windowText" lastClr="000000"/>` +
+		`<!-- <a:schemeClr val="accent6"/> -->` +
+		`</p:sld>`)
+
+	tests := []struct {
+		name      string
+		mapping   map[string]string
+		fallback  string
+		protected []string
+		want      map[string]string
+	}{
+		{
+			name:    "no fallback",
+			mapping: map[string]string{"accent1": "FF0000"},
+			want:    map[string]string{"accent1": "FF0000"},
+		},
+		{
+			name:     "hex fallback, explicit target kept",
+			mapping:  map[string]string{"accent1": "FF0000"},
+			fallback: "808080",
+			want:     map[string]string{"accent1": "FF0000", "tx1": "808080", "AABBCC": "808080"},
+		},
+		{
+			name:     "scheme fallback",
+			mapping:  map[string]string{"AABBCC": "accent2"},
+			fallback: "dk2",
+			want:     map[string]string{"AABBCC": "accent2", "accent1": "dk2", "tx1": "dk2", "FF0000": "dk2", "808080": "dk2"},
+		},
+		{
+			name:      "protected colors left alone",
+			mapping:   map[string]string{"accent1": "FF0000"},
+			fallback:  "808080",
+			protected: []string{"dk1", "aabbcc"},
+			want:      map[string]string{"accent1": "FF0000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protected, err := ParseProtectedColors(tt.protected)
+			if err != nil {
+				t.Fatalf("ParseProtectedColors() error = %v", err)
+			}
+			got := withUnmatchedFallback(xml, tt.mapping, tt.fallback, protected)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withUnmatchedFallback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// progressReporter serializes calls to an Options.Progress callback so it is
+// safe to step from multiple goroutines
+type progressReporter struct {
+	mu    sync.Mutex
+	fn    func(done, total int)
+	done  int
+	total int
+}
+
+// newProgressReporter returns a reporter for total steps; fn may be nil
+func newProgressReporter(fn func(done, total int), total int) *progressReporter {
+	return &progressReporter{fn: fn, total: total}
+}
+
+// step records one finished part and reports it
+func (p *progressReporter) step() {
+	if p == nil || p.fn == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.fn(p.done, p.total)
+}
+
+// progressBarWidth is the number of cells in the CLI progress bar
+const progressBarWidth = 30
+
+// newProgressBar returns a Progress callback that draws a single-line bar on w,
+// redrawing it in place and clearing it once all parts are done
+func newProgressBar(w io.Writer) func(done, total int) {
+	return func(done, total int) {
+		if total <= 0 {
+			return
+		}
+
+		filled := done * progressBarWidth / total
+		line := fmt.Sprintf("Processing [%s%s] %d/%d",
+			strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), done, total)
+
+		if done >= total {
+			// Clear the line so the summary that follows starts clean
+			fmt.Fprintf(w, "\r%s\r", strings.Repeat(" ", len(line)))
+			return
+		}
+		fmt.Fprintf(w, "\r%s", line)
+	}
+}
+
+// isTerminal reports whether w is a character device such as an interactive terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// cliProgress returns the Progress callback for a command: a progress bar on
+// stderr when it is a terminal and --quiet is not set, nil otherwise
+func cliProgress(cmd *cobra.Command) func(done, total int) {
+	if quiet || !isTerminal(cmd.ErrOrStderr()) {
+		return nil
+	}
+	return newProgressBar(cmd.ErrOrStderr())
+}
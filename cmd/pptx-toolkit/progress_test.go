@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProcessPPTX_Progress(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 3, ColorsPerSlide: 4})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	var calls [][2]int
+	opts := Options{
+		IncludeTheme: true,
+		Progress: func(done, total int) {
+			calls = append(calls, [2]int{done, total})
+		},
+	}
+
+	filesProcessed, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "FF0000"}, nil, "all", nil, opts)
+	if err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+
+	// 3 slides, 1 layout, 1 master, 1 theme
+	const wantTotal = 6
+	if len(calls) != wantTotal {
+		t.Fatalf("expected %d progress calls, got %d: %v", wantTotal, len(calls), calls)
+	}
+	for i, call := range calls {
+		if call[0] != i+1 || call[1] != wantTotal {
+			t.Errorf("call %d = (%d, %d), want (%d, %d)", i, call[0], call[1], i+1, wantTotal)
+		}
+	}
+	if filesProcessed != wantTotal {
+		t.Errorf("filesProcessed = %d, want %d", filesProcessed, wantTotal)
+	}
+}
+
+func TestProgressReporter_Serialized(t *testing.T) {
+	const total = 200
+
+	// The callback itself is not synchronized; the race detector flags any overlap
+	var seen []int
+	reporter := newProgressReporter(func(done, total int) {
+		seen = append(seen, done)
+	}, total)
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reporter.step()
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != total {
+		t.Fatalf("expected %d calls, got %d", total, len(seen))
+	}
+	for i, done := range seen {
+		if done != i+1 {
+			t.Fatalf("call %d reported done = %d, want %d", i, done, i+1)
+		}
+	}
+
+	// A nil callback is a no-op
+	newProgressReporter(nil, 1).step()
+}
+
+func TestNewProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := newProgressBar(&buf)
+
+	bar(3, 10)
+	if got := buf.String(); !strings.HasPrefix(got, "\rProcessing [=========") || !strings.HasSuffix(got, "] 3/10") {
+		t.Errorf("unexpected bar: %q", got)
+	}
+
+	// Completion clears the line
+	buf.Reset()
+	bar(10, 10)
+	if got := buf.String(); strings.TrimSpace(got) != "" || !strings.HasSuffix(got, "\r") {
+		t.Errorf("expected bar to clear itself, got %q", got)
+	}
+
+	if isTerminal(&buf) {
+		t.Error("a buffer is not a terminal")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// changeLogVersion is the current version of the change log format
+const changeLogVersion = 1
+
+// Processing passes, in the order they are applied to a part. Offsets of a
+// change are relative to the part's content after its own pass. Theme definitions
+// are recolored after a theme part's references are swapped and tidied, so
+// passThemeColors comes last.
+const (
+	passSchemeColors = 1 // scheme → scheme/hex
+	passSrgbColors   = 2 // hex → scheme/hex
+	passEmptyColors  = 3 // empty color containers self-closed (--normalize-empty)
+	passThemeColors  = 4 // theme color scheme definitions (--include-theme)
+)
+
+// ChangeRecord is a single edit made to a part
+type ChangeRecord struct {
+	File     string `json:"file"`     // Archive path of the part
+	Pass     int    `json:"pass"`     // Processing pass that made the edit
+	Element  string `json:"element"`  // Local name of the edited element (e.g. "schemeClr")
+	Offset   int    `json:"offset"`   // Byte offset of NewValue in the part after this pass
+	OldValue string `json:"oldValue"` // Bytes before the edit
+	NewValue string `json:"newValue"` // Bytes after the edit
+}
+
+// ChangeLog records every edit made by a swap so it can be reversed exactly
+type ChangeLog struct {
+	Version int               `json:"version"`
+	Mapping map[string]string `json:"mapping,omitempty"`
+	Changes []ChangeRecord    `json:"changes"`
+}
+
+// NewChangeLog returns an empty change log for a color mapping
+func NewChangeLog(colorMapping map[string]string) *ChangeLog {
+	return &ChangeLog{Version: changeLogVersion, Mapping: colorMapping, Changes: []ChangeRecord{}}
+}
+
+// elementNamePattern matches the local name at the start of a tag
+var elementNamePattern = regexp.MustCompile(`^</?(?:[A-Za-z_][\w.\-]*:)?([A-Za-z_][\w.\-]*)`)
+
+// elementAt returns the local name of the element whose tag contains offset
+func elementAt(content []byte, offset int) string {
+	start := bytes.LastIndexByte(content[:min(offset+1, len(content))], '<')
+	if start == -1 {
+		return ""
+	}
+	if match := elementNamePattern.FindSubmatch(content[start:]); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// add records the edits one pass applied to src. Edits must be sorted by offset,
+// as applyEdits leaves them.
+func (l *ChangeLog) add(file string, pass int, src []byte, edits []byteEdit) {
+	delta := 0
+	for _, edit := range edits {
+		l.Changes = append(l.Changes, ChangeRecord{
+			File:     file,
+			Pass:     pass,
+			Element:  elementAt(src, edit.start),
+			Offset:   edit.start + delta,
+			OldValue: string(src[edit.start:edit.end]),
+			NewValue: string(edit.replacement),
+		})
+		delta += len(edit.replacement) - (edit.end - edit.start)
+	}
+}
+
+// WriteChangeLog saves a change log as indented JSON
+func WriteChangeLog(path string, log *ChangeLog) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write change log: %w", err)
+	}
+	return nil
+}
+
+// WriteChangeReport saves a change summary as indented JSON
+func WriteChangeReport(path string, parts []PartChanges) error {
+	data, err := json.MarshalIndent(parts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write change report: %w", err)
+	}
+	return nil
+}
+
+// ReadChangeLog loads a change log written by WriteChangeLog
+func ReadChangeLog(path string) (*ChangeLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change log: %w", err)
+	}
+
+	var log ChangeLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("invalid change log: %w", err)
+	}
+	if log.Version != changeLogVersion {
+		return nil, fmt.Errorf("unsupported change log version %d", log.Version)
+	}
+
+	return &log, nil
+}
+
+// ColorChange is one distinct color transformation made in a part
+type ColorChange struct {
+	From  string `json:"from"`  // Color before the change (e.g., "accent1", "FF0000")
+	To    string `json:"to"`    // Color after the change
+	Count int    `json:"count"` // Number of times it was made in the part
+}
+
+// PartChanges lists the distinct color transformations made in one part
+type PartChanges struct {
+	File    string        `json:"file"`    // Archive path of the part
+	Changes []ColorChange `json:"changes"` // Most frequent first
+}
+
+// colorAttrPattern matches the color attribute of a color element: the val of a
+// schemeClr or srgbClr, or the cached lastClr of a sysClr
+var colorAttrPattern = regexp.MustCompile(`\b(lastClr|val)="([^"]*)"`)
+
+// recordedColor returns the color a recorded value stands for. Values recorded
+// as bare attribute values (e.g., "accent1") are the color; values recording
+// whole elements (e.g., `<a:schemeClr val="accent1"><a:tint .../></a:schemeClr>`,
+// or a theme slot such as `<a:accent1><a:srgbClr val="156082"/></a:accent1>`)
+// stand for the color of the first tag carrying one, preferring a sysClr's lastClr.
+func recordedColor(value string) string {
+	if !strings.HasPrefix(value, "<") {
+		return value
+	}
+	for rest := value; rest != ""; {
+		end := strings.IndexByte(rest, '>')
+		if end == -1 {
+			end = len(rest) - 1
+		}
+		color := ""
+		for _, match := range colorAttrPattern.FindAllStringSubmatch(rest[:end+1], -1) {
+			if match[1] == "lastClr" || color == "" {
+				color = match[2]
+			}
+		}
+		if color != "" {
+			return color
+		}
+		rest = rest[end+1:]
+	}
+	return ""
+}
+
+// SummarizeChanges groups the changes of a log by part and by distinct
+// old→new color, for reviewing what a swap did. Parts are in natural order.
+func SummarizeChanges(log *ChangeLog) []PartChanges {
+	type transform struct{ from, to string }
+	counts := make(map[string]map[transform]int)
+	for _, change := range log.Changes {
+		if counts[change.File] == nil {
+			counts[change.File] = make(map[transform]int)
+		}
+		counts[change.File][transform{recordedColor(change.OldValue), recordedColor(change.NewValue)}]++
+	}
+
+	files := make([]string, 0, len(counts))
+	for file := range counts {
+		files = append(files, file)
+	}
+	sortNatural(files)
+
+	parts := make([]PartChanges, 0, len(files))
+	for _, file := range files {
+		part := PartChanges{File: file}
+		for t, count := range counts[file] {
+			part.Changes = append(part.Changes, ColorChange{From: t.from, To: t.to, Count: count})
+		}
+		sort.Slice(part.Changes, func(i, j int) bool {
+			a, b := part.Changes[i], part.Changes[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			if a.From != b.From {
+				return a.From < b.From
+			}
+			return a.To < b.To
+		})
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// revertChanges reverses the recorded changes to one part's content, undoing
+// passes in reverse order and, within a pass, edits from the end backwards
+func revertChanges(content []byte, changes []ChangeRecord) ([]byte, error) {
+	sorted := make([]ChangeRecord, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Pass != sorted[j].Pass {
+			return sorted[i].Pass > sorted[j].Pass
+		}
+		return sorted[i].Offset > sorted[j].Offset
+	})
+
+	for _, change := range sorted {
+		end := change.Offset + len(change.NewValue)
+		if change.Offset < 0 || end > len(content) || string(content[change.Offset:end]) != change.NewValue {
+			return nil, fmt.Errorf("content at offset %d does not match the recorded change (was the file modified after the swap?)", change.Offset)
+		}
+
+		reverted := make([]byte, 0, len(content)-len(change.NewValue)+len(change.OldValue))
+		reverted = append(reverted, content[:change.Offset]...)
+		reverted = append(reverted, change.OldValue...)
+		reverted = append(reverted, content[end:]...)
+		content = reverted
+	}
+
+	return content, nil
+}
+
+// UndoChanges reverses a recorded swap, restoring the original bytes of every
+// part it changed. Returns the number of parts restored.
+func UndoChanges(inputPath, outputPath string, log *ChangeLog) (int, error) {
+	byFile := make(map[string][]ChangeRecord)
+	for _, change := range log.Changes {
+		byFile[change.File] = append(byFile[change.File], change)
+	}
+
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return 0, fmt.Errorf("input file not found: %s", inputPath)
+	}
+
+	tempDir, err := newTempDir("")
+	if err != nil {
+		return 0, err
+	}
+	defer removeTemp(tempDir)
+
+	if err := extractPPTX(inputPath, tempDir); err != nil {
+		return 0, err
+	}
+
+	changedFiles := make(map[string]bool)
+	for file, changes := range byFile {
+		path := filepath.Join(tempDir, filepath.FromSlash(file))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", file, err)
+		}
+
+		reverted, err := revertChanges(content, changes)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if err := os.WriteFile(path, reverted, 0644); err != nil {
+			return 0, err
+		}
+		changedFiles[file] = true
+	}
+
+	if err := writePPTX(inputPath, outputPath, tempDir, changedFiles); err != nil {
+		return 0, err
+	}
+
+	return len(changedFiles), nil
+}
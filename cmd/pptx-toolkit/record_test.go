@@ -0,0 +1,226 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAllEntries returns the uncompressed content of every file entry in a ZIP
+func readAllEntries(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+
+	entries := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = content
+	}
+	return entries
+}
+
+func TestUndoChanges_RoundTrip(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	// A theme whose accent1 is an empty container and whose format scheme, after
+	// the color scheme, has another one
+	themeDeck := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2, Parts: map[string]string{
+		"ppt/theme/theme1.xml": strings.NewReplacer(
+			`<a:accent1><a:srgbClr val="4F81BD"/></a:accent1>`, `<a:accent1><a:srgbClr val="156082"></a:srgbClr></a:accent1>`,
+			`</a:clrScheme>`, `</a:clrScheme><a:fmtScheme name="Format"><a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"></a:schemeClr></a:solidFill></a:fillStyleLst></a:fmtScheme>`,
+		).Replace(syntheticThemeXML("Empty Slots", "Empty Slots")),
+	}})
+
+	tests := []struct {
+		name    string
+		input   string // testdata/test.pptx if empty
+		scope   string // "all" if empty
+		mapping map[string]string
+		opts    Options
+	}{
+		{
+			name:    "many to one",
+			mapping: map[string]string{"accent1": "accent2", "accent3": "accent2", "accent4": "FF0000"},
+		},
+		{
+			name:    "scheme to hex with modifiers and hex to scheme",
+			mapping: map[string]string{"accent2": "123456", "000000": "dk2"},
+		},
+		{
+			name:    "edits from both passes overlap",
+			mapping: map[string]string{"accent1": "FF0000", "FF0000": "accent6"},
+		},
+		{
+			name:    "theme and table styles",
+			mapping: map[string]string{"accent1": "FF0000"},
+			opts:    Options{IncludeTheme: true, IncludeTableStyles: true},
+		},
+		{
+			// dk1 turns from sysClr into a longer srgbClr ahead of the empty
+			// container the normalize pass closed
+			name:    "theme slots and empty containers in one theme part",
+			input:   themeDeck,
+			scope:   "theme",
+			mapping: map[string]string{"accent1": "FF0000", "dk1": "112233"},
+			opts:    Options{IncludeTheme: true, NormalizeEmpty: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swapped := filepath.Join(t.TempDir(), "swapped.pptx")
+			restored := filepath.Join(t.TempDir(), "restored.pptx")
+			input, scope := tt.input, tt.scope
+			if input == "" {
+				input = testPPTX
+			}
+			if scope == "" {
+				scope = "all"
+			}
+
+			opts := tt.opts
+			opts.Record = NewChangeLog(tt.mapping)
+			if _, _, err := ProcessPPTXWithOptions(input, swapped, tt.mapping, nil, scope, nil, opts); err != nil {
+				t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+			}
+			if len(opts.Record.Changes) == 0 {
+				t.Fatal("expected changes to be recorded")
+			}
+
+			// Round-trip the log through JSON as the CLI does
+			recordPath := filepath.Join(t.TempDir(), "changes.json")
+			if err := WriteChangeLog(recordPath, opts.Record); err != nil {
+				t.Fatal(err)
+			}
+			changeLog, err := ReadChangeLog(recordPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := UndoChanges(swapped, restored, changeLog); err != nil {
+				t.Fatalf("UndoChanges failed: %v", err)
+			}
+
+			original := readAllEntries(t, input)
+			output := readAllEntries(t, restored)
+			for name, content := range original {
+				if !bytes.Equal(content, output[name]) {
+					t.Errorf("%s: not restored to original bytes", name)
+				}
+			}
+		})
+	}
+}
+
+func TestUndoChanges_ModifiedInput(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 4})
+	swapped := filepath.Join(t.TempDir(), "swapped.pptx")
+
+	record := NewChangeLog(map[string]string{"accent1": "accent2"})
+	if _, _, err := ProcessPPTXWithOptions(inputPath, swapped, record.Mapping, nil, "all", nil, Options{Record: record}); err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+
+	// Undoing against the original (not the swapped output) must fail rather than corrupt it
+	if _, err := UndoChanges(inputPath, filepath.Join(t.TempDir(), "restored.pptx"), record); err == nil {
+		t.Error("expected error when the input does not match the recorded changes")
+	}
+}
+
+func TestChangeLog_Add(t *testing.T) {
+	src := []byte(`<a:schemeClr val="accent1"/><a:schemeClr val="accent1"><a:lumMod val="75000"/></a:schemeClr>`)
+	edits := schemeColorWithSrgbEdits(src, map[string]string{"accent1": "FF0000"}, ReplaceOptions{})
+	dst := applyEdits(src, edits)
+
+	record := NewChangeLog(nil)
+	record.add("ppt/slides/slide1.xml", passSchemeColors, src, edits)
+
+	if len(record.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(record.Changes))
+	}
+	for _, change := range record.Changes {
+		if change.Element != "schemeClr" {
+			t.Errorf("element = %q, want schemeClr", change.Element)
+		}
+		if got := string(dst[change.Offset : change.Offset+len(change.NewValue)]); got != change.NewValue {
+			t.Errorf("offset %d points at %q, want %q", change.Offset, got, change.NewValue)
+		}
+	}
+
+	reverted, err := revertChanges(dst, record.Changes)
+	if err != nil {
+		t.Fatalf("revertChanges() error = %v", err)
+	}
+	if !bytes.Equal(reverted, src) {
+		t.Errorf("revertChanges() = %s, want %s", reverted, src)
+	}
+}
+
+func TestRecordedColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "accent1", want: "accent1"},
+		{value: "FF0000", want: "FF0000"},
+		{value: `<a:srgbClr val="FF0000"/>`, want: "FF0000"},
+		{value: `<a:schemeClr val="accent1"><a:tint val="50000"/></a:schemeClr>`, want: "accent1"},
+		{value: `<a:sysClr val="windowText" lastClr="000000"/>`, want: "000000"},
+		{value: `<a:accent1><a:srgbClr val="156082"/></a:accent1>`, want: "156082"},
+		{value: `<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1>`, want: "000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := recordedColor(tt.value); got != tt.want {
+				t.Errorf("recordedColor(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	log := NewChangeLog(nil)
+	log.Changes = []ChangeRecord{
+		{File: "ppt/slides/slide10.xml", OldValue: "accent1", NewValue: "accent3"},
+		{File: "ppt/slides/slide2.xml", OldValue: `<a:schemeClr val="accent1"><a:tint val="50000"/></a:schemeClr>`, NewValue: `<a:srgbClr val="FF0000"/>`},
+		{File: "ppt/slides/slide2.xml", OldValue: "accent2", NewValue: "accent3"},
+		{File: "ppt/slides/slide2.xml", OldValue: `<a:schemeClr val="accent1"/>`, NewValue: `<a:srgbClr val="FF0000"/>`},
+	}
+
+	want := []PartChanges{
+		{File: "ppt/slides/slide2.xml", Changes: []ColorChange{
+			{From: "accent1", To: "FF0000", Count: 2},
+			{From: "accent2", To: "accent3", Count: 1},
+		}},
+		{File: "ppt/slides/slide10.xml", Changes: []ColorChange{
+			{From: "accent1", To: "accent3", Count: 1},
+		}},
+	}
+
+	if got := SummarizeChanges(log); !reflect.DeepEqual(got, want) {
+		t.Errorf("SummarizeChanges() = %+v, want %+v", got, want)
+	}
+}
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"archive/zip"
 	"bytes"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/antchfx/xmlquery"
@@ -44,112 +42,81 @@ func ValidateName(name string) error {
 	return nil
 }
 
-// RenameColorScheme renames colour scheme(s) in a PowerPoint file
-func RenameColorScheme(inputPath, outputPath, newName string, themeFilter []string) (int, error) {
-	// Validate input
-	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-		return 0, fmt.Errorf("input file not found: %s", inputPath)
-	}
+// clrSchemeNameAttrPattern matches the name attribute of a theme's clrScheme element
+var clrSchemeNameAttrPattern = regexp.MustCompile(`(<(?:[A-Za-z_][\w.\-]*:)?clrScheme\b[^>]*?\sname=")([^"]*)(")`)
 
-	themesRenamed := 0
-
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "pptx-toolkit-*")
-	if err != nil {
-		return 0, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Extract PPTX
-	zipReader, err := zip.OpenReader(inputPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to open PPTX: %w", err)
-	}
-	defer zipReader.Close()
-
-	for _, file := range zipReader.File {
-		filePath := filepath.Join(tempDir, file.Name)
-
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(filePath, os.ModePerm)
-			continue
-		}
+// RenameOutcome reports what a colour scheme rename did to one theme part
+type RenameOutcome struct {
+	Theme   string `json:"theme"`   // Theme file (e.g., "theme1.xml")
+	OldName string `json:"oldName"` // The colour scheme's name before the rename, empty if it had none
+	Renamed bool   `json:"renamed"` // Whether the colour scheme was renamed
+	Reason  string `json:"reason"`  // Why the theme was skipped, empty if renamed
+}
 
-		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			return 0, err
-		}
+// RenameColorScheme renames colour scheme(s) in a PowerPoint file
+func RenameColorScheme(inputPath, outputPath, newName string, themeFilter []string) (int, error) {
+	return RenameColorSchemeWithOptions(inputPath, outputPath, newName, themeFilter, Options{})
+}
 
-		outFile, err := os.Create(filePath)
-		if err != nil {
-			return 0, err
-		}
+// RenameColorSchemeWithOptions is RenameColorScheme with additional options.
+// Only Progress and TempDir apply; Progress is stepped once per theme part considered.
+func RenameColorSchemeWithOptions(inputPath, outputPath, newName string, themeFilter []string, opts Options) (int, error) {
+	outcomes, err := RenameColorSchemeOutcomes(inputPath, outputPath, newName, themeFilter, opts)
+	return countRenamed(outcomes), err
+}
 
-		rc, err := file.Open()
-		if err != nil {
-			outFile.Close()
-			return 0, err
+// countRenamed returns the number of outcomes whose theme was renamed
+func countRenamed(outcomes []RenameOutcome) int {
+	renamed := 0
+	for _, outcome := range outcomes {
+		if outcome.Renamed {
+			renamed++
 		}
+	}
+	return renamed
+}
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return 0, err
+// RenameColorSchemeOutcomes is RenameColorSchemeWithOptions, reporting the outcome for
+// every theme part considered. Themes without a colour scheme (e.g., parts carrying
+// only effects or fonts) are skipped rather than failing the rename; it is an error
+// only if none of the selected themes could be renamed.
+func RenameColorSchemeOutcomes(inputPath, outputPath, newName string, themeFilter []string, opts Options) ([]RenameOutcome, error) {
+	if opts.TempDir != "" {
+		if err := ValidateTempDir(opts.TempDir); err != nil {
+			return nil, err
 		}
 	}
+	if err := checkThemeFilter(inputPath, themeFilter); err != nil {
+		return nil, err
+	}
 
-	// Build theme relationship mappings for validation
-	masterToTheme, _ := buildThemeRelationships(tempDir)
+	var outcomes []RenameOutcome
+	err := editPackageIn(opts.TempDir, opts.Timings, inputPath, outputPath, func(tempDir string) (map[string]bool, error) {
+		var changedParts map[string]bool
+		var err error
+		outcomes, changedParts, err = renameExtracted(tempDir, newName, themeFilter, opts)
+		return changedParts, err
+	})
+	return outcomes, err
+}
 
-	// Validate theme filter
-	if err := validateThemeFilter(themeFilter, masterToTheme); err != nil {
-		return 0, err
-	}
+// renameExtracted renames the colour schemes of an extracted presentation in place,
+// returning the outcome for every theme part considered and the archive paths of the
+// parts changed
+func renameExtracted(tempDir, newName string, themeFilter []string, opts Options) ([]RenameOutcome, map[string]bool, error) {
+	var outcomes []RenameOutcome
 
 	// Process theme files
-	themesDir := filepath.Join(tempDir, "ppt", "theme")
+	themesDir := docPath(tempDir, "theme")
 	if _, err := os.Stat(themesDir); os.IsNotExist(err) {
-		return 0, fmt.Errorf("no themes directory found")
-	}
-
-	themeFiles, err := filepath.Glob(filepath.Join(themesDir, "theme*.xml"))
-	if err != nil {
-		return 0, err
+		return outcomes, nil, fmt.Errorf("no themes directory found")
 	}
 
-	// Normalize theme filter (ensure .xml extension)
-	normalizedFilter := make(map[string]bool)
-	if len(themeFilter) > 0 {
-		for _, theme := range themeFilter {
-			if strings.HasSuffix(theme, ".xml") {
-				normalizedFilter[theme] = true
-			} else {
-				normalizedFilter[theme+".xml"] = true
-			}
-		}
-	}
-
-	for _, themeFile := range themeFiles {
-		themeName := filepath.Base(themeFile)
-
-		// Check theme filter
-		if len(normalizedFilter) > 0 {
-			if !normalizedFilter[themeName] {
-				continue
-			}
-		}
-
-		// Read theme XML
-		content, err := os.ReadFile(themeFile)
-		if err != nil {
-			return themesRenamed, err
-		}
-
+	changedFiles, err := forEachTheme(tempDir, themeFilter, opts.Progress, func(themeName string, content []byte) ([]byte, error) {
 		// Parse to verify structure and find clrScheme
 		doc, err := xmlquery.Parse(bytes.NewReader(content))
 		if err != nil {
-			return themesRenamed, err
+			return nil, err
 		}
 
 		// Find the clrScheme element - try with namespace first
@@ -160,7 +127,8 @@ func RenameColorScheme(inputPath, outputPath, newName string, themeFilter []stri
 		}
 
 		if node == nil {
-			continue
+			outcomes = append(outcomes, RenameOutcome{Theme: themeName, Reason: "no color scheme"})
+			return content, nil
 		}
 
 		// Get the current name
@@ -173,66 +141,35 @@ func RenameColorScheme(inputPath, outputPath, newName string, themeFilter []stri
 		}
 
 		if currentName == "" {
-			continue
+			outcomes = append(outcomes, RenameOutcome{Theme: themeName, Reason: "color scheme has no name"})
+			return content, nil
 		}
 
-		// Use string replacement to update the name attribute
-		// Match: <...clrScheme name="currentName"...>
-		// Replace with: <...clrScheme name="newName"...>
-		oldAttr := fmt.Sprintf(`name="%s"`, currentName)
-		newAttr := fmt.Sprintf(`name="%s"`, newName)
-		modified := bytes.Replace(content, []byte(oldAttr), []byte(newAttr), 1)
-
-		// Write back to file
-		if err := os.WriteFile(themeFile, modified, 0644); err != nil {
-			return themesRenamed, err
-		}
-
-		themesRenamed++
-	}
-
-	if themesRenamed == 0 {
-		return 0, fmt.Errorf("no themes were renamed (this might indicate an issue with the theme filter)")
-	}
-
-	// Create output ZIP
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return themesRenamed, fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outFile.Close()
-
-	zipWriter := zip.NewWriter(outFile)
-	defer zipWriter.Close()
-
-	// Add all files to ZIP
-	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		// Rewrite the name attribute of the clrScheme start tag itself, so a theme
+		// or font scheme sharing the same name is left alone. The current name was
+		// decoded by the parser; the new one is escaped on the way back in.
+		modified, ok, err := setNameAttr(content, clrSchemeNameAttrPattern, newName)
 		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
+			return nil, err
 		}
-
-		relPath, err := filepath.Rel(tempDir, path)
-		if err != nil {
-			return err
+		if !ok {
+			outcomes = append(outcomes, RenameOutcome{Theme: themeName, OldName: currentName, Reason: "color scheme name could not be located"})
+			return content, nil
 		}
 
-		zipFile, err := zipWriter.Create(filepath.ToSlash(relPath))
-		if err != nil {
-			return err
-		}
+		outcomes = append(outcomes, RenameOutcome{Theme: themeName, OldName: currentName, Renamed: true})
+		return modified, nil
+	})
+	if err != nil {
+		return outcomes, nil, err
+	}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
+	if countRenamed(outcomes) == 0 {
+		if len(outcomes) == 0 {
+			return outcomes, nil, fmt.Errorf("no themes were renamed (this might indicate an issue with the theme filter)")
 		}
+		return outcomes, nil, fmt.Errorf("no themes were renamed: none of the %d selected theme(s) has a named color scheme", len(outcomes))
+	}
 
-		_, err = io.Copy(zipFile, bytes.NewReader(content))
-		return err
-	})
-
-	return themesRenamed, err
+	return outcomes, changedFiles, nil
 }
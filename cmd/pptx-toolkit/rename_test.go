@@ -0,0 +1,127 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// effectsOnlyThemeXML is a theme part with format and font schemes but no color scheme
+const effectsOnlyThemeXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+	`<a:theme xmlns:a="` + drawingmlNS + `" name="Effects Only"><a:themeElements>` +
+	`<a:fontScheme name="Office"><a:majorFont><a:latin typeface="Calibri"/></a:majorFont><a:minorFont><a:latin typeface="Calibri"/></a:minorFont></a:fontScheme>` +
+	`<a:fmtScheme name="Office"/>` +
+	`</a:themeElements></a:theme>`
+
+func TestRenameColorSchemeOutcomes(t *testing.T) {
+	mixed := map[string]string{
+		"ppt/theme/theme2.xml": effectsOnlyThemeXML,
+		"ppt/theme/theme3.xml": syntheticThemeXML("Second Theme", "Second"),
+	}
+	effectsOnly := map[string]string{
+		"ppt/theme/theme1.xml": effectsOnlyThemeXML,
+	}
+
+	tests := []struct {
+		name        string
+		parts       map[string]string
+		themeFilter []string
+		want        []RenameOutcome
+		wantErr     bool
+	}{
+		{
+			name:  "mixed themes",
+			parts: mixed,
+			want: []RenameOutcome{
+				{Theme: "theme1.xml", OldName: "Synthetic", Renamed: true},
+				{Theme: "theme2.xml", Reason: "no color scheme"},
+				{Theme: "theme3.xml", OldName: "Second", Renamed: true},
+			},
+		},
+		{
+			name:        "only the theme without a color scheme",
+			parts:       effectsOnly,
+			themeFilter: []string{"theme1"},
+			want:        []RenameOutcome{{Theme: "theme1.xml", Reason: "no color scheme"}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, Parts: tt.parts})
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+			outcomes, err := RenameColorSchemeOutcomes(inputPath, outputPath, "Renamed", tt.themeFilter, Options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameColorSchemeOutcomes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(outcomes, tt.want) {
+				t.Errorf("RenameColorSchemeOutcomes() = %+v, want %+v", outcomes, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			themes, err := ReadThemes(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, theme := range themes {
+				if theme.ColorSchemeName != "Renamed" {
+					t.Errorf("%s: color scheme = %q, want %q", theme.FileName, theme.ColorSchemeName, "Renamed")
+				}
+			}
+		})
+	}
+}
+
+func TestRenameColorSchemeEntities(t *testing.T) {
+	// Theme and color scheme share a name containing an entity
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/theme/theme1.xml": syntheticThemeXML("A &amp; B", "A &amp; B")},
+	})
+
+	themes, err := ReadThemes(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if themes[0].ColorSchemeName != "A & B" {
+		t.Fatalf("input color scheme = %q, want %q", themes[0].ColorSchemeName, "A & B")
+	}
+
+	tests := []struct {
+		newName string
+		wantRaw string
+	}{
+		{newName: "C & D", wantRaw: `<a:clrScheme name="C &amp; D">`},
+		{newName: `Say "Hi" <now>`, wantRaw: `<a:clrScheme name="Say &#34;Hi&#34; &lt;now&gt;">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.newName, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			if _, err := RenameColorScheme(inputPath, outputPath, tt.newName, nil); err != nil {
+				t.Fatalf("RenameColorScheme() error = %v", err)
+			}
+
+			raw := string(readZipEntry(t, outputPath, "ppt/theme/theme1.xml"))
+			if !strings.Contains(raw, tt.wantRaw) {
+				t.Errorf("theme1.xml missing %s:\n%s", tt.wantRaw, raw)
+			}
+			if !strings.Contains(raw, `<a:theme xmlns:a="`+drawingmlNS+`" name="A &amp; B">`) {
+				t.Errorf("theme name should be untouched:\n%s", raw)
+			}
+
+			themes, err := ReadThemes(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if themes[0].ColorSchemeName != tt.newName || themes[0].ThemeName != "A & B" {
+				t.Errorf("got theme %q, color scheme %q", themes[0].ThemeName, themes[0].ColorSchemeName)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ColorModifier is a color transform applied to a color reference, such as the
+// <a:lumMod val="75000"/> child of a schemeClr
+type ColorModifier struct {
+	Name string `json:"name"` // Element name without prefix (e.g., "lumMod")
+	Val  string `json:"val"`  // The val attribute as written (e.g., "75000" or "75%")
+}
+
+// parsePercentage parses a DrawingML percentage, written in thousandths of a
+// percent ("75000") or, in strict documents, with a percent sign ("75%"), into a
+// fraction (0.75). Negative values are allowed.
+func parsePercentage(val string) (float64, error) {
+	if number, ok := strings.CutSuffix(val, "%"); ok {
+		percent, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage: '%s'", val)
+		}
+		return percent / 100, nil
+	}
+
+	thousandths, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage: '%s'", val)
+	}
+	return float64(thousandths) / 100000, nil
+}
+
+// ResolveEffectiveColor returns the hex color PowerPoint renders for a base hex
+// color with modifiers applied one after another, in document order, each to the
+// result of the previous one:
+//
+//   - lumMod and lumOff work in HSL space: lumMod scales the luminance and lumOff
+//     adds to it, so the theme picker's "Lighter 80%" (lumMod 20000, lumOff 80000)
+//     maps L to 0.2·L + 0.8 and "Darker 25%" (lumMod 75000) to 0.75·L
+//   - satMod scales the saturation in HSL space
+//   - tint and shade blend toward white and black in linear RGB: a 40% tint is 40%
+//     of the color and 60% white, a 40% shade is 40% of the color and 60% black
+//
+// Luminance and saturation are clamped to [0, 1] after each step, so a negative
+// lumOff darkens. Other modifiers (alpha, hueMod, ...) are ignored. Results match
+// PowerPoint's swatches to within one step per channel.
+func ResolveEffectiveColor(base string, modifiers []ColorModifier) (string, error) {
+	color, ok := hexToRGB(base)
+	if !ok {
+		return "", fmt.Errorf("invalid hex color: '%s'", base)
+	}
+
+	for _, modifier := range modifiers {
+		var apply func(color rgbColor, amount float64) rgbColor
+		switch modifier.Name {
+		case "lumMod":
+			apply = func(color rgbColor, amount float64) rgbColor {
+				hsl := color.HSL()
+				hsl.L = clamp01(hsl.L * amount)
+				return hsl.RGB()
+			}
+		case "lumOff":
+			apply = func(color rgbColor, amount float64) rgbColor {
+				hsl := color.HSL()
+				hsl.L = clamp01(hsl.L + amount)
+				return hsl.RGB()
+			}
+		case "satMod":
+			apply = func(color rgbColor, amount float64) rgbColor {
+				hsl := color.HSL()
+				hsl.S = clamp01(hsl.S * amount)
+				return hsl.RGB()
+			}
+		case "tint":
+			apply = func(color rgbColor, amount float64) rgbColor {
+				return mixLinear(color, amount, 1)
+			}
+		case "shade":
+			apply = func(color rgbColor, amount float64) rgbColor {
+				return mixLinear(color, amount, 0)
+			}
+		default:
+			continue
+		}
+
+		amount, err := parsePercentage(modifier.Val)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", modifier.Name, err)
+		}
+		color = apply(color, amount)
+	}
+
+	return color.Hex(), nil
+}
+
+// mixLinear keeps the given fraction of a color and fills the rest with a gray
+// level (1 for white, 0 for black), blending in linear RGB
+func mixLinear(color rgbColor, fraction, gray float64) rgbColor {
+	fraction = clamp01(fraction)
+	mix := func(v float64) float64 {
+		return linearToSRGB(srgbToLinear(v)*fraction + gray*(1-fraction))
+	}
+	return rgbColor{R: mix(color.R), G: mix(color.G), B: mix(color.B)}
+}
+
+// srgbToLinear converts an sRGB channel value in [0, 1] to linear light
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear light channel value in [0, 1] back to sRGB
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
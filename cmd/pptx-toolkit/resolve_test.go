@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// withinOneStep reports whether two hex colors differ by at most 1 in each channel
+func withinOneStep(a, b string) bool {
+	for i := 0; i < 6; i += 2 {
+		x, err1 := strconv.ParseUint(a[i:i+2], 16, 8)
+		y, err2 := strconv.ParseUint(b[i:i+2], 16, 8)
+		if err1 != nil || err2 != nil || x > y+1 || y > x+1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResolveEffectiveColor(t *testing.T) {
+	// The Lighter/Darker cases are PowerPoint's theme picker swatches for Office 2007's
+	// accent1 (4F81BD)
+	tests := []struct {
+		name      string
+		base      string
+		modifiers []ColorModifier
+		want      string
+	}{
+		{name: "no modifiers", base: "4f81bd", want: "4F81BD"},
+		{name: "lighter 80%", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "20000"}, {"lumOff", "80000"}}, want: "DBE5F1"},
+		{name: "lighter 60%", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "40000"}, {"lumOff", "60000"}}, want: "B9CDE5"},
+		{name: "lighter 40%", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "60000"}, {"lumOff", "40000"}}, want: "95B3D7"},
+		{name: "darker 25%", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "75000"}}, want: "376092"},
+		{name: "darker 50%", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "50000"}}, want: "244061"},
+		{name: "strict percentages", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "75%"}}, want: "376092"},
+		{name: "negative offset darkens", base: "4F81BD", modifiers: []ColorModifier{{"lumOff", "-25000"}}, want: "264366"}, // L 0.53 → 0.28
+		{name: "clamped to white", base: "4F81BD", modifiers: []ColorModifier{{"lumMod", "150000"}, {"lumOff", "50000"}}, want: "FFFFFF"},
+		{name: "clamped to black", base: "4F81BD", modifiers: []ColorModifier{{"lumOff", "-80000"}}, want: "000000"},
+		{name: "tint blends toward white in linear RGB", base: "000000", modifiers: []ColorModifier{{"tint", "50000"}}, want: "BCBCBC"},
+		{name: "shade blends toward black in linear RGB", base: "FFFFFF", modifiers: []ColorModifier{{"shade", "50000"}}, want: "BCBCBC"},
+		{name: "tint", base: "4F81BD", modifiers: []ColorModifier{{"tint", "40000"}}, want: "D0D8E8"},
+		{name: "shade", base: "4F81BD", modifiers: []ColorModifier{{"shade", "75000"}}, want: "4471A6"},
+		{name: "satMod", base: "4F81BD", modifiers: []ColorModifier{{"satMod", "50000"}}, want: "6B84A2"},
+		{name: "document order: tint then lumMod", base: "000000", modifiers: []ColorModifier{{"tint", "50000"}, {"lumMod", "50000"}}, want: "5E5E5E"},
+		{name: "document order: lumMod then tint", base: "000000", modifiers: []ColorModifier{{"lumMod", "50000"}, {"tint", "50000"}}, want: "BCBCBC"},
+		{name: "other modifiers ignored", base: "4F81BD", modifiers: []ColorModifier{{"alpha", "50000"}, {"hueMod", "50000"}}, want: "4F81BD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveEffectiveColor(tt.base, tt.modifiers)
+			if err != nil {
+				t.Fatalf("ResolveEffectiveColor() error = %v", err)
+			}
+			if !withinOneStep(got, tt.want) {
+				t.Errorf("ResolveEffectiveColor() = %s, want %s (±1 per channel)", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ResolveEffectiveColor("accent1", nil); err == nil {
+		t.Error("ResolveEffectiveColor() accepted a non-hex base")
+	}
+	if _, err := ResolveEffectiveColor("4F81BD", []ColorModifier{{"lumMod", "lots"}}); err == nil {
+		t.Error("ResolveEffectiveColor() accepted an invalid percentage")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var slideCmd = &cobra.Command{
+	Use:   "slide",
+	Short: "Slide-related operations",
+	Long:  "Slide-related operations for PowerPoint files.",
+}
+
+var slideThemesCmd = &cobra.Command{
+	Use:   "themes <input.pptx>",
+	Short: "List which theme each slide uses",
+	Long: `List which theme each slide uses, in visual slide order.
+
+The theme is resolved through the slide's layout and master. Slides whose
+theme cannot be resolved are shown as "unknown". Hidden slides are marked
+[hidden]; they keep their number, as in PowerPoint's slide sorter.
+
+Examples:
+  pptx-toolkit slide themes input.pptx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSlideThemes,
+}
+
+func init() {
+	slideCmd.AddCommand(slideThemesCmd)
+}
+
+func runSlideThemes(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile := args[0]
+
+	// Theme display names, keyed by file name
+	themes, err := ReadThemes(inputFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+	themeNames := make(map[string]string)
+	for _, theme := range themes {
+		themeNames[theme.FileName] = theme.ThemeName
+	}
+
+	warnSlideOrder(cmd, inputFile)
+
+	var slideThemes []SlideTheme
+	err = withExtractedPPTX(inputFile, func(tempDir string) error {
+		slideThemes, err = BuildSlideThemeMapping(tempDir)
+		return err
+	})
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	for _, st := range slideThemes {
+		hidden := ""
+		if st.Hidden {
+			hidden = " [hidden]"
+		}
+
+		if st.Theme == "" {
+			cmd.Printf("%3d: unknown%s\n", st.Slide, hidden)
+			continue
+		}
+
+		themeBase := strings.TrimSuffix(st.Theme, ".xml")
+		if name, ok := themeNames[st.Theme]; ok {
+			cmd.Printf("%3d: %s (%s)%s\n", st.Slide, themeBase, name, hidden)
+		} else {
+			cmd.Printf("%3d: %s%s\n", st.Slide, themeBase, hidden)
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
 	"fmt"
+	"html"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -82,21 +87,24 @@ func ParseSlideRange(flag string) ([]int, error) {
 }
 
 // BuildSlideMapping creates a map of visual slide number to file path
-// Parses presentation.xml for order (NOT file names)
+// Parses presentation.xml for order (NOT file names). If the presentation's
+// relationships can't be read, slides are numbered by file name instead (see
+// fallbackSlideMapping).
 func BuildSlideMapping(tempDir string) (map[int]string, error) {
 	mapping := make(map[int]string)
 
-	// Parse presentation.xml
-	presentationPath := filepath.Join(tempDir, "ppt", "presentation.xml")
+	// Parse presentation.xml, wherever the package keeps it
+	presentationName := presentationPart(tempDir)
+	presentationPath := filepath.Join(tempDir, filepath.FromSlash(presentationName))
 	presentationFile, err := os.Open(presentationPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open presentation.xml: %w", err)
+		return nil, fmt.Errorf("failed to open %s: %w", path.Base(presentationName), err)
 	}
 	defer presentationFile.Close()
 
 	doc, err := xmlquery.Parse(presentationFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse presentation.xml: %w", err)
+		return nil, fmt.Errorf("failed to parse %s: %w", path.Base(presentationName), err)
 	}
 
 	// Find slide IDs in order
@@ -111,16 +119,17 @@ func BuildSlideMapping(tempDir string) (map[int]string, error) {
 	}
 
 	// Parse relationships file
-	relsPath := filepath.Join(tempDir, "ppt", "_rels", "presentation.xml.rels")
+	relsName := path.Base(presentationName) + ".rels"
+	relsPath := filepath.Join(filepath.Dir(presentationPath), "_rels", relsName)
 	relsFile, err := os.Open(relsPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open presentation.xml.rels: %w", err)
+		return fallbackSlideMapping(tempDir, presentationName)
 	}
 	defer relsFile.Close()
 
 	relsDoc, err := xmlquery.Parse(relsFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse presentation.xml.rels: %w", err)
+		return fallbackSlideMapping(tempDir, presentationName)
 	}
 
 	// Build mapping: visual slide number → file path
@@ -148,13 +157,148 @@ func BuildSlideMapping(tempDir string) (map[int]string, error) {
 		}
 
 		visualSlideNum := i + 1 // 1-indexed
-		// target is like "slides/slide1.xml", prepend "ppt/"
-		mapping[visualSlideNum] = filepath.Join("ppt", filepath.FromSlash(target))
+		// target is like "slides/slide1.xml", relative to the presentation part, or a
+		// part name like "/ppt/slides/slide1.xml"
+		if strings.HasPrefix(target, "/") {
+			mapping[visualSlideNum] = filepath.FromSlash(path.Clean(target[1:]))
+		} else {
+			mapping[visualSlideNum] = filepath.FromSlash(path.Join(partDir(presentationName), target))
+		}
+	}
+
+	return mapping, nil
+}
+
+// fallbackSlideMapping numbers the slide parts next to a presentation part whose
+// relationships are missing or unreadable in file name order (slide2.xml before
+// slide10.xml). PowerPoint's order can differ; see checkSlideOrder.
+func fallbackSlideMapping(tempDir, presentationName string) (map[int]string, error) {
+	slidesDir := path.Join(partDir(presentationName), "slides")
+	files, err := filepath.Glob(filepath.Join(tempDir, filepath.FromSlash(slidesDir), "slide*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no slides found in presentation")
 	}
 
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Base(file)
+	}
+	sortNatural(names)
+
+	mapping := make(map[int]string, len(names))
+	for i, name := range names {
+		mapping[i+1] = filepath.FromSlash(path.Join(slidesDir, name))
+	}
 	return mapping, nil
 }
 
+// checkSlideOrder reports whether slides of a PPTX file are numbered by file name
+// because the presentation's relationships are missing or unreadable, reading only
+// those parts from the archive. Slide numbers may then differ from PowerPoint's.
+func checkSlideOrder(pptxPath string) (bool, error) {
+	zipReader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open PPTX: %w", err)
+	}
+	defer zipReader.Close()
+
+	presentationName := zipPresentationPart(zipReader.File)
+	relsName := path.Join(partDir(presentationName), "_rels", path.Base(presentationName)+".rels")
+	file, err := zipReader.Open(relsName)
+	if err != nil {
+		return true, nil
+	}
+	defer file.Close()
+
+	if _, err := xmlquery.Parse(file); err != nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// hiddenSlidePattern matches the root element of a slide hidden in slide shows (show="0")
+var hiddenSlidePattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?sld\b[^>]*\sshow="(?:0|false)"`)
+
+// isSlideHidden reports whether a slide part is hidden. PowerPoint marks hidden
+// slides with show="0" on the slide's root element; they still count in numbering.
+func isSlideHidden(slidePath string) bool {
+	content, err := os.ReadFile(slidePath)
+	if err != nil {
+		return false
+	}
+	return hiddenSlidePattern.Match(content)
+}
+
+// FindHiddenSlides returns the visual numbers of hidden slides, sorted
+func FindHiddenSlides(tempDir string) ([]int, error) {
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var hidden []int
+	for slideNum, slidePath := range slideMapping {
+		if isSlideHidden(filepath.Join(tempDir, slidePath)) {
+			hidden = append(hidden, slideNum)
+		}
+	}
+	sort.Ints(hidden)
+	return hidden, nil
+}
+
+// ResolveVisibleSlides translates visible slide numbers (counting only slides shown
+// in a slide show, as PowerPoint numbers them when presenting) into slide numbers
+// counting every entry in sldIdLst. With no hidden slides the numbers are unchanged.
+func ResolveVisibleSlides(tempDir string, visibleNums []int) ([]int, error) {
+	if len(visibleNums) == 0 {
+		return visibleNums, nil
+	}
+
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := FindHiddenSlides(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	isHidden := make(map[int]bool, len(hidden))
+	for _, slideNum := range hidden {
+		isHidden[slideNum] = true
+	}
+
+	var visible []int
+	for slideNum := range slideMapping {
+		if !isHidden[slideNum] {
+			visible = append(visible, slideNum)
+		}
+	}
+	sort.Ints(visible)
+
+	resolved := make([]int, 0, len(visibleNums))
+	var invalid []string
+	for _, visibleNum := range visibleNums {
+		if visibleNum > len(visible) {
+			invalid = append(invalid, fmt.Sprintf("%d", visibleNum))
+			continue
+		}
+		resolved = append(resolved, visible[visibleNum-1])
+	}
+
+	if len(invalid) == 1 {
+		return nil, fmt.Errorf("visible slide %s does not exist (presentation has %d visible slides)", invalid[0], len(visible))
+	}
+	if len(invalid) > 1 {
+		return nil, fmt.Errorf("visible slides %s do not exist (presentation has %d visible slides)",
+			strings.Join(invalid, ", "), len(visible))
+	}
+
+	return resolved, nil
+}
+
 // ValidateSlideNumbers checks if all requested slides exist in the presentation
 // Reports all invalid slides together
 func ValidateSlideNumbers(tempDir string, slideNums []int) error {
@@ -194,9 +338,120 @@ func ValidateSlideNumbers(tempDir string, slideNums []int) error {
 	return nil
 }
 
+// Section is a named group of slides, as shown in PowerPoint's slide sorter
+type Section struct {
+	Name   string `json:"name"`
+	Slides []int  `json:"slides"` // Visual slide numbers, in order
+}
+
+// ReadSections returns the sections of an extracted presentation, in order. Sections
+// are kept in an extension of presentation.xml (p14:sectionLst) that lists each
+// section's slides by slide id; a presentation without one has no sections.
+func ReadSections(tempDir string) ([]Section, error) {
+	presentationName := presentationPart(tempDir)
+	content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(presentationName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path.Base(presentationName), err)
+	}
+	doc, err := xmlquery.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path.Base(presentationName), err)
+	}
+
+	// Slide ids in slide order, as numbered by BuildSlideMapping
+	slideNums := make(map[string]int)
+	for i, node := range xmlquery.Find(doc, "/*[local-name()='presentation']/*[local-name()='sldIdLst']/*[local-name()='sldId']") {
+		slideNums[node.SelectAttr("id")] = i + 1
+	}
+
+	var sections []Section
+	for _, node := range xmlquery.Find(doc, "//*[local-name()='sectionLst']/*[local-name()='section']") {
+		section := Section{Name: node.SelectAttr("name"), Slides: []int{}}
+		for _, slide := range xmlquery.Find(node, "./*[local-name()='sldIdLst']/*[local-name()='sldId']") {
+			if slideNum, ok := slideNums[slide.SelectAttr("id")]; ok {
+				section.Slides = append(section.Slides, slideNum)
+			}
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// ResolveSections returns the slide numbers in the named sections, sorted. Names
+// match case-insensitively; a name matching no section is reported, together with
+// those available, as *ErrSectionNotFound.
+func ResolveSections(tempDir string, names []string) ([]int, error) {
+	sections, err := ReadSections(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[int]bool)
+	var missing []string
+	for _, name := range names {
+		found := false
+		for _, section := range sections {
+			if strings.EqualFold(section.Name, strings.TrimSpace(name)) {
+				found = true
+				for _, slideNum := range section.Slides {
+					selected[slideNum] = true
+				}
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		available := make([]string, 0, len(sections))
+		for _, section := range sections {
+			available = append(available, section.Name)
+		}
+		return nil, &ErrSectionNotFound{Names: missing, Available: available}
+	}
+
+	slideNums := make([]int, 0, len(selected))
+	for slideNum := range selected {
+		slideNums = append(slideNums, slideNum)
+	}
+	sort.Ints(slideNums)
+	return slideNums, nil
+}
+
+// SlideContentType is a kind of part that belongs to a slide and is selected with it
+type SlideContentType string
+
+const (
+	SlideCharts   SlideContentType = "charts"   // Charts and their color and style parts
+	SlideDiagrams SlideContentType = "diagrams" // SmartArt data, layout, colors, style, and drawing parts
+	SlideNotes    SlideContentType = "notes"    // Speaker notes
+)
+
+// AllSlideContent enables every SlideContentType
+var AllSlideContent = map[SlideContentType]bool{
+	SlideCharts:   true,
+	SlideDiagrams: true,
+	SlideNotes:    true,
+}
+
+// slideContentDirs are the package folders, under the document root, holding each
+// SlideContentType
+var slideContentDirs = map[SlideContentType]string{
+	SlideCharts:   "charts",
+	SlideDiagrams: "diagrams",
+	SlideNotes:    "notesSlides",
+}
+
 // GetSlideContent returns all files that belong to the specified slides
 // Includes: slide files, charts + sub-files, diagrams (all 5 files), notes
 func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
+	return GetSlideContentWithTypes(tempDir, slideNums, AllSlideContent)
+}
+
+// GetSlideContentWithTypes is GetSlideContent including only the content types
+// enabled in types; the slide files themselves are always included
+func GetSlideContentWithTypes(tempDir string, slideNums []int, types map[SlideContentType]bool) (map[string]bool, error) {
 	if len(slideNums) == 0 {
 		return nil, nil
 	}
@@ -223,40 +478,18 @@ func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
 		// Build absolute path for file operations
 		slidePath := filepath.Join(tempDir, slideRelPath)
 
-		// Find slide's relationships
-		slideDir := filepath.Dir(slidePath)
-		slideName := filepath.Base(slidePath)
-		relsPath := filepath.Join(slideDir, "_rels", slideName+".rels")
-
-		if _, err := os.Stat(relsPath); os.IsNotExist(err) {
-			continue
-		}
-
-		// Parse relationships
-		relsFile, err := os.Open(relsPath)
-		if err != nil {
-			continue
-		}
-		relsDoc, err := xmlquery.Parse(relsFile)
-		relsFile.Close()
-		if err != nil {
-			continue
-		}
-
-		// Find all relationships
-		rels := xmlquery.Find(relsDoc, "//Relationship")
-
-		for _, rel := range rels {
+		for _, rel := range readPartRels(slidePath) {
 			relType := rel.SelectAttr("Type")
 			target := rel.SelectAttr("Target")
 
-			if target == "" {
+			// External targets (e.g. linked files) are not parts of the package
+			if target == "" || rel.SelectAttr("TargetMode") == "External" {
 				continue
 			}
 
 			// Process charts
-			if strings.HasSuffix(relType, "/chart") {
-				chartPath := resolveRelativePath(slidePath, target)
+			if types[SlideCharts] && strings.HasSuffix(relType, "/chart") {
+				chartPath := resolveRelativePath(tempDir, slidePath, target)
 				chartRelPath, _ := filepath.Rel(tempDir, chartPath)
 				chartRelPath = filepath.ToSlash(chartRelPath)
 				filesToProcess[chartRelPath] = true
@@ -275,8 +508,8 @@ func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
 							subRels := xmlquery.Find(chartRelsDoc, "//Relationship")
 							for _, subRel := range subRels {
 								subTarget := subRel.SelectAttr("Target")
-								if subTarget != "" {
-									subPath := resolveRelativePath(chartPath, subTarget)
+								if subTarget != "" && subRel.SelectAttr("TargetMode") != "External" {
+									subPath := resolveRelativePath(tempDir, chartPath, subTarget)
 									// Only include XML files (not embedded Excel data)
 									if strings.HasSuffix(subPath, ".xml") {
 										subRelPath, _ := filepath.Rel(tempDir, subPath)
@@ -300,8 +533,8 @@ func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
 			}
 
 			for _, diagType := range diagramTypes {
-				if strings.HasSuffix(relType, diagType) {
-					diagPath := resolveRelativePath(slidePath, target)
+				if types[SlideDiagrams] && strings.HasSuffix(relType, diagType) {
+					diagPath := resolveRelativePath(tempDir, slidePath, target)
 					diagRelPath, _ := filepath.Rel(tempDir, diagPath)
 					diagRelPath = filepath.ToSlash(diagRelPath)
 					filesToProcess[diagRelPath] = true
@@ -310,8 +543,8 @@ func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
 			}
 
 			// Process notes slides
-			if strings.HasSuffix(relType, "/notesSlide") {
-				notesPath := resolveRelativePath(slidePath, target)
+			if types[SlideNotes] && strings.HasSuffix(relType, "/notesSlide") {
+				notesPath := resolveRelativePath(tempDir, slidePath, target)
 				notesRelPath, _ := filepath.Rel(tempDir, notesPath)
 				notesRelPath = filepath.ToSlash(notesRelPath)
 				filesToProcess[notesRelPath] = true
@@ -322,10 +555,172 @@ func GetSlideContent(tempDir string, slideNums []int) (map[string]bool, error) {
 	return filesToProcess, nil
 }
 
-// resolveRelativePath resolves a relative path like "../charts/chart1.xml"
-// from a base path like "/tmp/ppt/slides/slide1.xml"
-func resolveRelativePath(basePath, target string) string {
+// readPartRels returns the Relationship elements of an extracted part's .rels file,
+// or nil if the part has none or it cannot be parsed
+func readPartRels(partPath string) []*xmlquery.Node {
+	relsPath := filepath.Join(filepath.Dir(partPath), "_rels", filepath.Base(partPath)+".rels")
+	relsFile, err := os.Open(relsPath)
+	if err != nil {
+		return nil
+	}
+	relsDoc, err := xmlquery.Parse(relsFile)
+	relsFile.Close()
+	if err != nil {
+		return nil
+	}
+	return xmlquery.Find(relsDoc, "//Relationship")
+}
+
+// resolveRelativePath resolves a relationship target like "../charts/chart1.xml"
+// from a base path like "/tmp/ppt/slides/slide1.xml". A target starting with '/'
+// is a part name (e.g., "/ppt/charts/chart1.xml") and is resolved against the
+// package root tempDir instead.
+func resolveRelativePath(tempDir, basePath, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return filepath.Join(tempDir, filepath.FromSlash(target))
+	}
 	baseDir := filepath.Dir(basePath)
 	targetPath := filepath.Join(baseDir, filepath.FromSlash(target))
 	return filepath.Clean(targetPath)
 }
+
+// SlideTheme pairs a visual slide number with the theme it resolves to
+type SlideTheme struct {
+	Slide  int    `json:"slide"`  // Visual slide number (1-indexed)
+	Path   string `json:"path"`   // Slide part path (e.g., "ppt/slides/slide1.xml")
+	Theme  string `json:"theme"`  // Theme file (e.g., "theme1.xml"), empty if unresolved
+	Hidden bool   `json:"hidden"` // Whether the slide is hidden in slide shows
+}
+
+// BuildSlideThemeMapping resolves the theme used by every slide, in visual order.
+// Resolution follows slide → slideLayout → slideMaster → theme relationships;
+// slides whose chain cannot be followed get an empty Theme.
+func BuildSlideThemeMapping(tempDir string) ([]SlideTheme, error) {
+	slideMapping, err := BuildSlideMapping(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	masterToTheme, _ := buildThemeRelationships(tempDir)
+	layoutToMaster, _ := buildLayoutToMasterMapping(tempDir)
+
+	slideNums := make([]int, 0, len(slideMapping))
+	for slideNum := range slideMapping {
+		slideNums = append(slideNums, slideNum)
+	}
+	sort.Ints(slideNums)
+
+	result := make([]SlideTheme, 0, len(slideNums))
+	for _, slideNum := range slideNums {
+		slideRelPath := slideMapping[slideNum]
+		theme, _ := getSlideTheme(filepath.Join(tempDir, slideRelPath), layoutToMaster, masterToTheme)
+		result = append(result, SlideTheme{
+			Slide:  slideNum,
+			Path:   filepath.ToSlash(slideRelPath),
+			Theme:  theme,
+			Hidden: isSlideHidden(filepath.Join(tempDir, slideRelPath)),
+		})
+	}
+
+	return result, nil
+}
+
+// textRunPattern matches a DrawingML text run's content (<a:t>...</a:t>), whatever
+// the namespace prefix
+var textRunPattern = regexp.MustCompile(`<(?:[A-Za-z_][\w.\-]*:)?t(?:\s[^>]*)?>([^<]*)</(?:[A-Za-z_][\w.\-]*:)?t>`)
+
+// firstTextRun returns the first non-blank text run of a slide part, with entities
+// decoded and whitespace collapsed, or "" if the slide has no text
+func firstTextRun(xmlContent []byte) string {
+	for _, match := range textRunPattern.FindAllSubmatch(xmlContent, -1) {
+		text := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+		if text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// titleShapeXPath finds shapes holding a title placeholder, whatever the namespace prefixes
+const titleShapeXPath = `//*[local-name()='sp'][*[local-name()='nvSpPr']/*[local-name()='nvPr']/*[local-name()='ph'][@type='title' or @type='ctrTitle']]`
+
+// GetSlideTitle returns the text of a slide's title placeholder (type "title" or
+// "ctrTitle"), falling back to the first text run when the slide has no title or
+// its title is empty. Paragraphs of a multi-line title are joined with spaces.
+// Returns "" for slides without text.
+func GetSlideTitle(slideXML []byte) string {
+	doc, err := xmlquery.Parse(bytes.NewReader(slideXML))
+	if err != nil {
+		return firstTextRun(slideXML)
+	}
+
+	if shape := xmlquery.FindOne(doc, titleShapeXPath); shape != nil {
+		var paragraphs []string
+		for _, paragraph := range xmlquery.Find(shape, ".//*[local-name()='p']") {
+			var text strings.Builder
+			for _, run := range xmlquery.Find(paragraph, ".//*[local-name()='t']") {
+				text.WriteString(run.InnerText())
+			}
+			paragraphs = append(paragraphs, text.String())
+		}
+		if title := strings.Join(strings.Fields(strings.Join(paragraphs, " ")), " "); title != "" {
+			return title
+		}
+	}
+
+	return firstTextRun(slideXML)
+}
+
+// SlideSummary is a one-line description of a slide, for previews
+type SlideSummary struct {
+	Slide   int    `json:"slide"`             // Visual slide number (1-indexed)
+	Title   string `json:"title"`             // Title placeholder or first text, empty if none
+	Hidden  bool   `json:"hidden"`            // Whether the slide is hidden in slide shows
+	Section string `json:"section,omitempty"` // Name of the slide's section, empty if none
+}
+
+// ListSlides returns a summary of every slide, in visual order
+func ListSlides(pptxPath string) ([]SlideSummary, error) {
+	var result []SlideSummary
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		slideMapping, err := BuildSlideMapping(tempDir)
+		if err != nil {
+			return err
+		}
+
+		slideNums := make([]int, 0, len(slideMapping))
+		for slideNum := range slideMapping {
+			slideNums = append(slideNums, slideNum)
+		}
+		sort.Ints(slideNums)
+
+		sections, err := ReadSections(tempDir)
+		if err != nil {
+			return err
+		}
+		slideSections := make(map[int]string)
+		for _, section := range sections {
+			for _, slideNum := range section.Slides {
+				slideSections[slideNum] = section.Name
+			}
+		}
+
+		for _, slideNum := range slideNums {
+			content, err := os.ReadFile(filepath.Join(tempDir, slideMapping[slideNum]))
+			if err != nil {
+				return err
+			}
+			result = append(result, SlideSummary{
+				Slide:   slideNum,
+				Title:   GetSlideTitle(content),
+				Hidden:  hiddenSlidePattern.Match(content),
+				Section: slideSections[slideNum],
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"archive/zip"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -411,6 +414,260 @@ func TestGetSlideContent(t *testing.T) {
 	})
 }
 
+func TestGetSlideContent_TargetForms(t *testing.T) {
+	relsXML := func(rels ...string) string {
+		return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			strings.Join(rels, "") + `</Relationships>`
+	}
+	rel := func(id, relType, target, mode string) string {
+		attrs := `Id="` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/` + relType + `" Target="` + target + `"`
+		if mode != "" {
+			attrs += ` TargetMode="` + mode + `"`
+		}
+		return `<Relationship ` + attrs + `/>`
+	}
+
+	path := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2, Parts: map[string]string{
+		// The slide itself is listed by part name
+		"ppt/_rels/presentation.xml.rels": relsXML(
+			rel("rId1", "slideMaster", "slideMasters/slideMaster1.xml", ""),
+			rel("rId2", "slide", "/ppt/slides/slide1.xml", ""),
+			rel("rId3", "theme", "theme/theme1.xml", ""),
+		),
+		"ppt/slides/_rels/slide1.xml.rels": relsXML(
+			rel("rId1", "slideLayout", "../slideLayouts/slideLayout1.xml", ""),
+			rel("rId2", "chart", "/ppt/charts/chart1.xml", ""),
+			rel("rId3", "notesSlide", "file:///C:/notes/notesSlide1.xml", "External"),
+			rel("rId4", "chart", "https://example.com/chart9.xml", "External"),
+		),
+		"ppt/charts/chart1.xml":            `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"/>`,
+		"ppt/charts/_rels/chart1.xml.rels": relsXML(rel("rId1", "chartColorStyle", "/ppt/charts/colors1.xml", "")),
+		"ppt/charts/colors1.xml":           `<cs:colorStyle xmlns:cs="http://schemas.microsoft.com/office/drawing/2012/chartStyle"/>`,
+	}})
+
+	err := withExtractedPPTX(path, func(tempDir string) error {
+		files, err := GetSlideContent(tempDir, []int{1})
+		if err != nil {
+			return err
+		}
+
+		want := map[string]bool{
+			"ppt/slides/slide1.xml":  true,
+			"ppt/charts/chart1.xml":  true,
+			"ppt/charts/colors1.xml": true,
+		}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("GetSlideContent() = %v, want %v", files, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetSlideContentWithTypes(t *testing.T) {
+	// Slide 1 has a chart (with a color style part), a diagram, and speaker notes
+	path := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2, Parts: map[string]string{
+		"ppt/slides/_rels/slide1.xml.rels": syntheticRelsXML(
+			[3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"},
+			[3]string{"rId2", "chart", "../charts/chart1.xml"},
+			[3]string{"rId3", "diagramData", "../diagrams/data1.xml"},
+			[3]string{"rId4", "diagramColors", "../diagrams/colors1.xml"},
+			[3]string{"rId5", "notesSlide", "../notesSlides/notesSlide1.xml"},
+		),
+		"ppt/charts/chart1.xml":            `<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart"/>`,
+		"ppt/charts/_rels/chart1.xml.rels": syntheticRelsXML([3]string{"rId1", "chartColorStyle", "colors1.xml"}),
+		"ppt/charts/colors1.xml":           `<cs:colorStyle xmlns:cs="http://schemas.microsoft.com/office/drawing/2012/chartStyle"/>`,
+		"ppt/diagrams/data1.xml":           `<dgm:dataModel xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram"/>`,
+		"ppt/diagrams/colors1.xml":         `<dgm:colorsDef xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram"/>`,
+		"ppt/notesSlides/notesSlide1.xml":  `<p:notes xmlns:p="` + presentationmlNS + `"/>`,
+	}})
+
+	parts := map[SlideContentType][]string{
+		SlideCharts:   {"ppt/charts/chart1.xml", "ppt/charts/colors1.xml"},
+		SlideDiagrams: {"ppt/diagrams/data1.xml", "ppt/diagrams/colors1.xml"},
+		SlideNotes:    {"ppt/notesSlides/notesSlide1.xml"},
+	}
+
+	err := withExtractedPPTX(path, func(tempDir string) error {
+		// Every combination of the three types
+		for mask := 0; mask < 8; mask++ {
+			types := map[SlideContentType]bool{
+				SlideCharts:   mask&1 != 0,
+				SlideDiagrams: mask&2 != 0,
+				SlideNotes:    mask&4 != 0,
+			}
+
+			want := map[string]bool{"ppt/slides/slide1.xml": true}
+			for contentType, enabled := range types {
+				if enabled {
+					for _, part := range parts[contentType] {
+						want[part] = true
+					}
+				}
+			}
+
+			files, err := GetSlideContentWithTypes(tempDir, []int{1}, types)
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("GetSlideContentWithTypes(%v) = %v, want %v", types, files, want)
+			}
+		}
+
+		// GetSlideContent enables every type
+		files, err := GetSlideContent(tempDir, []int{1})
+		if err != nil {
+			return err
+		}
+		if len(files) != 6 {
+			t.Errorf("GetSlideContent() = %v, want the slide and all 5 content parts", files)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sectionedPresentationXML returns a presentation part for a synthetic deck with the
+// given number of slides, grouped into sections of the given slide ids (slide n has
+// id 255+n, as in syntheticParts)
+func sectionedPresentationXML(slides int, sections map[string][]int, order ...string) string {
+	var sldIDs, sectionLst strings.Builder
+	for i := 1; i <= slides; i++ {
+		sldIDs.WriteString(fmt.Sprintf(`<p:sldId id="%d" r:id="rId%d"/>`, 255+i, i+1))
+	}
+	for _, name := range order {
+		sectionLst.WriteString(`<p14:section name="` + name + `" id="{00000000-0000-0000-0000-000000000000}"><p14:sldIdLst>`)
+		for _, id := range sections[name] {
+			sectionLst.WriteString(fmt.Sprintf(`<p14:sldId id="%d"/>`, id))
+		}
+		sectionLst.WriteString(`</p14:sldIdLst></p14:section>`)
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:presentation xmlns:a="` + drawingmlNS + `" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:p="` + presentationmlNS + `">` +
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>` +
+		`<p:sldIdLst>` + sldIDs.String() + `</p:sldIdLst>` +
+		`<p:sldSz cx="12192000" cy="6858000"/>` +
+		`<p:extLst><p:ext uri="{521415D9-36F7-43E2-AB2F-B90AF26B5E84}">` +
+		`<p14:sectionLst xmlns:p14="http://schemas.microsoft.com/office/powerpoint/2010/main">` + sectionLst.String() + `</p14:sectionLst>` +
+		`</p:ext></p:extLst></p:presentation>`
+}
+
+func TestResolveSections(t *testing.T) {
+	// Slide ids are listed out of slide order within a section, as after moving slides
+	sectioned := writeSyntheticPPTX(t, syntheticDeck{Slides: 5, ColorsPerSlide: 1, Parts: map[string]string{
+		"ppt/presentation.xml": sectionedPresentationXML(5, map[string][]int{
+			"Intro":   {256},
+			"Results": {258, 257, 259},
+			"Backup":  {260},
+			"Empty":   {},
+		}, "Intro", "Results", "Backup", "Empty"),
+	}})
+	plain := writeSyntheticPPTX(t, syntheticDeck{Slides: 2, ColorsPerSlide: 1})
+
+	tests := []struct {
+		name    string
+		path    string
+		names   []string
+		want    []int
+		wantErr string
+	}{
+		{name: "one section", path: sectioned, names: []string{"Results"}, want: []int{2, 3, 4}},
+		{name: "several sections", path: sectioned, names: []string{"Backup", "Intro"}, want: []int{1, 5}},
+		{name: "case-insensitive", path: sectioned, names: []string{" intro "}, want: []int{1}},
+		{name: "empty section", path: sectioned, names: []string{"Empty"}, want: []int{}},
+		{name: "unknown section", path: sectioned, names: []string{"Intro", "Outro"}, wantErr: "section(s) not found: Outro\nAvailable sections: Intro, Results, Backup, Empty"},
+		{name: "no sections", path: plain, names: []string{"Intro"}, wantErr: "section(s) not found: Intro\nThe presentation has no sections"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withExtractedPPTX(tt.path, func(tempDir string) error {
+				got, err := ResolveSections(tempDir, tt.names)
+				if tt.wantErr != "" {
+					var notFound *ErrSectionNotFound
+					if !errors.As(err, &notFound) || err.Error() != tt.wantErr {
+						t.Errorf("ResolveSections() error = %v, want %q", err, tt.wantErr)
+					}
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ResolveSections() = %v, want %v", got, tt.want)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestBuildSlideMapping_WithoutPresentationRels(t *testing.T) {
+	intact := writeSyntheticPPTX(t, syntheticDeck{Slides: 11, ColorsPerSlide: 1})
+	unreadable := writeSyntheticPPTX(t, syntheticDeck{Slides: 11, ColorsPerSlide: 1, Parts: map[string]string{
+		"ppt/_rels/presentation.xml.rels": `<Relationships><Relationship Id="rId2"`,
+	}})
+
+	// Slides are numbered by file name, slide2.xml before slide10.xml
+	want := make(map[int]string)
+	for i := 1; i <= 11; i++ {
+		want[i] = filepath.Join("ppt", "slides", fmt.Sprintf("slide%d.xml", i))
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		removeRels bool
+		byFileName bool
+	}{
+		{name: "intact", path: intact},
+		{name: "missing", path: intact, removeRels: true},
+		{name: "unreadable", path: unreadable, byFileName: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withExtractedPPTX(tt.path, func(tempDir string) error {
+				if tt.removeRels {
+					if err := os.Remove(filepath.Join(tempDir, "ppt", "_rels", "presentation.xml.rels")); err != nil {
+						return err
+					}
+				}
+				got, err := BuildSlideMapping(tempDir)
+				if err != nil {
+					return err
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("BuildSlideMapping() = %v, want %v", got, want)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.removeRels {
+				byFileName, err := checkSlideOrder(tt.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if byFileName != tt.byFileName {
+					t.Errorf("checkSlideOrder() = %v, want %v", byFileName, tt.byFileName)
+				}
+			}
+		})
+	}
+}
+
 func TestResolveRelativePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -442,14 +699,287 @@ func TestResolveRelativePath(t *testing.T) {
 			target:   "../slideLayouts/slideLayout1.xml",
 			want:     filepath.Clean("/tmp/ppt/slideLayouts/slideLayout1.xml"),
 		},
+		{
+			name:     "absolute part name",
+			basePath: "/tmp/ppt/slides/slide1.xml",
+			target:   "/ppt/charts/chart1.xml",
+			want:     filepath.Clean("/tmp/ppt/charts/chart1.xml"),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := resolveRelativePath(tt.basePath, tt.target)
+			got := resolveRelativePath(filepath.Clean("/tmp"), tt.basePath, tt.target)
 			if got != tt.want {
 				t.Errorf("resolveRelativePath() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestBuildSlideThemeMapping(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	t.Run("fixture slides resolve to their themes", func(t *testing.T) {
+		var slideThemes []SlideTheme
+		err := withExtractedPPTX(testPPTX, func(tempDir string) error {
+			var err error
+			slideThemes, err = BuildSlideThemeMapping(tempDir)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("BuildSlideThemeMapping() error = %v", err)
+		}
+
+		if len(slideThemes) != 13 {
+			t.Fatalf("expected 13 slides, got %d", len(slideThemes))
+		}
+
+		// From research: slides 1-7 use theme1, 8-10 theme2, 11-13 theme3
+		expected := map[int]string{1: "theme1.xml", 7: "theme1.xml", 8: "theme2.xml", 10: "theme2.xml", 11: "theme3.xml", 13: "theme3.xml"}
+		for _, st := range slideThemes {
+			if want, ok := expected[st.Slide]; ok && st.Theme != want {
+				t.Errorf("slide %d: expected %s, got %s", st.Slide, want, st.Theme)
+			}
+		}
+	})
+
+	t.Run("unresolvable slide has empty theme", func(t *testing.T) {
+		path := writeSyntheticPPTX(t, syntheticDeck{
+			Slides: 2,
+			Parts:  map[string]string{"ppt/slides/_rels/slide2.xml.rels": ""},
+		})
+
+		var slideThemes []SlideTheme
+		err := withExtractedPPTX(path, func(tempDir string) error {
+			var err error
+			slideThemes, err = BuildSlideThemeMapping(tempDir)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("BuildSlideThemeMapping() error = %v", err)
+		}
+
+		if len(slideThemes) != 2 {
+			t.Fatalf("expected 2 slides, got %d", len(slideThemes))
+		}
+		if slideThemes[0].Theme != "theme1.xml" {
+			t.Errorf("slide 1: expected theme1.xml, got %q", slideThemes[0].Theme)
+		}
+		if slideThemes[1].Theme != "" {
+			t.Errorf("slide 2: expected unresolved theme, got %q", slideThemes[1].Theme)
+		}
+	})
+}
+
+func TestFindHiddenSlides(t *testing.T) {
+	path := writeHiddenSlideDeck(t)
+
+	var hidden []int
+	var slideThemes []SlideTheme
+	err := withExtractedPPTX(path, func(tempDir string) error {
+		var err error
+		if hidden, err = FindHiddenSlides(tempDir); err != nil {
+			return err
+		}
+		slideThemes, err = BuildSlideThemeMapping(tempDir)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FindHiddenSlides() error = %v", err)
+	}
+
+	if len(hidden) != 1 || hidden[0] != 3 {
+		t.Errorf("FindHiddenSlides() = %v, want [3]", hidden)
+	}
+	for _, st := range slideThemes {
+		if st.Hidden != (st.Slide == 3) {
+			t.Errorf("slide %d: Hidden = %v", st.Slide, st.Hidden)
+		}
+	}
+
+	info, err := ReadPresentationInfo(path)
+	if err != nil {
+		t.Fatalf("ReadPresentationInfo() error = %v", err)
+	}
+	if info.Slides != 5 || len(info.HiddenSlides) != 1 || info.HiddenSlides[0] != 3 {
+		t.Errorf("ReadPresentationInfo() slides = %d, hidden = %v", info.Slides, info.HiddenSlides)
+	}
+}
+
+func TestResolveVisibleSlides(t *testing.T) {
+	path := writeHiddenSlideDeck(t)
+
+	tests := []struct {
+		name    string
+		visible []int
+		want    []int
+		wantErr string
+	}{
+		{name: "before the hidden slide", visible: []int{1, 2}, want: []int{1, 2}},
+		{name: "after the hidden slide", visible: []int{3, 4}, want: []int{4, 5}},
+		{name: "past the last visible slide", visible: []int{5}, wantErr: "visible slide 5 does not exist (presentation has 4 visible slides)"},
+		{name: "several past the end", visible: []int{2, 5, 6}, wantErr: "visible slides 5, 6 do not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			err := withExtractedPPTX(path, func(tempDir string) error {
+				var err error
+				got, err = ResolveVisibleSlides(tempDir, tt.visible)
+				return err
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveVisibleSlides() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVisibleSlides() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveVisibleSlides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessPPTXVisibleIndex(t *testing.T) {
+	inputPath := writeHiddenSlideDeck(t)
+
+	tests := []struct {
+		name         string
+		visibleIndex bool
+		wantChanged  string
+	}{
+		{name: "slide list position", visibleIndex: false, wantChanged: "ppt/slides/slide3.xml"},
+		{name: "visible position", visibleIndex: true, wantChanged: "ppt/slides/slide4.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.pptx")
+			_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "accent3"},
+				nil, "content", []int{3}, Options{VisibleIndex: tt.visibleIndex})
+			if err != nil {
+				t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+			}
+
+			for _, part := range []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml", "ppt/slides/slide3.xml", "ppt/slides/slide4.xml", "ppt/slides/slide5.xml"} {
+				changed := strings.Contains(string(readZipEntry(t, outputPath, part)), `<a:schemeClr val="accent3"/>`)
+				if changed != (part == tt.wantChanged) {
+					t.Errorf("%s changed = %v", part, changed)
+				}
+			}
+		})
+	}
+}
+
+func TestFirstTextRun(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want string
+	}{
+		{name: "first run", xml: `<p:sld><a:p><a:r><a:t>Agenda</a:t></a:r><a:r><a:t>Second</a:t></a:r></a:p></p:sld>`, want: "Agenda"},
+		{name: "skips blank runs", xml: `<a:t> </a:t><a:t>Title</a:t>`, want: "Title"},
+		{name: "decodes entities", xml: `<a:t>Q&amp;A &lt;live&gt;</a:t>`, want: "Q&A <live>"},
+		{name: "collapses whitespace", xml: `<a:t xml:space="preserve">  Two   words </a:t>`, want: "Two words"},
+		{name: "other prefix", xml: `<d:t>Drawing</d:t>`, want: "Drawing"},
+		{name: "ignores tab elements", xml: `<a:tab/><a:tcPr/>`, want: ""},
+		{name: "no text", xml: `<p:sld><p:cSld/></p:sld>`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstTextRun([]byte(tt.xml)); got != tt.want {
+				t.Errorf("firstTextRun() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSlides(t *testing.T) {
+	summaries, err := ListSlides(writeHiddenSlideDeck(t))
+	if err != nil {
+		t.Fatalf("ListSlides() error = %v", err)
+	}
+	if len(summaries) != 5 {
+		t.Fatalf("ListSlides() returned %d slides, want 5", len(summaries))
+	}
+	for i, summary := range summaries {
+		if summary.Slide != i+1 || summary.Title != "" || summary.Hidden != (i+1 == 3) {
+			t.Errorf("slide %d: got %+v", i+1, summary)
+		}
+	}
+
+	fixture := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(fixture); os.IsNotExist(err) {
+		t.Skip("Test file not found")
+	}
+	summaries, err = ListSlides(fixture)
+	if err != nil {
+		t.Fatalf("ListSlides() error = %v", err)
+	}
+	if len(summaries) != 13 || summaries[3].Title != "Theme 1: Chart" {
+		t.Errorf("ListSlides() = %+v", summaries)
+	}
+}
+
+func TestGetSlideTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		slide string
+		want  string
+	}{
+		{
+			name:  "title placeholder after body text",
+			slide: syntheticTitledSlideXML(2, syntheticTextShapeXML("body", "First bullet"), syntheticTextShapeXML("title", "Agenda")),
+			want:  "Agenda",
+		},
+		{
+			name:  "centered title",
+			slide: syntheticTitledSlideXML(0, syntheticTextShapeXML("subTitle", "Subtitle"), syntheticTextShapeXML("ctrTitle", "Quarterly Review")),
+			want:  "Quarterly Review",
+		},
+		{
+			name:  "multi-line title",
+			slide: syntheticTitledSlideXML(0, syntheticTextShapeXML("title", "Results", "and Outlook")),
+			want:  "Results and Outlook",
+		},
+		{
+			name:  "empty title falls back to first text",
+			slide: syntheticTitledSlideXML(0, syntheticTextShapeXML("title", ""), syntheticTextShapeXML("", "Free text")),
+			want:  "Free text",
+		},
+		{
+			name:  "no title placeholder",
+			slide: syntheticTitledSlideXML(2, syntheticTextShapeXML("body", "Only body")),
+			want:  "Only body",
+		},
+		{
+			name:  "no text",
+			slide: syntheticSlideXML(4),
+			want:  "",
+		},
+		{
+			name:  "malformed XML falls back to first text",
+			slide: `<p:sld><a:t>Broken</a:t>`,
+			want:  "Broken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSlideTitle([]byte(tt.slide)); got != tt.want {
+				t.Errorf("GetSlideTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
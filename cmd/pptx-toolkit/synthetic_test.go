@@ -0,0 +1,223 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// syntheticDeck describes a minimal but structurally complete presentation
+// that tests and benchmarks can generate without a binary fixture
+type syntheticDeck struct {
+	Slides         int               // Number of slides
+	ColorsPerSlide int               // Color references per slide (alternating schemeClr/srgbClr)
+	Parts          map[string]string // Extra or overriding parts, keyed by archive path
+}
+
+// syntheticSchemeCycle is the sequence of scheme colors written into synthetic slides
+var syntheticSchemeCycle = []string{"accent1", "accent2", "accent3", "accent4", "accent5", "accent6", "dk1", "lt1"}
+
+// syntheticHexCycle is the sequence of hex colors written into synthetic slides
+var syntheticHexCycle = []string{"AABBCC", "FF0000", "00FF00", "0000FF", "123456", "ABCDEF"}
+
+// syntheticSlideXML returns a slide with the given number of color references
+func syntheticSlideXML(colors int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	b.WriteString(`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>`)
+	for i := 0; i < colors; i++ {
+		b.WriteString(`<p:sp><p:spPr><a:solidFill>`)
+		if i%2 == 0 {
+			scheme := syntheticSchemeCycle[(i/2)%len(syntheticSchemeCycle)]
+			if i%4 == 0 {
+				b.WriteString(`<a:schemeClr val="` + scheme + `"/>`)
+			} else {
+				b.WriteString(`<a:schemeClr val="` + scheme + `"><a:lumMod val="75000"/></a:schemeClr>`)
+			}
+		} else {
+			b.WriteString(`<a:srgbClr val="` + syntheticHexCycle[(i/2)%len(syntheticHexCycle)] + `"/>`)
+		}
+		b.WriteString(`</a:solidFill></p:spPr></p:sp>`)
+	}
+	b.WriteString(`</p:spTree></p:cSld></p:sld>`)
+	return b.String()
+}
+
+// syntheticTextShapeXML returns a text shape with one paragraph per argument. A non-empty
+// phType makes it a placeholder of that type (e.g., "title", "ctrTitle", "body").
+func syntheticTextShapeXML(phType string, paragraphs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Text"/><p:cNvSpPr/><p:nvPr>`)
+	if phType != "" {
+		b.WriteString(`<p:ph type="` + phType + `"/>`)
+	}
+	b.WriteString(`</p:nvPr></p:nvSpPr><p:spPr/><p:txBody><a:bodyPr/>`)
+	for _, paragraph := range paragraphs {
+		b.WriteString(`<a:p><a:r><a:t>` + paragraph + `</a:t></a:r></a:p>`)
+	}
+	b.WriteString(`</p:txBody></p:sp>`)
+	return b.String()
+}
+
+// syntheticTitledSlideXML returns a slide with the given color references followed
+// by the given shapes (see syntheticTextShapeXML)
+func syntheticTitledSlideXML(colors int, shapes ...string) string {
+	return strings.Replace(syntheticSlideXML(colors), `</p:spTree>`, strings.Join(shapes, "")+`</p:spTree>`, 1)
+}
+
+// syntheticThemeXML returns a theme part with a full color scheme
+func syntheticThemeXML(themeName, schemeName string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<a:theme xmlns:a="` + drawingmlNS + `" name="` + themeName + `"><a:themeElements>` +
+		`<a:clrScheme name="` + schemeName + `">` +
+		`<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1>` +
+		`<a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>` +
+		`<a:dk2><a:srgbClr val="1F497D"/></a:dk2>` +
+		`<a:lt2><a:srgbClr val="EEECE1"/></a:lt2>` +
+		`<a:accent1><a:srgbClr val="4F81BD"/></a:accent1>` +
+		`<a:accent2><a:srgbClr val="C0504D"/></a:accent2>` +
+		`<a:accent3><a:srgbClr val="9BBB59"/></a:accent3>` +
+		`<a:accent4><a:srgbClr val="8064A2"/></a:accent4>` +
+		`<a:accent5><a:srgbClr val="4BACC6"/></a:accent5>` +
+		`<a:accent6><a:srgbClr val="F79646"/></a:accent6>` +
+		`<a:hlink><a:srgbClr val="0000FF"/></a:hlink>` +
+		`<a:folHlink><a:srgbClr val="800080"/></a:folHlink>` +
+		`</a:clrScheme></a:themeElements></a:theme>`
+}
+
+// syntheticRelsXML returns a relationships part from (id, type, target) triples
+func syntheticRelsXML(rels ...[3]string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for _, rel := range rels {
+		b.WriteString(`<Relationship Id="` + rel[0] + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/` + rel[1] + `" Target="` + rel[2] + `"/>`)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// syntheticParts builds the archive parts for a deck, before overrides are applied
+func syntheticParts(deck syntheticDeck) map[string]string {
+	parts := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>` +
+			`</Types>`,
+		"_rels/.rels":                                  syntheticRelsXML([3]string{"rId1", "officeDocument", "ppt/presentation.xml"}),
+		"ppt/theme/theme1.xml":                         syntheticThemeXML("Synthetic Theme", "Synthetic"),
+		"ppt/slideMasters/slideMaster1.xml":            `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><p:sldMaster xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:bg><p:bgPr><a:solidFill><a:schemeClr val="bg1"/></a:solidFill></p:bgPr></p:bg></p:cSld><p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/></p:sldMaster>`,
+		"ppt/slideMasters/_rels/slideMaster1.xml.rels": syntheticRelsXML([3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"}, [3]string{"rId2", "theme", "../theme/theme1.xml"}),
+		"ppt/slideLayouts/slideLayout1.xml":            `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><p:sldLayout xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree><p:sp><p:spPr><a:solidFill><a:schemeClr val="accent1"/></a:solidFill></p:spPr></p:sp></p:spTree></p:cSld></p:sldLayout>`,
+		"ppt/slideLayouts/_rels/slideLayout1.xml.rels": syntheticRelsXML([3]string{"rId1", "slideMaster", "../slideMasters/slideMaster1.xml"}),
+	}
+
+	var sldIDs strings.Builder
+	presRels := [][3]string{{"rId1", "slideMaster", "slideMasters/slideMaster1.xml"}}
+	for i := 1; i <= deck.Slides; i++ {
+		rID := fmt.Sprintf("rId%d", i+1)
+		sldIDs.WriteString(fmt.Sprintf(`<p:sldId id="%d" r:id="%s"/>`, 255+i, rID))
+		presRels = append(presRels, [3]string{rID, "slide", fmt.Sprintf("slides/slide%d.xml", i)})
+
+		parts[fmt.Sprintf("ppt/slides/slide%d.xml", i)] = syntheticSlideXML(deck.ColorsPerSlide)
+		parts[fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", i)] = syntheticRelsXML([3]string{"rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"})
+	}
+	presRels = append(presRels, [3]string{fmt.Sprintf("rId%d", deck.Slides+2), "theme", "theme/theme1.xml"})
+
+	parts["ppt/presentation.xml"] = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:presentation xmlns:a="` + drawingmlNS + `" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:p="` + presentationmlNS + `">` +
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>` +
+		`<p:sldIdLst>` + sldIDs.String() + `</p:sldIdLst>` +
+		`<p:sldSz cx="12192000" cy="6858000"/></p:presentation>`
+	parts["ppt/_rels/presentation.xml.rels"] = syntheticRelsXML(presRels...)
+
+	for name, content := range deck.Parts {
+		parts[name] = content
+	}
+
+	return parts
+}
+
+// writeSyntheticPPTX writes the deck as a PPTX in a test temp directory and returns its path.
+// An override part with empty content removes that part from the archive, except for
+// names ending in "/", which are written as explicit directory entries.
+func writeSyntheticPPTX(tb testing.TB, deck syntheticDeck) string {
+	tb.Helper()
+
+	parts := syntheticParts(deck)
+
+	// Write in sorted order so archives are reproducible
+	names := make([]string, 0, len(parts))
+	for name, content := range parts {
+		if content != "" || strings.HasSuffix(name, "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	path := filepath.Join(tb.TempDir(), "synthetic.pptx")
+	outFile, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer outFile.Close()
+
+	zipWriter := zip.NewWriter(outFile)
+	for _, name := range names {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := w.Write([]byte(parts[name])); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	return path
+}
+
+// writeHiddenSlideDeck writes a five-slide synthetic deck whose middle slide (3) is
+// hidden, so visible positions 3-4 are slides 4-5
+func writeHiddenSlideDeck(tb testing.TB) string {
+	tb.Helper()
+
+	hiddenSlide := strings.Replace(syntheticSlideXML(2), `<p:sld `, `<p:sld show="0" `, 1)
+	return writeSyntheticPPTX(tb, syntheticDeck{
+		Slides:         5,
+		ColorsPerSlide: 2,
+		Parts:          map[string]string{"ppt/slides/slide3.xml": hiddenSlide},
+	})
+}
+
+func TestWriteSyntheticPPTX(t *testing.T) {
+	path := writeSyntheticPPTX(t, syntheticDeck{Slides: 5, ColorsPerSlide: 8})
+
+	themes, err := ReadThemes(path)
+	if err != nil {
+		t.Fatalf("ReadThemes() error = %v", err)
+	}
+	if len(themes) != 1 || themes[0].Colors.Accent1 != "4F81BD" {
+		t.Errorf("unexpected themes: %+v", themes)
+	}
+
+	if err := VerifyOutput(path, path); err != nil {
+		t.Errorf("synthetic deck is not structurally sound: %v", err)
+	}
+
+	slides, err := countSlides(path)
+	if err != nil {
+		t.Fatalf("countSlides() error = %v", err)
+	}
+	if slides != 5 {
+		t.Errorf("expected 5 slides, got %d", slides)
+	}
+}
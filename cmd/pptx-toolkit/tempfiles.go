@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"sync"
+)
+
+// liveTemps holds the temporary files and directories in use. Deferred removals
+// run on normal returns and panics alike, but not when the process is killed by a
+// signal; the CLI removes whatever is still listed here before exiting on one.
+var liveTemps = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// trackTemp lists a temporary file or directory for removal on interrupt
+func trackTemp(path string) {
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	liveTemps.paths[path] = true
+}
+
+// forgetTemp unlists a temporary file that has been kept, e.g. renamed into place
+func forgetTemp(path string) {
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	delete(liveTemps.paths, path)
+}
+
+// removeTemp removes a tracked temporary file or directory and unlists it
+func removeTemp(path string) error {
+	forgetTemp(path)
+	return os.RemoveAll(path)
+}
+
+// removeAllTemps removes every tracked temporary file and directory, for use when
+// the process is about to exit without running its defers
+func removeAllTemps() {
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+
+	paths := make([]string, 0, len(liveTemps.paths))
+	for path := range liveTemps.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		os.RemoveAll(path)
+		delete(liveTemps.paths, path)
+	}
+}
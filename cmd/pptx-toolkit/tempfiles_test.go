@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditPackage_PanicRemovesTempDir(t *testing.T) {
+	var extracted string
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the edit to panic")
+			}
+		}()
+		editPackage(filepath.Join("testdata", "test.pptx"), filepath.Join(t.TempDir(), "output.pptx"), func(tempDir string) (map[string]bool, error) {
+			extracted = tempDir
+			panic("edit failed")
+		})
+	}()
+
+	if _, err := os.Stat(extracted); !os.IsNotExist(err) {
+		t.Errorf("extraction %s was not removed after a panic", extracted)
+	}
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	if liveTemps.paths[extracted] {
+		t.Errorf("extraction %s is still tracked after a panic", extracted)
+	}
+}
+
+func TestRemoveAllTemps(t *testing.T) {
+	dir := t.TempDir()
+	tracked := filepath.Join(dir, "tracked")
+	kept := filepath.Join(dir, "kept.pptx")
+	for _, path := range []string{tracked, kept} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		trackTemp(path)
+	}
+	forgetTemp(kept)
+
+	removeAllTemps()
+
+	if _, err := os.Stat(tracked); !os.IsNotExist(err) {
+		t.Errorf("tracked temp %s was not removed", tracked)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("forgotten temp %s was removed: %v", kept, err)
+	}
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	if len(liveTemps.paths) != 0 {
+		t.Errorf("removeAllTemps() left %d paths tracked", len(liveTemps.paths))
+	}
+}
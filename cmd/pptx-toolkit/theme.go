@@ -4,8 +4,12 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/antchfx/xmlquery"
 )
@@ -26,12 +30,63 @@ type ColorScheme struct {
 	FolHlink string `json:"folHlink"`
 }
 
+// schemeColorNames lists the clrScheme slots in document order
+var schemeColorNames = []string{
+	"dk1", "lt1", "dk2", "lt2",
+	"accent1", "accent2", "accent3", "accent4", "accent5", "accent6",
+	"hlink", "folHlink",
+}
+
+// Get returns the hex value of a scheme color slot by name, or "" for an unknown name
+func (c ColorScheme) Get(name string) string {
+	switch name {
+	case "dk1":
+		return c.Dk1
+	case "lt1":
+		return c.Lt1
+	case "dk2":
+		return c.Dk2
+	case "lt2":
+		return c.Lt2
+	case "accent1":
+		return c.Accent1
+	case "accent2":
+		return c.Accent2
+	case "accent3":
+		return c.Accent3
+	case "accent4":
+		return c.Accent4
+	case "accent5":
+		return c.Accent5
+	case "accent6":
+		return c.Accent6
+	case "hlink":
+		return c.Hlink
+	case "folHlink":
+		return c.FolHlink
+	}
+	return ""
+}
+
 // Theme represents a PowerPoint theme
 type Theme struct {
-	FileName        string      `json:"fileName"`        // e.g., "theme1.xml"
-	ThemeName       string      `json:"themeName"`       // e.g., "Office Theme Deck"
-	ColorSchemeName string      `json:"colorSchemeName"` // e.g., "Office"
-	Colors          ColorScheme `json:"colors"`
+	FileName          string             `json:"fileName"`                    // e.g., "theme1.xml"
+	ThemeName         string             `json:"themeName"`                   // e.g., "Office Theme Deck"
+	ColorSchemeName   string             `json:"colorSchemeName"`             // e.g., "Office"
+	Colors            ColorScheme        `json:"colors"`                      // The theme's own color scheme
+	ExtraColorSchemes []ExtraColorScheme `json:"extraColorSchemes,omitempty"` // Schemes in extraClrSchemeLst, in order
+
+	// UndefinedColors lists the slots of the theme's own scheme that are missing or
+	// hold no color, in document order. They read as 000000 in Colors.
+	UndefinedColors []string `json:"undefinedColors,omitempty"`
+}
+
+// ExtraColorScheme is an additional color scheme a theme carries in its
+// extraClrSchemeLst. PowerPoint offers these as alternatives; they color nothing
+// until one is chosen.
+type ExtraColorScheme struct {
+	Name   string      `json:"name"`
+	Colors ColorScheme `json:"colors"`
 }
 
 // extractRGBColor extracts RGB color value from a color definition element
@@ -75,7 +130,8 @@ func parseThemeXML(xmlContent []byte, fileName string) (*Theme, error) {
 		themeName = fileName
 	}
 
-	// Find color scheme
+	// Find color scheme. The theme's own comes first, in themeElements; any others
+	// are extras in extraClrSchemeLst.
 	clrScheme := xmlquery.FindOne(doc, "//*[local-name()='clrScheme']")
 	if clrScheme == nil {
 		return nil, fmt.Errorf("no clrScheme element found")
@@ -86,14 +142,43 @@ func parseThemeXML(xmlContent []byte, fileName string) (*Theme, error) {
 		colorSchemeName = "Unknown"
 	}
 
-	// Extract all scheme colors
+	// Additional schemes offered alongside the theme's own
+	var extras []ExtraColorScheme
+	for _, extra := range xmlquery.Find(doc, "//*[local-name()='extraClrSchemeLst']/*[local-name()='extraClrScheme']/*[local-name()='clrScheme']") {
+		extras = append(extras, ExtraColorScheme{Name: extra.SelectAttr("name"), Colors: schemeColors(extra)})
+	}
+
+	return &Theme{
+		FileName:          fileName,
+		ThemeName:         themeName,
+		ColorSchemeName:   colorSchemeName,
+		Colors:            schemeColors(clrScheme),
+		ExtraColorSchemes: extras,
+		UndefinedColors:   undefinedSlots(clrScheme),
+	}, nil
+}
+
+// undefinedSlots returns the slots of a clrScheme element that are missing or have
+// no color element, in document order. Minimal themes written by other tools may
+// define only some of them.
+func undefinedSlots(clrScheme *xmlquery.Node) []string {
+	var undefined []string
+	for _, name := range schemeColorNames {
+		slot := clrScheme.SelectElement(fmt.Sprintf("*[local-name()='%s']", name))
+		if slot == nil || slot.SelectElement("*") == nil {
+			undefined = append(undefined, name)
+		}
+	}
+	return undefined
+}
+
+// schemeColors extracts the slot colors of a clrScheme element
+func schemeColors(clrScheme *xmlquery.Node) ColorScheme {
 	getColor := func(name string) string {
-		xpath := fmt.Sprintf("//*[local-name()='clrScheme']/*[local-name()='%s']", name)
-		elem := xmlquery.FindOne(doc, xpath)
-		return extractRGBColor(elem)
+		return extractRGBColor(clrScheme.SelectElement(fmt.Sprintf("*[local-name()='%s']", name)))
 	}
 
-	colors := ColorScheme{
+	return ColorScheme{
 		Dk1:      getColor("dk1"),
 		Lt1:      getColor("lt1"),
 		Dk2:      getColor("dk2"),
@@ -107,13 +192,6 @@ func parseThemeXML(xmlContent []byte, fileName string) (*Theme, error) {
 		Hlink:    getColor("hlink"),
 		FolHlink: getColor("folHlink"),
 	}
-
-	return &Theme{
-		FileName:        fileName,
-		ThemeName:       themeName,
-		ColorSchemeName: colorSchemeName,
-		Colors:          colors,
-	}, nil
 }
 
 // ReadThemes reads all themes from a PowerPoint file
@@ -128,14 +206,15 @@ func ReadThemes(pptxPath string) ([]*Theme, error) {
 	var themeFiles []string
 
 	// Collect theme files
+	themeDir := rootPath(zipDocumentRoot(zipReader.File), "theme")
 	for _, file := range zipReader.File {
-		if filepath.Dir(file.Name) == "ppt/theme" && filepath.Ext(file.Name) == ".xml" {
+		if path.Dir(file.Name) == themeDir && path.Ext(file.Name) == ".xml" {
 			themeFiles = append(themeFiles, file.Name)
 		}
 	}
 
-	// Sort for consistent ordering (theme1, theme2, etc.)
-	sort.Strings(themeFiles)
+	// Sort for consistent ordering (theme1, theme2, ..., theme10)
+	sortNatural(themeFiles)
 
 	// Parse each theme file
 	for _, themeFile := range themeFiles {
@@ -161,3 +240,211 @@ func ReadThemes(pptxPath string) ([]*Theme, error) {
 
 	return themes, nil
 }
+
+// ReadThemesDir is ReadThemes for a presentation already extracted to dir (e.g., by
+// unzip or a build system that keeps OOXML packages expanded)
+func ReadThemesDir(dir string) ([]*Theme, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("input directory not found: %s", dir)
+	}
+
+	themeFiles, err := filepath.Glob(filepath.Join(docPath(dir, "theme"), "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	sortNatural(themeFiles)
+
+	var themes []*Theme
+	for _, themeFile := range themeFiles {
+		content, err := os.ReadFile(themeFile)
+		if err != nil {
+			continue
+		}
+
+		theme, err := parseThemeXML(content, filepath.Base(themeFile))
+		if err == nil {
+			themes = append(themes, theme)
+		}
+	}
+
+	return themes, nil
+}
+
+// ListThemeNames returns the names of the theme parts in a presentation, as accepted
+// by --theme (e.g., "theme1"), sorted. Unlike ReadThemes it does not parse the themes.
+func ListThemeNames(pptxPath string) ([]string, error) {
+	zipReader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX file: %w", err)
+	}
+	defer zipReader.Close()
+
+	var themeFiles []string
+	themeDir := rootPath(zipDocumentRoot(zipReader.File), "theme")
+	for _, file := range zipReader.File {
+		if path.Dir(file.Name) == themeDir && path.Ext(file.Name) == ".xml" {
+			themeFiles = append(themeFiles, filepath.Base(file.Name))
+		}
+	}
+
+	return themeNames(themeFiles), nil
+}
+
+// MatchThemeNames returns the themes, as accepted by --theme (e.g., "theme1"), whose
+// color scheme name or theme name matches one of names, case-insensitively. A name
+// that is exactly some theme's name selects only the themes with that name;
+// otherwise it selects every theme whose names contain it. Names matching no theme
+// are reported as *ErrThemeNameNotFound.
+func MatchThemeNames(themes []*Theme, names []string) ([]string, error) {
+	var matched []string
+	var missing []string
+	for _, name := range names {
+		want := strings.ToLower(strings.TrimSpace(name))
+
+		var exact, partial []string
+		for _, theme := range themes {
+			schemeName := strings.ToLower(theme.ColorSchemeName)
+			themeName := strings.ToLower(theme.ThemeName)
+			switch {
+			case schemeName == want || themeName == want:
+				exact = append(exact, theme.FileName)
+			case strings.Contains(schemeName, want) || strings.Contains(themeName, want):
+				partial = append(partial, theme.FileName)
+			}
+		}
+
+		switch {
+		case want == "":
+			missing = append(missing, name)
+		case len(exact) > 0:
+			matched = append(matched, exact...)
+		case len(partial) > 0:
+			matched = append(matched, partial...)
+		default:
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		available := make([]string, 0, len(themes))
+		for _, theme := range themes {
+			available = append(available, fmt.Sprintf("%q / %q (%s)", theme.ColorSchemeName, theme.ThemeName,
+				strings.TrimSuffix(theme.FileName, ".xml")))
+		}
+		return nil, &ErrThemeNameNotFound{Names: missing, Available: available}
+	}
+
+	return themeNames(matched), nil
+}
+
+// ResolveThemeNames is MatchThemeNames for the themes of a PPTX file
+func ResolveThemeNames(pptxPath string, names []string) ([]string, error) {
+	themes, err := ReadThemes(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	return MatchThemeNames(themes, names)
+}
+
+// GetColorScheme returns the color scheme of a single theme. The theme can be named
+// with or without its extension (e.g., "theme1" or "theme1.xml").
+func GetColorScheme(pptxPath, themeName string) (*ColorScheme, error) {
+	themes, err := ReadThemes(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := themeName
+	if !strings.HasSuffix(fileName, ".xml") {
+		fileName += ".xml"
+	}
+
+	available := make([]string, 0, len(themes))
+	for _, theme := range themes {
+		if theme.FileName == fileName {
+			colors := theme.Colors
+			return &colors, nil
+		}
+		available = append(available, theme.FileName)
+	}
+
+	return nil, fmt.Errorf("theme '%s' not found. Available themes: %s",
+		strings.TrimSuffix(themeName, ".xml"), strings.Join(themeNames(available), ", "))
+}
+
+// clrSchemePattern matches a clrScheme element of a theme part. The first match is
+// the theme's own scheme; later ones are in extraClrSchemeLst.
+var clrSchemePattern = regexp.MustCompile(`(?s)<(?:\w+:)?clrScheme\b[^>]*>.*?</(?:\w+:)?clrScheme>`)
+
+// SetSchemeColors rewrites color slots in a theme part's own clrScheme; extra
+// schemes (extraClrSchemeLst) are left alone.
+// Keys of colors are scheme color names and values are 6-digit hex colors; each
+// named slot's definition is replaced with a single srgbClr. Slots not present
+// in the theme are left alone.
+func SetSchemeColors(themeXML []byte, colors map[string]string) ([]byte, error) {
+	edits, err := schemeSlotEdits(themeXML, colors)
+	if err != nil {
+		return nil, err
+	}
+	return applyEdits(themeXML, edits), nil
+}
+
+// schemeSlotEdits returns the edits SetSchemeColors makes, one per slot element
+// replaced, sorted by offset
+func schemeSlotEdits(themeXML []byte, colors map[string]string) ([]byteEdit, error) {
+	for name, hex := range colors {
+		if !ValidSchemeColors[name] {
+			return nil, fmt.Errorf("invalid scheme color: '%s'", name)
+		}
+		if !isValidHexColor(hex) {
+			return nil, fmt.Errorf("invalid hex color for %s: '%s'", name, hex)
+		}
+	}
+
+	loc := clrSchemePattern.FindIndex(themeXML)
+	if loc == nil {
+		return nil, fmt.Errorf("no clrScheme element found")
+	}
+
+	scheme := themeXML[loc[0]:loc[1]]
+	var edits []byteEdit
+	for name, hex := range colors {
+		slotPattern := regexp.MustCompile(`(?s)<(\w+:)?` + name + `>.*?</(?:\w+:)?` + name + `>`)
+		for _, match := range slotPattern.FindAllSubmatchIndex(scheme, -1) {
+			prefix := ""
+			if match[2] != -1 {
+				prefix = string(scheme[match[2]:match[3]])
+			}
+			replacement := `<` + prefix + name + `><` + prefix + `srgbClr val="` + strings.ToUpper(hex) + `"/></` + prefix + name + `>`
+			edits = append(edits, byteEdit{loc[0] + match[0], loc[0] + match[1], []byte(replacement)})
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	return edits, nil
+}
+
+// themeColorChanges derives the clrScheme slot updates implied by a color mapping.
+// A scheme→hex mapping sets that slot; a hex→hex mapping updates every slot that
+// currently holds the source hex. Mappings with a scheme color target are ignored,
+// since they only make sense for references. An explicit scheme→hex mapping wins
+// over a hex→hex mapping that happens to match the same slot.
+func themeColorChanges(colors ColorScheme, colorMapping map[string]string) map[string]string {
+	changes := make(map[string]string)
+	for source, target := range colorMapping {
+		if !isValidHexColor(source) || !isValidHexColor(target) {
+			continue
+		}
+		for _, name := range schemeColorNames {
+			if strings.EqualFold(colors.Get(name), source) {
+				changes[name] = target
+			}
+		}
+	}
+	for source, target := range colorMapping {
+		if ValidSchemeColors[source] && isValidHexColor(target) {
+			changes[source] = target
+		}
+	}
+	return changes
+}
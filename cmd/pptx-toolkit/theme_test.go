@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -173,3 +176,301 @@ func TestParseThemeXML_SystemColors(t *testing.T) {
 		t.Errorf("expected accent1 '156082', got '%s'", theme.Colors.Accent1)
 	}
 }
+
+func TestSetSchemeColors(t *testing.T) {
+	input := []byte(syntheticThemeXML("Test Theme", "Test"))
+
+	output, err := SetSchemeColors(input, map[string]string{"accent1": "ff0000", "dk1": "112233"})
+	if err != nil {
+		t.Fatalf("SetSchemeColors() error = %v", err)
+	}
+
+	theme, err := parseThemeXML(output, "theme1.xml")
+	if err != nil {
+		t.Fatalf("output is not a valid theme: %v", err)
+	}
+
+	if theme.Colors.Accent1 != "FF0000" {
+		t.Errorf("accent1 = %s, want FF0000", theme.Colors.Accent1)
+	}
+	if theme.Colors.Dk1 != "112233" {
+		t.Errorf("dk1 = %s, want 112233 (sysClr replaced)", theme.Colors.Dk1)
+	}
+	if theme.Colors.Accent2 != "C0504D" {
+		t.Errorf("accent2 = %s, want it unchanged", theme.Colors.Accent2)
+	}
+	if theme.ColorSchemeName != "Test" {
+		t.Errorf("color scheme name = %s, want it unchanged", theme.ColorSchemeName)
+	}
+
+	if _, err := SetSchemeColors(input, map[string]string{"accent1": "accent2"}); err == nil {
+		t.Error("expected error for non-hex value")
+	}
+	if _, err := SetSchemeColors([]byte(`<a:theme/>`), map[string]string{"accent1": "FF0000"}); err == nil {
+		t.Error("expected error for theme without clrScheme")
+	}
+}
+
+func TestThemeColorChanges(t *testing.T) {
+	colors := ColorScheme{Dk2: "1F497D", Accent1: "4F81BD", Accent2: "4F81BD", Accent3: "9BBB59"}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "scheme to hex sets slot",
+			mapping: map[string]string{"accent3": "FF0000"},
+			want:    map[string]string{"accent3": "FF0000"},
+		},
+		{
+			name:    "hex to hex updates every matching slot",
+			mapping: map[string]string{"4f81bd": "00FF00"},
+			want:    map[string]string{"accent1": "00FF00", "accent2": "00FF00"},
+		},
+		{
+			name:    "explicit scheme mapping wins",
+			mapping: map[string]string{"4F81BD": "00FF00", "accent1": "FF0000"},
+			want:    map[string]string{"accent1": "FF0000", "accent2": "00FF00"},
+		},
+		{
+			name:    "scheme targets are ignored",
+			mapping: map[string]string{"accent1": "accent2", "1F497D": "accent3"},
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := themeColorChanges(colors, tt.mapping)
+			if len(got) != len(tt.want) {
+				t.Fatalf("themeColorChanges() = %v, want %v", got, tt.want)
+			}
+			for name, hex := range tt.want {
+				if got[name] != hex {
+					t.Errorf("%s = %s, want %s", name, got[name], hex)
+				}
+			}
+		})
+	}
+}
+
+func TestGetColorScheme(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	themes, err := ReadThemes(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"theme2", "theme2.xml"} {
+		colors, err := GetColorScheme(testPPTX, name)
+		if err != nil {
+			t.Fatalf("GetColorScheme(%q) error = %v", name, err)
+		}
+		if *colors != themes[1].Colors {
+			t.Errorf("GetColorScheme(%q) = %+v, want %+v", name, *colors, themes[1].Colors)
+		}
+	}
+
+	_, err = GetColorScheme(testPPTX, "theme9")
+	if err == nil {
+		t.Fatal("expected error for missing theme")
+	}
+	if !strings.Contains(err.Error(), "theme1, theme2, theme3, theme4, theme5") {
+		t.Errorf("expected error to list available themes, got: %v", err)
+	}
+}
+
+func TestListThemeNames(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	names, err := ListThemeNames(testPPTX)
+	if err != nil {
+		t.Fatalf("ListThemeNames() error = %v", err)
+	}
+
+	themes, err := ReadThemes(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(themes) {
+		t.Fatalf("ListThemeNames() = %v, ReadThemes() found %d themes", names, len(themes))
+	}
+	for i, theme := range themes {
+		if names[i]+".xml" != theme.FileName {
+			t.Errorf("names[%d] = %s, ReadThemes() file = %s", i, names[i], theme.FileName)
+		}
+	}
+}
+
+func TestMatchThemeNames(t *testing.T) {
+	themes := []*Theme{
+		{FileName: "theme1.xml", ThemeName: "Office Theme", ColorSchemeName: "Office"},
+		{FileName: "theme2.xml", ThemeName: "Corporate Deck", ColorSchemeName: "Corporate Brand"},
+		{FileName: "theme3.xml", ThemeName: "Corporate Deck (Dark)", ColorSchemeName: "Corporate Brand Dark"},
+		{FileName: "theme4.xml", ThemeName: "Office Theme", ColorSchemeName: "Office 2013"},
+	}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr []string // Names reported as not found
+	}{
+		{name: "exact scheme name", names: []string{"Corporate Brand"}, want: []string{"theme2"}},
+		{name: "case-insensitive", names: []string{"corporate brand dark"}, want: []string{"theme3"}},
+		{name: "exact theme name shared by several themes", names: []string{"Office Theme"}, want: []string{"theme1", "theme4"}},
+		{name: "exact match wins over partial", names: []string{"Office"}, want: []string{"theme1"}},
+		{name: "partial match selects every theme containing it", names: []string{"corporate"}, want: []string{"theme2", "theme3"}},
+		{name: "several names", names: []string{"Office 2013", "Dark"}, want: []string{"theme3", "theme4"}},
+		{name: "unknown names", names: []string{"Office", "Brandless", " "}, wantErr: []string{"Brandless", " "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchThemeNames(themes, tt.names)
+			if tt.wantErr != nil {
+				var notFound *ErrThemeNameNotFound
+				if !errors.As(err, &notFound) {
+					t.Fatalf("MatchThemeNames() error = %v, want ErrThemeNameNotFound", err)
+				}
+				if !reflect.DeepEqual(notFound.Names, tt.wantErr) || len(notFound.Available) != len(themes) {
+					t.Errorf("ErrThemeNameNotFound = %+v, want names %v and all themes available", notFound, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchThemeNames() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchThemeNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// extraSchemesThemeXML is a theme whose object defaults reference accent1 and
+// C0504D, with two extra color schemes: "Alt One" (accent1 112233) and "Alt Two",
+// a copy of the theme's own
+func extraSchemesThemeXML() string {
+	extra := func(name, accent1 string) string {
+		theme := syntheticThemeXML("", name)
+		scheme := theme[strings.Index(theme, "<a:clrScheme"):strings.Index(theme, "</a:themeElements>")]
+		return `<a:extraClrScheme>` + strings.Replace(scheme, `val="4F81BD"`, `val="`+accent1+`"`, 1) +
+			`<a:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+			`</a:extraClrScheme>`
+	}
+	return strings.Replace(syntheticThemeXML("Extras Theme", "Main"), `</a:themeElements>`,
+		`</a:themeElements><a:objectDefaults><a:spDef><a:spPr/><a:bodyPr/><a:lstStyle/><a:style>`+
+			`<a:fillRef idx="1"><a:schemeClr val="accent1"/></a:fillRef>`+
+			`<a:fontRef idx="minor"><a:srgbClr val="C0504D"/></a:fontRef>`+
+			`</a:style></a:spDef></a:objectDefaults>`+
+			`<a:extraClrSchemeLst>`+extra("Alt One", "112233")+extra("Alt Two", "4F81BD")+`</a:extraClrSchemeLst>`, 1)
+}
+
+func TestParseThemeXML_ExtraSchemes(t *testing.T) {
+	theme, err := parseThemeXML([]byte(extraSchemesThemeXML()), "theme1.xml")
+	if err != nil {
+		t.Fatalf("parseThemeXML() error = %v", err)
+	}
+
+	if theme.ColorSchemeName != "Main" || theme.Colors.Accent1 != "4F81BD" {
+		t.Errorf("own scheme = %s (accent1 %s), want Main (accent1 4F81BD)", theme.ColorSchemeName, theme.Colors.Accent1)
+	}
+	if len(theme.ExtraColorSchemes) != 2 {
+		t.Fatalf("extra schemes = %+v, want 2", theme.ExtraColorSchemes)
+	}
+	if extra := theme.ExtraColorSchemes[0]; extra.Name != "Alt One" || extra.Colors.Accent1 != "112233" || extra.Colors.Accent2 != "C0504D" {
+		t.Errorf("first extra scheme = %+v, want Alt One with accent1 112233", extra)
+	}
+	if extra := theme.ExtraColorSchemes[1]; extra.Name != "Alt Two" || extra.Colors != theme.Colors {
+		t.Errorf("second extra scheme = %+v, want Alt Two with the theme's own colors", extra)
+	}
+
+	// Setting slots touches the theme's own scheme only
+	output, err := SetSchemeColors([]byte(extraSchemesThemeXML()), map[string]string{"accent1": "FF0000"})
+	if err != nil {
+		t.Fatalf("SetSchemeColors() error = %v", err)
+	}
+	updated, err := parseThemeXML(output, "theme1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Colors.Accent1 != "FF0000" {
+		t.Errorf("accent1 = %s, want FF0000", updated.Colors.Accent1)
+	}
+	if !reflect.DeepEqual(updated.ExtraColorSchemes, theme.ExtraColorSchemes) {
+		t.Errorf("extra schemes changed: %+v, want %+v", updated.ExtraColorSchemes, theme.ExtraColorSchemes)
+	}
+}
+
+func TestSwapThemeExtraSchemes(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2, Parts: map[string]string{
+		"ppt/theme/theme1.xml": extraSchemesThemeXML(),
+	}})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	// Hex and scheme targets, plus a catch-all, all with the theme scheme in play
+	mapping := map[string]string{"accent1": "accent2", "4F81BD": "accent3", "C0504D": "00FF00"}
+	_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, mapping, nil, "theme", nil, Options{MapUnmatchedTo: "808080"})
+	if err != nil {
+		t.Fatalf("ProcessPPTXWithOptions() error = %v", err)
+	}
+
+	content := string(readZipEntry(t, outputPath, "ppt/theme/theme1.xml"))
+	before := extraSchemesThemeXML()
+	extras := func(xml string) string {
+		return xml[strings.Index(xml, "<a:extraClrSchemeLst>"):strings.Index(xml, "</a:extraClrSchemeLst>")]
+	}
+	if extras(content) != extras(before) {
+		t.Errorf("extra color schemes changed:\n%s\nwant:\n%s", extras(content), extras(before))
+	}
+
+	// Object defaults are references, and are swapped
+	for _, want := range []string{`<a:fillRef idx="1"><a:schemeClr val="accent2"/></a:fillRef>`, `<a:fontRef idx="minor"><a:srgbClr val="00FF00"/></a:fontRef>`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("object defaults missing %s", want)
+		}
+	}
+}
+
+// minimalThemeXML returns a theme whose scheme leaves out accent5 and accent6 and
+// has an empty hlink, as written by some non-Office tools
+func minimalThemeXML() string {
+	return strings.NewReplacer(
+		`<a:accent5><a:srgbClr val="4BACC6"/></a:accent5>`, ``,
+		`<a:accent6><a:srgbClr val="F79646"/></a:accent6>`, ``,
+		`<a:hlink><a:srgbClr val="0000FF"/></a:hlink>`, `<a:hlink/>`,
+	).Replace(syntheticThemeXML("Minimal", "Minimal"))
+}
+
+func TestParseThemeXML_UndefinedColors(t *testing.T) {
+	theme, err := parseThemeXML([]byte(minimalThemeXML()), "theme1.xml")
+	if err != nil {
+		t.Fatalf("parseThemeXML() error = %v", err)
+	}
+	if want := []string{"accent5", "accent6", "hlink"}; !reflect.DeepEqual(theme.UndefinedColors, want) {
+		t.Errorf("UndefinedColors = %v, want %v", theme.UndefinedColors, want)
+	}
+	if theme.Colors.Accent6 != "000000" {
+		t.Errorf("undefined accent6 = %s, want 000000", theme.Colors.Accent6)
+	}
+
+	complete, err := parseThemeXML([]byte(syntheticThemeXML("Complete", "Complete")), "theme1.xml")
+	if err != nil {
+		t.Fatalf("parseThemeXML() error = %v", err)
+	}
+	if complete.UndefinedColors != nil {
+		t.Errorf("UndefinedColors = %v for a complete scheme, want none", complete.UndefinedColors)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "Theme-related operations",
+	Long:  "Theme-related operations for PowerPoint files.",
+}
+
+var themeMergeCmd = &cobra.Command{
+	Use:   "merge <a.pptx> <b.pptx> [output.pptx]",
+	Short: "Report and resolve theme collisions between two presentations",
+	Long: `Report and resolve theme collisions between two presentations.
+
+Lists the themes of b.pptx whose part names (theme1.xml, ...) or display names
+collide with a theme of a.pptx. Given an output file, writes a copy of b.pptx
+with those themes renumbered and renamed, and every relationship to them updated,
+so that its slides can be combined with a.pptx without clashes.
+
+Slides are not copied between the files; the output is b.pptx, prepared for merging.
+
+Examples:
+  # Report collisions only
+  pptx-toolkit theme merge a.pptx b.pptx
+
+  # Write b.pptx with colliding themes renumbered and renamed
+  pptx-toolkit theme merge a.pptx b.pptx b-renamed.pptx`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: runThemeMerge,
+}
+
+var themeDedupeCmd = &cobra.Command{
+	Use:   "dedupe <input.pptx>",
+	Short: "Report duplicate theme definitions",
+	Long: `Report duplicate theme definitions: theme parts that are byte-identical, and
+themes whose color schemes have the same 12 colors.
+
+Duplicates are reported, not removed. Each slide, notes, and handout master owns
+its theme part, so collapsing them is left to PowerPoint.
+
+Examples:
+  pptx-toolkit theme dedupe input.pptx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThemeDedupe,
+}
+
+func init() {
+	themeCmd.AddCommand(themeMergeCmd)
+	themeCmd.AddCommand(themeDedupeCmd)
+}
+
+func runThemeMerge(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	firstFile := args[0]
+	secondFile := args[1]
+
+	for _, inputFile := range []string{firstFile, secondFile} {
+		if err := ValidateInputFile(inputFile); err != nil {
+			cmd.PrintErrln("Error:", err)
+			return exitWith(exitCodeFor(err))
+		}
+	}
+
+	renames, err := PlanThemeMerge(firstFile, secondFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	if len(renames) == 0 {
+		cmd.Printf("No theme collisions between %s and %s\n", firstFile, secondFile)
+	} else {
+		cmd.Printf("Theme collisions in %s:\n", secondFile)
+		for _, rename := range renames {
+			cmd.Printf("  %s (%s) → %s (%s)\n", rename.OldFile, rename.OldName, rename.NewFile, rename.NewName)
+		}
+	}
+
+	if len(args) < 3 {
+		return nil
+	}
+	outputFile := args[2]
+
+	WarnOutputExtension(cmd, secondFile, outputFile)
+
+	// Prompt for overwrite if needed
+	if shouldContinue, err := PromptOverwrite(cmd, outputFile); err != nil || !shouldContinue {
+		return exitWith(ExitAborted)
+	}
+
+	themesRenamed, err := ApplyThemeRenames(secondFile, outputFile, renames)
+	if err != nil {
+		cmd.PrintErrf("\nError: %v\n", err)
+		return errSilent
+	}
+
+	PrintSuccess(cmd, themesRenamed, "theme(s)", outputFile)
+
+	return nil
+}
+
+func runThemeDedupe(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	inputFile := args[0]
+
+	// Validate input file
+	if err := ValidateInputFile(inputFile); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return exitWith(exitCodeFor(err))
+	}
+
+	duplicates, err := FindDuplicateThemes(inputFile)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return errSilent
+	}
+
+	if len(duplicates.IdenticalThemes) == 0 && len(duplicates.IdenticalColorSchemes) == 0 {
+		cmd.Printf("No duplicate themes in %s\n", inputFile)
+		return nil
+	}
+
+	if len(duplicates.IdenticalThemes) > 0 {
+		cmd.Println("Identical themes:")
+		for _, group := range duplicates.IdenticalThemes {
+			cmd.Printf("  %s\n", strings.Join(group, ", "))
+		}
+	}
+
+	if len(duplicates.IdenticalColorSchemes) > 0 {
+		cmd.Println("Identical color schemes:")
+		for _, group := range duplicates.IdenticalColorSchemes {
+			cmd.Printf("  %s\n", strings.Join(group, ", "))
+		}
+	}
+
+	return nil
+}
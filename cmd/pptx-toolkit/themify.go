@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// themifySlots are the theme slots hardcoded colors can be turned back into, in order
+// of preference when several slots share a value. Hyperlink colors are left out: a
+// fill that happens to match hlink is not a hyperlink.
+var themifySlots = []string{
+	"dk1", "lt1", "dk2", "lt2",
+	"accent1", "accent2", "accent3", "accent4", "accent5", "accent6",
+}
+
+// ThemifyMapping returns the hex → scheme mapping that turns hardcoded colors equal
+// to one of a theme's colors into references to that slot. When several slots have
+// the same value, the first in themifySlots wins.
+func ThemifyMapping(colors ColorScheme) map[string]string {
+	mapping := make(map[string]string)
+	for _, slot := range themifySlots {
+		hex := strings.ToUpper(colors.Get(slot))
+		if !isValidHexColor(hex) {
+			continue
+		}
+		if _, taken := mapping[hex]; !taken {
+			mapping[hex] = slot
+		}
+	}
+	return mapping
+}
+
+// ThemifyMappings returns the ThemifyMapping of every theme used by a slide master,
+// keyed by theme file name (e.g., "theme1.xml"), ready for Options.ThemeMappings
+func ThemifyMappings(pptxPath string) (map[string]map[string]string, error) {
+	themeMappings := make(map[string]map[string]string)
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		masterToTheme, err := buildThemeRelationships(tempDir)
+		if err != nil {
+			return err
+		}
+
+		for _, theme := range masterToTheme {
+			if _, seen := themeMappings[theme]; seen {
+				continue
+			}
+			content, err := os.ReadFile(docPath(tempDir, "theme", theme))
+			if err != nil {
+				return err
+			}
+			parsed, err := parseThemeXML(content, theme)
+			if err != nil {
+				// Not a theme with a color scheme; nothing to map to
+				continue
+			}
+			themeMappings[theme] = ThemifyMapping(parsed.Colors)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return themeMappings, nil
+}
+
+// Themify replaces hardcoded colors (srgbClr) that match a color of the governing
+// theme with references to that theme slot, so they follow the theme from then on.
+// Each part is mapped with its own theme's colors; parts with no single governing
+// theme are left alone. Returns the number of files processed, as ProcessPPTXWithOptions.
+func Themify(inputPath, outputPath string, themeFilter []string, scope string, opts Options) (int, error) {
+	themeMappings, err := ThemifyMappings(inputPath)
+	if err != nil {
+		return 0, err
+	}
+	opts.ThemeMappings = themeMappings
+
+	filesProcessed, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{}, themeFilter, scope, nil, opts)
+	return filesProcessed, err
+}
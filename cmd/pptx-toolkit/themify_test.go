@@ -0,0 +1,127 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThemifyMapping(t *testing.T) {
+	colors := ColorScheme{
+		Dk1: "000000", Lt1: "FFFFFF", Dk2: "1F497D", Lt2: "eeece1",
+		Accent1: "4F81BD", Accent2: "C0504D", Accent3: "4F81BD", Accent4: "8064A2",
+		Accent5: "4BACC6", Accent6: "F79646", Hlink: "0000FF", FolHlink: "800080",
+	}
+
+	got := ThemifyMapping(colors)
+	want := map[string]string{
+		"000000": "dk1", "FFFFFF": "lt1", "1F497D": "dk2", "EEECE1": "lt2",
+		"4F81BD": "accent1", "C0504D": "accent2", "8064A2": "accent4",
+		"4BACC6": "accent5", "F79646": "accent6",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ThemifyMapping() = %v, want %v", got, want)
+	}
+}
+
+func TestThemify(t *testing.T) {
+	slide := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:sld xmlns:a="` + drawingmlNS + `" xmlns:p="` + presentationmlNS + `"><p:cSld><p:spTree>` +
+		`<p:sp><p:spPr><a:solidFill><a:srgbClr val="4f81bd"/></a:solidFill></p:spPr></p:sp>` +
+		`<p:sp><p:spPr><a:solidFill><a:srgbClr val="C0504D"><a:alpha val="50000"/></a:srgbClr></a:solidFill></p:spPr></p:sp>` +
+		`<p:sp><p:spPr><a:solidFill><a:srgbClr val="AABBCC"/></a:solidFill></p:spPr></p:sp>` +
+		`<p:sp><p:spPr><a:solidFill><a:srgbClr val="0000FF"/></a:solidFill></p:spPr></p:sp>` +
+		`</p:spTree></p:cSld></p:sld>`
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides: 1,
+		Parts:  map[string]string{"ppt/slides/slide1.xml": slide},
+	})
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	var replacements int
+	if _, err := Themify(inputPath, outputPath, nil, "all", Options{Replacements: &replacements}); err != nil {
+		t.Fatalf("Themify() error = %v", err)
+	}
+
+	result := string(readZipEntry(t, outputPath, "ppt/slides/slide1.xml"))
+	for _, want := range []string{
+		`<a:schemeClr val="accent1"/>`,
+		`<a:schemeClr val="accent2"><a:alpha val="50000"/></a:schemeClr>`,
+		`<a:srgbClr val="AABBCC"/>`,
+		`<a:srgbClr val="0000FF"/>`, // Hyperlink colors are not themified
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("slide1.xml missing %s:\n%s", want, result)
+		}
+	}
+	if err := VerifyOutput(inputPath, outputPath); err != nil {
+		t.Errorf("VerifyOutput() error = %v", err)
+	}
+}
+
+func TestThemify_PerThemeColors(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("Test file not found")
+	}
+
+	// Hardcode accent1 of each theme into its slides, then themify them back
+	hardcoded := filepath.Join(t.TempDir(), "hardcoded.pptx")
+	themes, err := ReadThemes(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	themeMappings := make(map[string]map[string]string)
+	for _, theme := range themes {
+		themeMappings[theme.FileName] = map[string]string{"accent1": theme.Colors.Accent1}
+	}
+	mappings, err := ThemifyMappings(testPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for theme := range themeMappings {
+		if _, used := mappings[theme]; !used {
+			delete(themeMappings, theme)
+		}
+	}
+	if _, _, err := ProcessPPTXWithOptions(testPPTX, hardcoded, map[string]string{}, nil, "content", nil, Options{ThemeMappings: themeMappings}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := countInParts(t, hardcoded, "<a:srgbClr ")
+
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+	if _, err := Themify(hardcoded, outputPath, nil, "content", Options{}); err != nil {
+		t.Fatalf("Themify() error = %v", err)
+	}
+
+	after := countInParts(t, outputPath, "<a:srgbClr ")
+	if after >= before {
+		t.Errorf("srgbClr count: before %d, after %d; expected themify to reduce it", before, after)
+	}
+	if restored, original := countInParts(t, outputPath, `"accent1"`), countInParts(t, testPPTX, `"accent1"`); restored != original {
+		t.Errorf("accent1 references after themify = %d, want %d (as in the original)", restored, original)
+	}
+}
+
+// countInParts counts occurrences of substr in every XML part outside ppt/theme
+func countInParts(t *testing.T, pptxPath, substr string) int {
+	t.Helper()
+	reader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	count := 0
+	for _, file := range reader.File {
+		if !strings.HasSuffix(file.Name, ".xml") || strings.HasPrefix(file.Name, "ppt/theme/") {
+			continue
+		}
+		count += strings.Count(string(readZipEntry(t, pptxPath, file.Name)), substr)
+	}
+	return count
+}
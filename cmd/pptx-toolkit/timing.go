@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// PhaseTimings records how long each phase of editing a package took (see
+// editPackage): unzipping the input, editing the extracted parts, and writing the
+// output archive
+type PhaseTimings struct {
+	Extract time.Duration
+	Process time.Duration
+	Write   time.Duration
+}
+
+// throughput returns the rate, in MB/s, at which size bytes were processed in elapsed
+func throughput(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(size) / 1e6 / elapsed.Seconds()
+}
+
+// printTiming prints the wall time and throughput of a swap or rename of inputFile
+// to stderr, followed by the time spent in each phase, for --timing
+func printTiming(cmd *cobra.Command, inputFile string, timings PhaseTimings, elapsed time.Duration) {
+	var size int64
+	if info, err := os.Stat(inputFile); err == nil {
+		size = info.Size()
+	}
+
+	cmd.PrintErrf("\nTiming: %s total, %.1f MB at %.1f MB/s\n",
+		elapsed.Round(time.Millisecond), float64(size)/1e6, throughput(size, elapsed))
+	cmd.PrintErrf("  extract  %s\n", timings.Extract.Round(time.Millisecond))
+	cmd.PrintErrf("  process  %s\n", timings.Process.Round(time.Millisecond))
+	cmd.PrintErrf("  write    %s\n", timings.Write.Round(time.Millisecond))
+}
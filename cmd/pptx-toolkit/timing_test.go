@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessPPTX_Timings(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 3, ColorsPerSlide: 4})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	var timings PhaseTimings
+	start := time.Now()
+	_, _, err := ProcessPPTXWithOptions(inputPath, outputPath, map[string]string{"accent1": "FF0000"}, nil, "all", nil, Options{Timings: &timings})
+	if err != nil {
+		t.Fatalf("ProcessPPTXWithOptions failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if timings.Extract <= 0 || timings.Process <= 0 || timings.Write <= 0 {
+		t.Errorf("expected every phase to be timed, got %+v", timings)
+	}
+	if sum := timings.Extract + timings.Process + timings.Write; sum > elapsed {
+		t.Errorf("phases took %s in total, longer than the whole swap (%s)", sum, elapsed)
+	}
+}
+
+func TestRenameColorScheme_Timings(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+	var timings PhaseTimings
+	if _, err := RenameColorSchemeOutcomes(inputPath, outputPath, "Renamed", nil, Options{Timings: &timings}); err != nil {
+		t.Fatalf("RenameColorSchemeOutcomes failed: %v", err)
+	}
+	if timings.Extract <= 0 || timings.Process <= 0 || timings.Write <= 0 {
+		t.Errorf("expected every phase to be timed, got %+v", timings)
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	tests := []struct {
+		size    int64
+		elapsed time.Duration
+		want    float64
+	}{
+		{10_000_000, 2 * time.Second, 5},
+		{500_000, 250 * time.Millisecond, 2},
+		{1_000_000, 0, 0}, // Too fast to measure
+	}
+	for _, tt := range tests {
+		if got := throughput(tt.size, tt.elapsed); got != tt.want {
+			t.Errorf("throughput(%d, %s) = %v, want %v", tt.size, tt.elapsed, got, tt.want)
+		}
+	}
+}
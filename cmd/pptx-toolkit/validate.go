@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// ColorProblem describes what is wrong with a referenced theme slot
+type ColorProblem string
+
+const (
+	// ProblemUndefined means the theme's color scheme has no color for the slot;
+	// readers fall back to black
+	ProblemUndefined ColorProblem = "undefined"
+
+	// ProblemBlack means the slot is 000000 although it is not a dark slot, which
+	// usually means a placeholder value was never filled in
+	ProblemBlack ColorProblem = "black"
+)
+
+// ColorIssue is a suspicious theme slot that the presentation references
+type ColorIssue struct {
+	Theme      string       `json:"theme"`      // Theme file (e.g., "theme1.xml")
+	Slot       string       `json:"slot"`       // Theme slot (e.g., "accent6")
+	Problem    ColorProblem `json:"problem"`    // What is wrong with the slot
+	References []string     `json:"references"` // Names the slot is referenced by (e.g., "bg2", "lt2")
+	Slides     []int        `json:"slides"`     // Visual slide numbers referencing the slot
+	OtherParts []string     `json:"otherParts"` // Parts not tied to a slide (masters, layouts)
+}
+
+// String describes the issue in one line, without where it is referenced
+func (issue ColorIssue) String() string {
+	via := ""
+	if len(issue.References) > 1 || (len(issue.References) == 1 && issue.References[0] != issue.Slot) {
+		via = fmt.Sprintf(" (referenced as %s)", strings.Join(issue.References, ", "))
+	}
+	switch issue.Problem {
+	case ProblemUndefined:
+		return fmt.Sprintf("%s: %s is not defined, so it renders black%s", issue.Theme, issue.Slot, via)
+	default:
+		return fmt.Sprintf("%s: %s is 000000, likely an unfilled placeholder%s", issue.Theme, issue.Slot, via)
+	}
+}
+
+// darkSlots are the theme slots for which black is an ordinary value
+var darkSlots = map[string]bool{"dk1": true, "dk2": true}
+
+// definedSchemeSlots returns the slots of a theme's color scheme that carry a color
+// value: an srgbClr val, or a sysClr with its cached lastClr
+func definedSchemeSlots(themeXML []byte) (map[string]bool, error) {
+	doc, err := xmlquery.Parse(bytes.NewReader(themeXML))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	defined := make(map[string]bool)
+	for _, name := range schemeColorNames {
+		elem := xmlquery.FindOne(doc, fmt.Sprintf("//*[local-name()='clrScheme']/*[local-name()='%s']", name))
+		if elem == nil {
+			continue
+		}
+		if node := elem.SelectElement("*[local-name()='srgbClr']"); node != nil && isValidHexColor(node.SelectAttr("val")) {
+			defined[name] = true
+		} else if node := elem.SelectElement("*[local-name()='sysClr']"); node != nil && isValidHexColor(node.SelectAttr("lastClr")) {
+			defined[name] = true
+		}
+	}
+	return defined, nil
+}
+
+// themeSlotProblems returns the problem, if any, of every slot of a theme
+func themeSlotProblems(themeXML []byte, fileName string) (map[string]ColorProblem, error) {
+	theme, err := parseThemeXML(themeXML, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defined, err := definedSchemeSlots(themeXML)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make(map[string]ColorProblem)
+	for _, name := range schemeColorNames {
+		switch {
+		case !defined[name]:
+			problems[name] = ProblemUndefined
+		case !darkSlots[name] && strings.EqualFold(theme.Colors.Get(name), "000000"):
+			problems[name] = ProblemBlack
+		}
+	}
+	return problems, nil
+}
+
+// FindColorIssues cross-checks the scheme color references of an extracted
+// presentation against the theme governing each part, and returns the referenced
+// slots that are undefined or suspiciously black. Aliases (bg1, tx1, ...) are
+// resolved through the color map in effect. Parts no single theme governs are not
+// checked. Issues are ordered by theme, then slot.
+func FindColorIssues(tempDir string) ([]ColorIssue, error) {
+	masterToTheme, err := buildThemeRelationships(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	layoutToMaster, _ := buildLayoutToMasterMapping(tempDir)
+
+	partThemes, err := buildPartThemes(tempDir, layoutToMaster, masterToTheme)
+	if err != nil {
+		return nil, err
+	}
+	partToSlides, err := buildPartToSlides(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	partColorMaps, err := buildPartColorMaps(tempDir, layoutToMaster)
+	if err != nil {
+		return nil, err
+	}
+	masterMaps, err := readMasterColorMaps(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each theme's slot problems, and the color map of the first master using it
+	masters := make([]string, 0, len(masterToTheme))
+	for master := range masterToTheme {
+		masters = append(masters, master)
+	}
+	sortNatural(masters)
+
+	themeProblems := make(map[string]map[string]ColorProblem)
+	themeColorMaps := make(map[string]ColorMap)
+	for _, master := range masters {
+		theme := masterToTheme[master]
+		if _, seen := themeProblems[theme]; seen {
+			continue
+		}
+		content, err := os.ReadFile(docPath(tempDir, "theme", theme))
+		if err != nil {
+			return nil, err
+		}
+		problems, err := themeSlotProblems(content, theme)
+		if err != nil {
+			// Not a theme with a color scheme; nothing to check against
+			continue
+		}
+		themeProblems[theme] = problems
+
+		colorMap, ok := masterMaps[master]
+		if !ok {
+			colorMap = defaultColorMap
+		}
+		themeColorMaps[theme] = colorMap
+	}
+
+	type issueKey struct{ theme, slot string }
+	found := make(map[issueKey]*ColorIssue)
+	references := make(map[issueKey]map[string]bool)
+	slides := make(map[issueKey]map[int]bool)
+	otherParts := make(map[issueKey]map[string]bool)
+
+	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(tempDir, path)
+		relPath = filepath.ToSlash(relPath)
+
+		theme, ok := partThemes[relPath]
+		problems := themeProblems[theme]
+		if !ok || len(problems) == 0 {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		colorMap := themeColorMaps[theme]
+		if maps, ok := partColorMaps[relPath]; ok {
+			colorMap = maps.Effective
+		}
+
+		for _, ref := range ExtractColors(content) {
+			if ref.Kind != ColorRefScheme {
+				continue
+			}
+			name := ref.Value
+			slot := colorMap.Resolve(name)
+			problem, ok := problems[slot]
+			if !ok {
+				continue
+			}
+
+			key := issueKey{theme, slot}
+			if found[key] == nil {
+				found[key] = &ColorIssue{Theme: theme, Slot: slot, Problem: problem}
+				references[key] = make(map[string]bool)
+				slides[key] = make(map[int]bool)
+				otherParts[key] = make(map[string]bool)
+			}
+			references[key][name] = true
+			if slideNums, ok := partToSlides[relPath]; ok {
+				for _, slideNum := range slideNums {
+					slides[key][slideNum] = true
+				}
+			} else {
+				otherParts[key][relPath] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]ColorIssue, 0, len(found))
+	for key, issue := range found {
+		for name := range references[key] {
+			issue.References = append(issue.References, name)
+		}
+		sort.Strings(issue.References)
+		for slideNum := range slides[key] {
+			issue.Slides = append(issue.Slides, slideNum)
+		}
+		sort.Ints(issue.Slides)
+		for part := range otherParts[key] {
+			issue.OtherParts = append(issue.OtherParts, part)
+		}
+		sortNatural(issue.OtherParts)
+		issues = append(issues, *issue)
+	}
+
+	slotOrder := make(map[string]int, len(schemeColorNames))
+	for i, name := range schemeColorNames {
+		slotOrder[name] = i
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Theme != issues[j].Theme {
+			return naturalLess(issues[i].Theme, issues[j].Theme)
+		}
+		return slotOrder[issues[i].Slot] < slotOrder[issues[j].Slot]
+	})
+
+	return issues, nil
+}
+
+// ValidateColors is FindColorIssues for a PPTX file
+func ValidateColors(pptxPath string) ([]ColorIssue, error) {
+	var issues []ColorIssue
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		var err error
+		issues, err = FindColorIssues(tempDir)
+		return err
+	})
+	return issues, err
+}
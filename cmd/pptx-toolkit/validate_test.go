@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThemeSlotProblems(t *testing.T) {
+	themeXML := strings.NewReplacer(
+		`<a:accent2><a:srgbClr val="C0504D"/></a:accent2>`, ``,
+		`<a:accent3><a:srgbClr val="9BBB59"/></a:accent3>`, `<a:accent3/>`,
+		`<a:lt2><a:srgbClr val="EEECE1"/></a:lt2>`, `<a:lt2><a:srgbClr val="000000"/></a:lt2>`,
+		`<a:dk2><a:srgbClr val="1F497D"/></a:dk2>`, `<a:dk2><a:srgbClr val="000000"/></a:dk2>`,
+	).Replace(syntheticThemeXML("Theme", "Scheme"))
+
+	got, err := themeSlotProblems([]byte(themeXML), "theme1.xml")
+	if err != nil {
+		t.Fatalf("themeSlotProblems() error = %v", err)
+	}
+	want := map[string]ColorProblem{
+		"accent2": ProblemUndefined,
+		"accent3": ProblemUndefined,
+		"lt2":     ProblemBlack,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("themeSlotProblems() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateColors(t *testing.T) {
+	themeXML := strings.NewReplacer(
+		`<a:accent2><a:srgbClr val="C0504D"/></a:accent2>`, ``,
+		`<a:lt2><a:srgbClr val="EEECE1"/></a:lt2>`, `<a:lt2><a:srgbClr val="000000"/></a:lt2>`,
+	).Replace(syntheticThemeXML("Theme", "Scheme"))
+
+	t.Run("suspicious references", func(t *testing.T) {
+		inputPath := writeSyntheticPPTX(t, syntheticDeck{
+			Slides:         3,
+			ColorsPerSlide: 4,
+			Parts: map[string]string{
+				"ppt/theme/theme1.xml":  themeXML,
+				"ppt/slides/slide3.xml": strings.Replace(syntheticSlideXML(1), `val="accent1"`, `val="bg2"`, 1),
+			},
+		})
+
+		got, err := ValidateColors(inputPath)
+		if err != nil {
+			t.Fatalf("ValidateColors() error = %v", err)
+		}
+		want := []ColorIssue{
+			{Theme: "theme1.xml", Slot: "lt2", Problem: ProblemBlack, References: []string{"bg2"}, Slides: []int{3}},
+			{Theme: "theme1.xml", Slot: "accent2", Problem: ProblemUndefined, References: []string{"accent2"}, Slides: []int{1, 2}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ValidateColors() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("unreferenced slots are not reported", func(t *testing.T) {
+		inputPath := writeSyntheticPPTX(t, syntheticDeck{
+			Slides:         1,
+			ColorsPerSlide: 2,
+			Parts:          map[string]string{"ppt/theme/theme1.xml": themeXML},
+		})
+
+		got, err := ValidateColors(inputPath)
+		if err != nil {
+			t.Fatalf("ValidateColors() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("ValidateColors() = %+v, want no issues", got)
+		}
+	})
+}
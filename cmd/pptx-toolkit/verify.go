@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// VerifyOutput re-opens a written PPTX and checks it is still structurally sound.
+//
+// It confirms that:
+//   - the output is a readable ZIP archive
+//   - every entry of the input (including directories, docProps, and _rels/.rels) is present
+//   - every XML part (including .rels) is well-formed
+//   - the same number of themes can be read as from the input
+//   - the same number of slides can be mapped as from the input
+//
+// This costs a second pass over the output, so callers should gate it behind an option.
+func VerifyOutput(inputPath, outputPath string) error {
+	zipReader, err := zip.OpenReader(outputPath)
+	if err != nil {
+		return fmt.Errorf("output is not a valid ZIP archive: %w", err)
+	}
+	defer zipReader.Close()
+
+	// Every input entry must still be present
+	inputReader, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	outputEntries := make(map[string]bool, len(zipReader.File))
+	for _, file := range zipReader.File {
+		outputEntries[file.Name] = true
+	}
+	var missing []string
+	for _, file := range inputReader.File {
+		if !outputEntries[file.Name] {
+			missing = append(missing, file.Name)
+		}
+	}
+	inputReader.Close()
+	if len(missing) > 0 {
+		return fmt.Errorf("output is missing entries: %s", strings.Join(missing, ", "))
+	}
+
+	// Every XML part must still parse
+	for _, file := range zipReader.File {
+		if !strings.HasSuffix(file.Name, ".xml") && !strings.HasSuffix(file.Name, ".rels") {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		err = checkWellFormedXML(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s is not well-formed XML: %w", file.Name, err)
+		}
+	}
+
+	// Theme count must match the input
+	inputThemes, err := ReadThemes(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input themes: %w", err)
+	}
+	outputThemes, err := ReadThemes(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read output themes: %w", err)
+	}
+	if len(inputThemes) != len(outputThemes) {
+		return fmt.Errorf("theme count changed: input has %d, output has %d",
+			len(inputThemes), len(outputThemes))
+	}
+
+	// Slide count must match the input
+	inputSlides, inputErr := countSlides(inputPath)
+	outputSlides, outputErr := countSlides(outputPath)
+	if inputErr == nil {
+		if outputErr != nil {
+			return fmt.Errorf("failed to map output slides: %w", outputErr)
+		}
+		if inputSlides != outputSlides {
+			return fmt.Errorf("slide count changed: input has %d, output has %d",
+				inputSlides, outputSlides)
+		}
+	}
+
+	return nil
+}
+
+// checkWellFormedXML reads an XML stream to the end, returning the first syntax error
+func checkWellFormedXML(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// countSlides extracts a PPTX and returns the number of slides in its slide mapping
+func countSlides(pptxPath string) (int, error) {
+	count := 0
+	err := withExtractedPPTX(pptxPath, func(tempDir string) error {
+		mapping, err := BuildSlideMapping(tempDir)
+		if err != nil {
+			return err
+		}
+		count = len(mapping)
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyOutput(t *testing.T) {
+	testPPTX := filepath.Join("testdata", "test.pptx")
+
+	if _, err := os.Stat(testPPTX); os.IsNotExist(err) {
+		t.Skip("test.pptx fixture not found")
+	}
+
+	t.Run("processed output verifies", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "output.pptx")
+
+		mapping := map[string]string{"accent1": "FF0000", "AABBCC": "accent2"}
+		if _, _, err := ProcessPPTX(testPPTX, outputPath, mapping, nil, "all", nil); err != nil {
+			t.Fatalf("ProcessPPTX failed: %v", err)
+		}
+
+		if err := VerifyOutput(testPPTX, outputPath); err != nil {
+			t.Errorf("VerifyOutput() error = %v", err)
+		}
+	})
+
+	t.Run("malformed XML part fails", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "broken.pptx")
+		copyZipWithOverride(t, testPPTX, outputPath, "ppt/slides/slide1.xml", []byte(`<p:sld><a:schemeClr val="accent1"></p:sld>`))
+
+		err := VerifyOutput(testPPTX, outputPath)
+		if err == nil {
+			t.Fatal("expected error for malformed XML, got nil")
+		}
+		if !strings.Contains(err.Error(), "ppt/slides/slide1.xml") {
+			t.Errorf("expected error to name the broken part, got: %v", err)
+		}
+	})
+
+	t.Run("not a zip fails", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "garbage.pptx")
+		if err := os.WriteFile(outputPath, []byte("not a zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := VerifyOutput(testPPTX, outputPath); err == nil {
+			t.Error("expected error for non-ZIP output, got nil")
+		}
+	})
+}
+
+// copyZipWithOverride copies a ZIP archive, replacing the content of one entry
+func copyZipWithOverride(t *testing.T, srcPath, dstPath, name string, content []byte) {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipReader.Close()
+
+	outFile, err := os.Create(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outFile.Close()
+
+	zipWriter := zip.NewWriter(outFile)
+	for _, file := range zipReader.File {
+		w, err := zipWriter.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if file.Name == name {
+			if _, err := w.Write(content); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.Copy(w, rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyOutput_MissingEntry(t *testing.T) {
+	inputPath := writeSyntheticPPTX(t, syntheticDeck{Slides: 1, ColorsPerSlide: 2})
+	outputPath := writeSyntheticPPTX(t, syntheticDeck{
+		Slides:         1,
+		ColorsPerSlide: 2,
+		Parts:          map[string]string{"_rels/.rels": ""},
+	})
+
+	err := VerifyOutput(inputPath, outputPath)
+	if err == nil || !strings.Contains(err.Error(), "_rels/.rels") {
+		t.Errorf("expected missing _rels/.rels error, got %v", err)
+	}
+}